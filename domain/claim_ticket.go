@@ -0,0 +1,14 @@
+package domain
+
+import "coupon_be/domain/enums"
+
+type ClaimTicket struct {
+	BaseModel
+
+	TicketID   string
+	CouponName string
+	Username   string
+	Status     enums.ClaimTicketStatus
+	Reason     string
+	Attempts   int
+}
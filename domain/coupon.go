@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"coupon_be/domain/enums"
+
 	"gorm.io/gorm"
 )
 
@@ -12,10 +14,23 @@ type Coupon struct {
 	Amount          uint64
 	RemainingAmount uint64
 
+	// ClaimStrategy overrides the globally configured claim strategy when it is not empty.
+	ClaimStrategy enums.ClaimStrategy
+	// Version is incremented on every stock update, used by the optimistic claim strategy.
+	Version uint64
+	// LastFence is the fencing token of the latest stock update made under the coupon claim lock.
+	LastFence int64
+	// ShardCount is the number of shards the stock is split into, the stock is not sharded when it is below 2.
+	ShardCount int
+
 	// Association
 	ClaimedBy []*User `gorm:"many2many:user_claims;"`
 }
 
+func (c *Coupon) IsSharded() bool {
+	return c != nil && c.ShardCount > 1
+}
+
 func (c *Coupon) IsUsable() bool {
 	if c == nil {
 		return false
@@ -0,0 +1,13 @@
+package domain
+
+// CouponShard holds a part of the stock of a sharded coupon, with its own counter row and claim lock.
+type CouponShard struct {
+	BaseModel
+
+	CouponID        uint64
+	ShardIndex      int
+	Amount          uint64
+	RemainingAmount uint64
+	// LastFence is the fencing token of the latest stock update made under the shard claim lock.
+	LastFence int64
+}
@@ -0,0 +1,34 @@
+package domain
+
+// CouponStock is the stock of a coupon next to the number of its claims.
+type CouponStock struct {
+	CouponID        uint64
+	CouponName      string
+	Amount          uint64
+	RemainingAmount uint64
+	ClaimedCount    uint64
+	// ShardCount is above 1 when RemainingAmount is the sum of the shards of the coupon.
+	ShardCount int
+}
+
+// ExpectedRemainingAmount is the remaining amount derived from the claims, negative when the coupon is over-claimed.
+func (s *CouponStock) ExpectedRemainingAmount() int64 {
+	return int64(s.Amount) - int64(s.ClaimedCount)
+}
+
+// IsDrifted reports whether the remaining amount disagrees with the claims.
+func (s *CouponStock) IsDrifted() bool {
+	return s.ExpectedRemainingAmount() != int64(s.RemainingAmount)
+}
+
+// CouponStockDrift records a drift found by the stock reconciler.
+type CouponStockDrift struct {
+	BaseModel
+
+	CouponID                uint64
+	Amount                  uint64
+	ClaimedCount            uint64
+	RemainingAmount         uint64
+	ExpectedRemainingAmount int64
+	Corrected               bool
+}
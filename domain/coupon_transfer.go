@@ -0,0 +1,11 @@
+package domain
+
+// CouponTransfer records a user claim moved from one user to another.
+type CouponTransfer struct {
+	BaseModel
+
+	UserClaimID uint64
+	CouponID    uint64
+	FromUserID  uint64
+	ToUserID    uint64
+}
@@ -0,0 +1,20 @@
+package enums
+
+// ClaimStrategy determines how concurrent claims on a single coupon are kept from over-claiming its stock.
+type ClaimStrategy string
+
+const (
+	// ClaimStrategyRedisLock serialises claims of a coupon with a distributed redis lock.
+	ClaimStrategyRedisLock ClaimStrategy = "redis_lock"
+	// ClaimStrategyRowLock relies on postgres only, using a conditional update that locks the coupon row.
+	ClaimStrategyRowLock ClaimStrategy = "row_lock"
+	// ClaimStrategyOptimistic compares the coupon version column on update and retries on conflicts.
+	ClaimStrategyOptimistic ClaimStrategy = "optimistic"
+	// ClaimStrategyRedisAtomic keeps the stock and claimants in redis, updated by a single lua script.
+	// Claims are persisted to postgres asynchronously by the claim writer.
+	ClaimStrategyRedisAtomic ClaimStrategy = "redis_atomic"
+)
+
+func (s ClaimStrategy) String() string {
+	return string(s)
+}
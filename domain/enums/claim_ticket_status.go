@@ -0,0 +1,14 @@
+package enums
+
+// ClaimTicketStatus is the processing status of an asynchronous claim.
+type ClaimTicketStatus string
+
+const (
+	ClaimTicketStatusPending   ClaimTicketStatus = "pending"
+	ClaimTicketStatusSucceeded ClaimTicketStatus = "succeeded"
+	ClaimTicketStatusFailed    ClaimTicketStatus = "failed"
+)
+
+func (s ClaimTicketStatus) String() string {
+	return string(s)
+}
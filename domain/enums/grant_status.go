@@ -0,0 +1,17 @@
+package enums
+
+// GrantStatus is the outcome of granting a coupon to a single user.
+type GrantStatus string
+
+const (
+	GrantStatusGranted        GrantStatus = "granted"
+	GrantStatusAlreadyClaimed GrantStatus = "already_claimed"
+	GrantStatusUserNotFound   GrantStatus = "user_not_found"
+	GrantStatusDuplicate      GrantStatus = "duplicate"
+	GrantStatusQuotaExceeded  GrantStatus = "quota_exceeded"
+	GrantStatusFailed         GrantStatus = "failed"
+)
+
+func (s GrantStatus) String() string {
+	return string(s)
+}
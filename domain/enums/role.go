@@ -0,0 +1,17 @@
+package enums
+
+// Role determines what a user is permitted to do.
+type Role string
+
+const (
+	// RoleAdmin manages everything, including the roles of the users and the locks.
+	RoleAdmin Role = "admin"
+	// RoleOperator manages the coupons, their grants, stock and claim queue.
+	RoleOperator Role = "operator"
+	// RoleCustomer claims and transfers coupons, the role of every registered user.
+	RoleCustomer Role = "customer"
+)
+
+func (r Role) String() string {
+	return string(r)
+}
@@ -1,6 +1,10 @@
 package domain
 
-import "gorm.io/gorm"
+import (
+	"coupon_be/domain/enums"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	BaseModel
@@ -9,4 +13,5 @@ type User struct {
 	Username  string
 	FullName  string
 	Password  string
+	Role      enums.Role
 }
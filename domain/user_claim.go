@@ -1,8 +1,19 @@
 package domain
 
+import "time"
+
 type UserClaim struct {
 	BaseModel
 
 	UserID   uint64
 	CouponID uint64
+
+	// TransferCount is the number of times the claim has been transferred to another user.
+	TransferCount int
+	// RedeemedAt is set once the coupon is redeemed, a redeemed claim can not be transferred anymore.
+	RedeemedAt *time.Time
+}
+
+func (c *UserClaim) IsRedeemed() bool {
+	return c != nil && c.RedeemedAt != nil
 }
@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"coupon_be/request"
+	"coupon_be/service/user"
+	"coupon_be/shared/auth"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
+	"coupon_be/shared/fhttp"
+	"coupon_be/shared/fhttp/middleware"
+	"coupon_be/util"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Controller manages the operations on the internals of the service, such as the held locks, and the roles of the
+// users.
+type Controller struct {
+	locks redis.ILockInspector
+	user  user.Service
+}
+
+func (c *Controller) RegisterRoutes(r *mux.Router) {
+	r.Handle("/locks", middleware.Authorized(auth.PermissionManageLocks, c.Locks)).Methods(http.MethodGet)
+	r.Handle("/locks/{key}", middleware.Authorized(auth.PermissionManageLocks, c.ForceReleaseLock)).Methods(http.MethodDelete)
+	r.Handle("/users/{username}/role", middleware.Authorized(auth.PermissionManageRoles, c.AssignRole)).Methods(http.MethodPut)
+}
+
+func (c *Controller) Locks(r *http.Request) (*fhttp.Response, error) {
+	locks, err := c.locks.HeldLocks(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	return &fhttp.Response{
+		Data:    locks,
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("%d locks are held.", len(locks)),
+	}, nil
+}
+
+func (c *Controller) ForceReleaseLock(r *http.Request) (*fhttp.Response, error) {
+	key := mux.Vars(r)["key"]
+
+	lock, err := c.locks.ForceRelease(r.Context(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fhttp.Response{
+		Data:    lock,
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("Lock %s is force-released.", key),
+	}, nil
+}
+
+func (c *Controller) AssignRole(r *http.Request) (*fhttp.Response, error) {
+	ctx := r.Context()
+
+	input := request.AssignRole{Username: mux.Vars(r)["username"]}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, fhttp.NewErrorResponse(
+			http.StatusUnprocessableEntity,
+			sharedErrs.ErrKindValidation.String(),
+			fmt.Sprintf("Invalid request body: %v", err))
+	}
+
+	if err := util.Validate(input); err != nil {
+		return nil, err
+	}
+
+	result, err := c.user.AssignRole(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fhttp.Response{
+		Data:    result,
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("User %s is assigned the %s role.", result.Username, result.Role),
+	}, nil
+}
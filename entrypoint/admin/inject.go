@@ -0,0 +1,26 @@
+package admin
+
+import (
+	"context"
+	"coupon_be/repository"
+	"coupon_be/service/user"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
+
+	"gorm.io/gorm"
+)
+
+// NewController initializes a new Controller instance.
+func NewController(ctx context.Context, repository repository.Repository, writerDB *gorm.DB) (*Controller, error) {
+	locks, err := redis.GetLockInspector(ctx)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplication, "Fail to initiate lock inspector", err)
+	}
+
+	userService, err := user.NewService(repository, writerDB)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindCodeInjection, "Fail to initiate user service", err)
+	}
+
+	return &Controller{locks: locks, user: userService}, nil
+}
@@ -2,18 +2,32 @@ package entrypoint
 
 import (
 	"context"
+	"coupon_be/entrypoint/admin"
 	"coupon_be/entrypoint/coupon"
+	"coupon_be/entrypoint/health"
 	"coupon_be/entrypoint/user"
 	"coupon_be/repository"
+	"coupon_be/shared/auth"
+	"coupon_be/shared/external/cache"
 	"coupon_be/shared/external/database"
+	"coupon_be/shared/external/ratelimit"
+	"coupon_be/shared/external/redis"
 	"coupon_be/shared/fhttp/middleware"
 	"coupon_be/util/config"
 	"coupon_be/util/logger"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
+const (
+	defaultIdempotencyTTL         = 24 * time.Hour
+	defaultIdempotencyInFlightTTL = time.Minute
+	defaultCacheTTL               = 5 * time.Second
+)
+
 // Controller - Interface for controllers to implement for route registration
 type Controller interface {
 	RegisterRoutes(router *mux.Router)
@@ -37,19 +51,30 @@ func StartControllers(router *mux.Router) error {
 
 	repo := repository.NewRepository(gormDB)
 
+	redis.CheckNamespace(ctx)
+
 	// Initialise all controllers
 	userController, err := user.NewController(ctx, repo, gormDB)
 	if err != nil {
 		return err
 	}
-	couponController, err := coupon.NewController(ctx, repo, gormDB)
+	couponController, err := coupon.NewController(ctx, cachedRepository(ctx, repo), gormDB)
+	if err != nil {
+		return err
+	}
+
+	adminController, err := admin.NewController(ctx, repo, gormDB)
 	if err != nil {
 		return err
 	}
 
+	healthController := health.NewController()
+
 	// register routes
+	healthController.RegisterRoutes(router.PathPrefix("/health").Subrouter())
 	userController.RegisterRoutes(router.PathPrefix("/users").Subrouter())
 	couponController.RegisterRoutes(router.PathPrefix("/coupons").Subrouter())
+	adminController.RegisterRoutes(router.PathPrefix("/admin").Subrouter())
 
 	return nil
 }
@@ -59,10 +84,15 @@ func Initialise() *mux.Router {
 
 	// Register Middlewares
 	router.Use(middleware.CorrelationID)
+	router.Use(clientAddress())
 	router.Use(middleware.ResponseTime)
 	router.Use(middleware.PanicRecovery())
 	router.Use(middleware.LogRequest)
 	router.Use(middleware.LogResponse)
+	router.Use(authenticate())
+	router.Use(middleware.CacheBypass)
+	router.Use(rateLimit())
+	router.Use(idempotency())
 	router = router.PathPrefix(config.Env().App.APIPrefix).Subrouter()
 
 	if err := StartControllers(router); err != nil {
@@ -71,3 +101,125 @@ func Initialise() *mux.Router {
 
 	return router
 }
+
+func idempotency() mux.MiddlewareFunc {
+	ttl := defaultIdempotencyTTL
+	if data := config.Env().Idempotency.TTL; data != "" {
+		var err error
+		if ttl, err = time.ParseDuration(data); err != nil {
+			logger.L().Fatal(fmt.Sprintf("invalid idempotency ttl: %v", err))
+		}
+	}
+
+	inFlightTTL := defaultIdempotencyInFlightTTL
+	if data := config.Env().Idempotency.InFlightTTL; data != "" {
+		var err error
+		if inFlightTTL, err = time.ParseDuration(data); err != nil || inFlightTTL <= 0 {
+			logger.L().Fatal(fmt.Sprintf("invalid idempotency in flight ttl %q", data))
+		}
+	}
+
+	store, err := redis.GetIdempotencyStore(context.Background())
+	if err != nil {
+		// keep serving without replays rather than failing the startup on an unreachable redis
+		logger.L().Error(fmt.Sprintf("failed to initiate idempotency store, idempotency keys are ignored: %v", err))
+
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	return middleware.Idempotency(store, ttl, inFlightTTL)
+}
+
+func clientAddress() mux.MiddlewareFunc {
+	trustedProxies, err := middleware.ParseTrustedProxies(config.Env().App.TrustedProxies)
+	if err != nil {
+		logger.L().Fatal(fmt.Sprintf("invalid trusted proxies: %v", err))
+	}
+
+	return middleware.ClientAddress(trustedProxies)
+}
+
+func authenticate() mux.MiddlewareFunc {
+	tokens, err := auth.GetTokenManager()
+	if err != nil {
+		logger.L().Fatal(fmt.Sprintf("failed to initiate token manager: %v", err))
+	}
+
+	return middleware.Authenticate(tokens)
+}
+
+func rateLimit() mux.MiddlewareFunc {
+	rateLimitConfig := config.Env().RateLimit
+
+	rules := make([]*middleware.RateLimitRule, len(rateLimitConfig.Rules))
+	for i, rule := range rateLimitConfig.Rules {
+		window, err := time.ParseDuration(rule.Window)
+		if err != nil || window <= 0 {
+			logger.L().Fatal(fmt.Sprintf("invalid rate limit window %q of %s %s", rule.Window, rule.Method, rule.Path))
+		}
+
+		key := middleware.RateLimitKey(rule.Key)
+		switch key {
+		case middleware.RateLimitKeyIP, middleware.RateLimitKeyUser, middleware.RateLimitKeyClaimUser:
+		default:
+			logger.L().Fatal(fmt.Sprintf("unknown rate limit key %q of %s %s", rule.Key, rule.Method, rule.Path))
+		}
+
+		if rule.Limit <= 0 {
+			logger.L().Fatal(fmt.Sprintf("invalid rate limit %d of %s %s", rule.Limit, rule.Method, rule.Path))
+		}
+
+		rules[i] = &middleware.RateLimitRule{
+			Method: rule.Method,
+			Path:   rule.Path,
+			Key:    key,
+			Limit:  rule.Limit,
+			Window: window,
+		}
+	}
+
+	limiter, err := ratelimit.GetLimiter(context.Background())
+	if err != nil {
+		logger.L().Fatal(fmt.Sprintf("failed to initiate rate limiter: %v", err))
+	}
+
+	return middleware.RateLimit(limiter, rules)
+}
+
+// cachedRepository returns the repository reading the coupon detail and listing through the cache when it is enabled.
+func cachedRepository(ctx context.Context, repo repository.Repository) repository.Repository {
+	cacheConfig := config.Env().Cache
+	if !cacheConfig.Enabled {
+		return repo
+	}
+
+	ttl := repository.CacheTTL{
+		Detail: cacheTTL(cacheConfig.DetailTTL),
+		List:   cacheTTL(cacheConfig.ListTTL),
+	}
+
+	c, err := cache.GetCache(ctx)
+	if err != nil {
+		// keep serving from postgres rather than failing the startup on an unreachable redis
+		logger.L().Error(fmt.Sprintf("failed to initiate cache, the coupon reads are not cached: %v", err))
+
+		return repo
+	}
+
+	go c.Listen(ctx)
+
+	return repository.NewCachedRepository(repo, c, ttl)
+}
+
+func cacheTTL(data string) time.Duration {
+	if data == "" {
+		return defaultCacheTTL
+	}
+
+	ttl, err := time.ParseDuration(data)
+	if err != nil || ttl <= 0 {
+		logger.L().Fatal(fmt.Sprintf("invalid cache ttl %q", data))
+	}
+
+	return ttl
+}
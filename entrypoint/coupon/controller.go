@@ -1,31 +1,49 @@
 package coupon
 
 import (
+	"context"
 	"coupon_be/request"
 	"coupon_be/service/coupon"
+	"coupon_be/shared/auth"
 	sharedErrs "coupon_be/shared/errors"
-	"coupon_be/shared/external/redis"
 	"coupon_be/shared/fhttp"
+	"coupon_be/shared/fhttp/middleware"
 	"coupon_be/util"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
 
+const defaultDeadLetterLimit = 50
+
 // Controller manages the authentication operations, such as login, logout, etc.
 type Controller struct {
-	coupon    coupon.Service
-	redisLock redis.ILock
+	coupon     coupon.Service
+	asyncClaim bool
 }
 
 func (c *Controller) RegisterRoutes(r *mux.Router) {
 	r.Handle("", fhttp.AppHandler(c.Index)).Methods(http.MethodGet)
 	r.Handle("/{coupon_name}", fhttp.AppHandler(c.Detail)).Methods(http.MethodGet)
-	r.Handle("", fhttp.AppHandler(c.Store)).Methods(http.MethodPost)
-	r.Handle("/claim", fhttp.AppHandler(c.Claim)).Methods(http.MethodPost)
+	r.Handle("", middleware.Authorized(auth.PermissionManageCoupons, c.Store)).Methods(http.MethodPost)
+	r.Handle("/claim", middleware.Authorized(auth.PermissionClaimCoupons, c.Claim)).Methods(http.MethodPost)
+	r.Handle("/claim/bundle", middleware.Authorized(auth.PermissionClaimCoupons, c.ClaimBundle)).Methods(http.MethodPost)
+	r.Handle("/transfer", middleware.Authorized(auth.PermissionClaimCoupons, c.Transfer)).Methods(http.MethodPost)
+	r.Handle("/{coupon_name}/grants", middleware.Authorized(auth.PermissionManageCoupons, c.Grant)).Methods(http.MethodPost)
+	r.Handle("/reconcile", middleware.Authorized(auth.PermissionManageCoupons, c.Reconcile)).Methods(http.MethodPost)
+	r.Handle("/claims/tickets/{ticket_id}", middleware.Authorized(auth.PermissionClaimCoupons, c.ClaimTicket)).Methods(http.MethodGet)
+	r.Handle("/claims/dead-letters", middleware.Authorized(auth.PermissionManageCoupons, c.ClaimDeadLetters)).Methods(http.MethodGet)
+	r.Handle("/claims/dead-letters/{id}/replay", middleware.Authorized(auth.PermissionManageCoupons, c.ReplayClaimDeadLetter)).Methods(http.MethodPost)
 }
 
 func (c *Controller) Index(r *http.Request) (*fhttp.Response, error) {
@@ -123,18 +141,34 @@ func (c *Controller) Claim(r *http.Request) (*fhttp.Response, error) {
 			fmt.Sprintf("Invalid request body: %v", err))
 	}
 
+	var err error
+	if input.Username, err = actingUsername(ctx, input.Username); err != nil {
+		return nil, err
+	}
+
 	if err := util.Validate(input); err != nil {
 		return nil, err
 	}
 
-	couponClaimKey := "claim:coupon:%s"
-	err := c.redisLock.WithLock(ctx, fmt.Sprintf(couponClaimKey, input.CouponName), func() error {
-		if err := c.coupon.Claim(ctx, &input); err != nil {
-			return err
+	// a sold-out coupon is rejected before the claim takes the lock or reaches postgres
+	if err := c.coupon.CheckSoldOut(ctx, input.CouponName); err != nil {
+		return nil, err
+	}
+
+	if c.asyncClaim {
+		ticket, err := c.coupon.EnqueueClaim(ctx, &input)
+		if err != nil {
+			return nil, err
 		}
-		return nil
-	})
-	if err != nil {
+
+		return &fhttp.Response{
+			Data:    ticket,
+			Status:  http.StatusAccepted,
+			Message: fmt.Sprintf("Claim of coupon %s by user %s is queued.", ticket.CouponName, ticket.Username),
+		}, nil
+	}
+
+	if err := c.coupon.Claim(ctx, &input); err != nil {
 		return nil, err
 	}
 
@@ -143,3 +177,299 @@ func (c *Controller) Claim(r *http.Request) (*fhttp.Response, error) {
 		Message: fmt.Sprintf("Coupon %s is successfully claimed by user %s.", input.CouponName, input.Username),
 	}, nil
 }
+
+func (c *Controller) ClaimBundle(r *http.Request) (*fhttp.Response, error) {
+	ctx := r.Context()
+
+	var input request.ClaimBundle
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, fhttp.NewErrorResponse(
+			http.StatusUnprocessableEntity,
+			sharedErrs.ErrKindValidation.String(),
+			fmt.Sprintf("Invalid request body: %v", err))
+	}
+
+	var err error
+	if input.Username, err = actingUsername(ctx, input.Username); err != nil {
+		return nil, err
+	}
+
+	if err := util.Validate(input); err != nil {
+		return nil, err
+	}
+
+	result, err := c.coupon.ClaimBundle(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	if !result.Claimed {
+		return &fhttp.Response{
+			Data:    result,
+			Status:  http.StatusConflict,
+			Message: fmt.Sprintf("Coupon bundle is blocked by coupon %s: %s", result.BlockedBy, result.Reason),
+		}, nil
+	}
+
+	return &fhttp.Response{
+		Data:    result,
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("Coupon bundle is successfully claimed by user %s.", result.Username),
+	}, nil
+}
+
+func (c *Controller) Transfer(r *http.Request) (*fhttp.Response, error) {
+	ctx := r.Context()
+
+	var input request.TransferCoupon
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, fhttp.NewErrorResponse(
+			http.StatusUnprocessableEntity,
+			sharedErrs.ErrKindValidation.String(),
+			fmt.Sprintf("Invalid request body: %v", err))
+	}
+
+	var err error
+	if input.Username, err = actingUsername(ctx, input.Username); err != nil {
+		return nil, err
+	}
+
+	if err := util.Validate(input); err != nil {
+		return nil, err
+	}
+
+	result, err := c.coupon.Transfer(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fhttp.Response{
+		Data:   result,
+		Status: http.StatusOK,
+		Message: fmt.Sprintf("Coupon %s is successfully transferred from user %s to user %s.",
+			result.CouponName, result.FromUsername, result.ToUsername),
+	}, nil
+}
+
+// Grant grants the coupon to the users listed in a JSON body, or in a CSV body with a header of username and/or
+// user_id columns. Grants beyond the remaining amount are made only with exceed_quota=true.
+func (c *Controller) Grant(r *http.Request) (*fhttp.Response, error) {
+	ctx := r.Context()
+
+	input := request.GrantCoupon{CouponName: mux.Vars(r)["coupon_name"]}
+	if input.CouponName == "" {
+		return nil, fhttp.NewErrorResponse(
+			http.StatusBadRequest,
+			sharedErrs.ErrKindValidation.String(),
+			"Please provide the correct coupon_name as string")
+	}
+
+	if data := r.URL.Query().Get("exceed_quota"); data != "" {
+		var err error
+		input.ExceedQuota, err = strconv.ParseBool(data)
+		if err != nil {
+			return nil, fhttp.NewErrorResponse(
+				http.StatusBadRequest,
+				sharedErrs.ErrKindValidation.String(),
+				"Please provide a valid exceed_quota as boolean")
+		}
+	}
+
+	var err error
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		err = decodeGrantCSV(r.Body, &input)
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&input)
+	}
+	if err != nil {
+		return nil, fhttp.NewErrorResponse(
+			http.StatusUnprocessableEntity,
+			sharedErrs.ErrKindValidation.String(),
+			fmt.Sprintf("Invalid request body: %v", err))
+	}
+
+	if err := util.Validate(input); err != nil {
+		return nil, err
+	}
+
+	result, err := c.coupon.Grant(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fhttp.Response{
+		Data:   result,
+		Status: http.StatusOK,
+		Message: fmt.Sprintf("Coupon %s is granted to %d users, %d skipped, %d failed.",
+			result.CouponName, result.Granted, result.Skipped, result.Failed),
+	}, nil
+}
+
+func (c *Controller) ClaimTicket(r *http.Request) (*fhttp.Response, error) {
+	ctx := r.Context()
+
+	ticketID := mux.Vars(r)["ticket_id"]
+	if ticketID == "" {
+		return nil, fhttp.NewErrorResponse(
+			http.StatusBadRequest,
+			sharedErrs.ErrKindValidation.String(),
+			"Please provide the correct ticket_id as string")
+	}
+
+	result, err := c.coupon.ClaimTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fhttp.Response{Data: result, Status: http.StatusOK}, nil
+}
+
+func (c *Controller) ClaimDeadLetters(r *http.Request) (*fhttp.Response, error) {
+	ctx := r.Context()
+
+	limit := defaultDeadLetterLimit
+	if data := r.URL.Query().Get("limit"); data != "" {
+		var err error
+		limit, err = strconv.Atoi(data)
+		if err != nil || limit <= 0 {
+			return nil, fhttp.NewErrorResponse(
+				http.StatusBadRequest,
+				sharedErrs.ErrKindValidation.String(),
+				"Please provide a valid limit as integer")
+		}
+	}
+
+	result, err := c.coupon.ClaimDeadLetters(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fhttp.Response{Data: result, Status: http.StatusOK}, nil
+}
+
+func (c *Controller) ReplayClaimDeadLetter(r *http.Request) (*fhttp.Response, error) {
+	ctx := r.Context()
+
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		return nil, fhttp.NewErrorResponse(
+			http.StatusBadRequest,
+			sharedErrs.ErrKindValidation.String(),
+			"Please provide the correct id as string")
+	}
+
+	result, err := c.coupon.ReplayClaimDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fhttp.Response{
+		Data:    result,
+		Status:  http.StatusAccepted,
+		Message: fmt.Sprintf("Dead letter %s is queued again.", id),
+	}, nil
+}
+
+func (c *Controller) Reconcile(r *http.Request) (*fhttp.Response, error) {
+	ctx := r.Context()
+
+	var autoCorrect bool
+	if data := r.URL.Query().Get("auto_correct"); data != "" {
+		var err error
+		autoCorrect, err = strconv.ParseBool(data)
+		if err != nil {
+			return nil, fhttp.NewErrorResponse(
+				http.StatusBadRequest,
+				sharedErrs.ErrKindValidation.String(),
+				"Please provide a valid auto_correct as boolean")
+		}
+	}
+
+	result, err := c.coupon.ReconcileRemainingAmounts(ctx, autoCorrect)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fhttp.Response{
+		Data:    result,
+		Status:  http.StatusOK,
+		Message: fmt.Sprintf("%d of %d coupons are drifted.", len(result.Drifts), result.CheckedCoupons),
+	}, nil
+}
+
+// actingUsername returns the user a claim or transfer is made as, the authenticated user unless a user permitted to
+// act as other users, i.e. an admin, names another user in the request body.
+func actingUsername(ctx context.Context, username string) (string, error) {
+	authenticated := constant.UsernameFromCtx(ctx)
+	if authenticated == "" {
+		return "", sharedErrs.UnauthorizedErr
+	}
+
+	if username == "" || username == authenticated {
+		return authenticated, nil
+	}
+
+	if !auth.Can(ctx, auth.PermissionActAsUser) {
+		logger.Warn(ctx, "user %s is not permitted to act as user %s", authenticated, username)
+		return "", sharedErrs.ForbiddenErr
+	}
+
+	return username, nil
+}
+
+// decodeGrantCSV reads the users of a grant from CSV, whose header names a username and/or a user_id column.
+func decodeGrantCSV(body io.Reader, input *request.GrantCoupon) error {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("reading csv header: %w", err)
+	}
+
+	usernameCol, userIDCol := -1, -1
+	for i, column := range header {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "username":
+			usernameCol = i
+		case "user_id":
+			userIDCol = i
+		}
+	}
+	if usernameCol < 0 && userIDCol < 0 {
+		return fmt.Errorf("csv header must have a username or user_id column")
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading csv: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		if usernameCol >= 0 {
+			if username := strings.TrimSpace(record[usernameCol]); username != "" {
+				input.Usernames = append(input.Usernames, username)
+				continue
+			}
+		}
+
+		if userIDCol >= 0 {
+			if data := strings.TrimSpace(record[userIDCol]); data != "" {
+				id, err := strconv.ParseUint(data, 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid user_id %q on line %d", data, line)
+				}
+
+				input.UserIDs = append(input.UserIDs, id)
+				continue
+			}
+		}
+
+		return fmt.Errorf("no username or user_id on line %d", line)
+	}
+}
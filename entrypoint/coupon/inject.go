@@ -5,22 +5,74 @@ import (
 	"coupon_be/repository"
 	"coupon_be/service/coupon"
 	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/queue"
 	"coupon_be/shared/external/redis"
+	"coupon_be/util/config"
+	"coupon_be/util/logger"
 
 	"gorm.io/gorm"
 )
 
+const (
+	ClaimModeSync  = "sync"
+	ClaimModeAsync = "async"
+)
+
 // NewController initializes a new Controller instance.
 func NewController(ctx context.Context, repository repository.Repository, writerDB *gorm.DB) (*Controller, error) {
-	couponService, err := coupon.NewService(repository, writerDB)
+	var options []coupon.Option
+
+	// without the lock, coupon.lock_unavailable_mode decides whether the claims fall back to the row locks or fail
+	redisLock, err := redis.GetLock(ctx)
 	if err != nil {
-		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindCodeInjection, "Fail to initiate coupon service", err)
+		if config.Env().Redis.LockStrictMode {
+			return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplication, "Fail to initiate Redis Lock", err)
+		}
+
+		logger.Error(ctx, "Fail to initiate Redis Lock, the claims are made as if the lock is unavailable: %v", err)
+	} else {
+		options = append(options, coupon.WithRedisLock(redisLock))
+	}
+
+	// the redis_atomic strategy is the only one that can not do without redis, so an unreachable redis fails it alone
+	stockCounter, err := redis.GetStockCounter(ctx)
+	if err != nil {
+		logger.Error(ctx, "Fail to initiate Redis Stock Counter, the redis_atomic strategy is unavailable: %v", err)
+	} else {
+		options = append(options, coupon.WithStockCounter(stockCounter))
+	}
+
+	soldOutMarker, err := redis.GetSoldOutMarker(ctx)
+	if err != nil {
+		logger.Error(ctx, "Fail to initiate Redis Sold Out Marker, the sold-out coupons are not short-circuited: %v", err)
+	} else {
+		options = append(options, coupon.WithSoldOutMarker(soldOutMarker))
 	}
 
-	redisLock, err := redis.GetRedisLock(ctx)
+	asyncClaim := config.Env().Coupon.ClaimMode == ClaimModeAsync
+
+	claimQueue, err := queue.GetQueue(ctx, coupon.ClaimQueueName)
 	if err != nil {
-		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplication, "Fail to initiate Redis Lock", err)
+		if asyncClaim {
+			return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplication, "Fail to initiate claim queue", err)
+		}
+
+		logger.Error(ctx, "Fail to initiate claim queue, the queued claims are not processed: %v", err)
+	} else {
+		options = append(options, coupon.WithClaimQueue(claimQueue))
 	}
 
-	return &Controller{coupon: couponService, redisLock: redisLock}, nil
+	couponService, err := coupon.NewService(repository, writerDB, options...)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindCodeInjection, "Fail to initiate coupon service", err)
+	}
+
+	go couponService.RunClaimWriter(ctx)
+	go couponService.RunClaimWorkers(ctx)
+	go couponService.RunStockReconciler(ctx)
+
+	return &Controller{
+		coupon:     couponService,
+		asyncClaim: asyncClaim,
+	}, nil
 }
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"coupon_be/shared/external/redis"
+	"coupon_be/shared/fhttp"
+	"coupon_be/util/config"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// LockHealth is the health of the redis lock nodes and the state of the circuit breaker of the lock.
+type LockHealth struct {
+	Nodes   []*redis.NodeHealth  `json:"nodes"`
+	Breaker *redis.BreakerStatus `json:"breaker,omitempty"`
+}
+
+// Controller reports the health of the dependencies of the service.
+type Controller struct{}
+
+func (c *Controller) RegisterRoutes(r *mux.Router) {
+	r.Handle("/redis-lock", fhttp.AppHandler(c.RedisLock)).Methods(http.MethodGet)
+}
+
+func (c *Controller) RedisLock(r *http.Request) (*fhttp.Response, error) {
+	ctx := r.Context()
+
+	if config.Env().Lock.Backend == redis.LockBackendMemory {
+		return &fhttp.Response{
+			Data:    &LockHealth{Nodes: []*redis.NodeHealth{}},
+			Status:  http.StatusOK,
+			Message: "Lock is in-memory, no redis lock node is used.",
+		}, nil
+	}
+
+	nodes, err := redis.GetLockNodesHealth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	healthy := 0
+	for _, node := range nodes {
+		if node.Healthy {
+			healthy++
+		}
+	}
+
+	breaker := redis.GetLockBreakerStatus()
+
+	status := http.StatusOK
+	if healthy < len(nodes)/2+1 || (breaker != nil && breaker.State == redis.BreakerOpen) {
+		status = http.StatusServiceUnavailable
+	}
+
+	return &fhttp.Response{
+		Data:    &LockHealth{Nodes: nodes, Breaker: breaker},
+		Status:  status,
+		Message: fmt.Sprintf("%d of %d redis lock nodes are healthy.", healthy, len(nodes)),
+	}, nil
+}
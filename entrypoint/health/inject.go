@@ -0,0 +1,6 @@
+package health
+
+// NewController initializes a new Controller instance.
+func NewController() *Controller {
+	return &Controller{}
+}
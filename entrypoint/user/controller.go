@@ -1,8 +1,13 @@
 package user
 
 import (
+	"coupon_be/request"
 	"coupon_be/service/user"
+	sharedErrs "coupon_be/shared/errors"
 	"coupon_be/shared/fhttp"
+	"coupon_be/util"
+	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -15,26 +20,87 @@ type Controller struct {
 
 func (c *Controller) RegisterRoutes(r *mux.Router) {
 	r.Handle("/register", fhttp.AppHandler(c.Register)).Methods(http.MethodPost)
+	r.Handle("/login", fhttp.AppHandler(c.Login)).Methods(http.MethodPost)
+	r.Handle("/refresh", fhttp.AppHandler(c.Refresh)).Methods(http.MethodPost)
 }
 
 func (c *Controller) Register(r *http.Request) (*fhttp.Response, error) {
 	ctx := r.Context()
 
-	//var input request.Register
-	//if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-	//	return nil, fhttp.NewErrorResponse(
-	//		http.StatusUnprocessableEntity,
-	//		sharedErrs.ErrKindValidation.String(),
-	//		fmt.Sprintf("Invalid request body: %v", err))
-	//}
-	//
-	//if err := util.Validate(input); err != nil {
-	//	return nil, err
-	//}
-
-	if err := c.user.Register(ctx); err != nil {
+	var input request.Register
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, fhttp.NewErrorResponse(
+			http.StatusUnprocessableEntity,
+			sharedErrs.ErrKindValidation.String(),
+			fmt.Sprintf("Invalid request body: %v", err))
+	}
+
+	if err := util.Validate(input); err != nil {
+		return nil, err
+	}
+
+	result, err := c.user.Register(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fhttp.Response{
+		Data:    result,
+		Status:  http.StatusCreated,
+		Message: fmt.Sprintf("User %s is registered successfully.", result.Username),
+	}, nil
+}
+
+func (c *Controller) Login(r *http.Request) (*fhttp.Response, error) {
+	ctx := r.Context()
+
+	var input request.Login
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, fhttp.NewErrorResponse(
+			http.StatusUnprocessableEntity,
+			sharedErrs.ErrKindValidation.String(),
+			fmt.Sprintf("Invalid request body: %v", err))
+	}
+
+	if err := util.Validate(input); err != nil {
+		return nil, err
+	}
+
+	result, err := c.user.Login(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fhttp.Response{
+		Data:    result,
+		Status:  http.StatusOK,
+		Message: "Logged in successfully.",
+	}, nil
+}
+
+func (c *Controller) Refresh(r *http.Request) (*fhttp.Response, error) {
+	ctx := r.Context()
+
+	var input request.Refresh
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, fhttp.NewErrorResponse(
+			http.StatusUnprocessableEntity,
+			sharedErrs.ErrKindValidation.String(),
+			fmt.Sprintf("Invalid request body: %v", err))
+	}
+
+	if err := util.Validate(input); err != nil {
+		return nil, err
+	}
+
+	result, err := c.user.Refresh(ctx, &input)
+	if err != nil {
 		return nil, err
 	}
 
-	return &fhttp.Response{Status: http.StatusOK}, nil
+	return &fhttp.Response{
+		Data:    result,
+		Status:  http.StatusOK,
+		Message: "Tokens are refreshed successfully.",
+	}, nil
 }
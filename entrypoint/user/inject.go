@@ -1,20 +1,40 @@
 package user
 
 import (
+	"cmp"
 	"context"
 	"coupon_be/repository"
+	"coupon_be/request"
 	"coupon_be/service/user"
+	"coupon_be/shared/auth"
 	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/config"
 
 	"gorm.io/gorm"
 )
 
+const defaultAdminFullName = "Admin"
+
 // NewController initializes a new Controller instance.
 func NewController(ctx context.Context, repository repository.Repository, writerDB *gorm.DB) (*Controller, error) {
-	authService, err := user.NewService(repository, writerDB)
+	tokens, err := auth.GetTokenManager()
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplication, "Fail to initiate token manager", err)
+	}
+
+	authService, err := user.NewService(repository, writerDB, user.WithTokenManager(tokens))
 	if err != nil {
 		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindCodeInjection, "Fail to initiate user service", err)
 	}
 
+	adminConfig := config.Env().Auth.Admin
+	if err = authService.BootstrapAdmin(ctx, &request.Register{
+		Username: adminConfig.Username,
+		FullName: cmp.Or(adminConfig.FullName, defaultAdminFullName),
+		Password: adminConfig.Password,
+	}); err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplication, "Fail to bootstrap admin", err)
+	}
+
 	return &Controller{user: authService}, nil
 }
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE coupons
+    ADD COLUMN IF NOT EXISTS claim_strategy VARCHAR(32) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS version        BIGINT      NOT NULL DEFAULT 0;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE coupons
+    DROP COLUMN IF EXISTS version,
+    DROP COLUMN IF EXISTS claim_strategy;
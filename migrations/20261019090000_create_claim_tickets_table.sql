@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+CREATE TABLE IF NOT EXISTS claim_tickets
+(
+    id          SERIAL PRIMARY KEY,
+    created_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    ticket_id   UUID         NOT NULL UNIQUE,
+    coupon_name VARCHAR(255) NOT NULL,
+    username    VARCHAR(100) NOT NULL,
+    status      VARCHAR(32)  NOT NULL,
+    reason      TEXT         NOT NULL DEFAULT '',
+    attempts    INT          NOT NULL DEFAULT 0
+);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS claim_tickets;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+CREATE TABLE IF NOT EXISTS coupon_stock_drifts
+(
+    id                        SERIAL PRIMARY KEY,
+    created_at                TIMESTAMP                      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at                TIMESTAMP                      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    coupon_id                 BIGINT REFERENCES coupons (id) NOT NULL,
+    amount                    INT                            NOT NULL,
+    claimed_count             INT                            NOT NULL,
+    remaining_amount          INT                            NOT NULL,
+    expected_remaining_amount INT                            NOT NULL,
+    corrected                 BOOLEAN                        NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX coupon_stock_drifts_coupon_id_idx ON coupon_stock_drifts(coupon_id);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS coupon_stock_drifts;
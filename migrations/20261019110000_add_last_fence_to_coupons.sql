@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE coupons
+    ADD COLUMN IF NOT EXISTS last_fence BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE coupons
+    DROP COLUMN IF EXISTS last_fence;
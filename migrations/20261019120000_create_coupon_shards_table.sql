@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE coupons
+    ADD COLUMN IF NOT EXISTS shard_count INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS coupon_shards
+(
+    id               SERIAL PRIMARY KEY,
+    created_at       TIMESTAMP                      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMP                      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    coupon_id        BIGINT REFERENCES coupons (id) NOT NULL,
+    shard_index      INT                            NOT NULL,
+    amount           INT                            NOT NULL,
+    remaining_amount INT                            NOT NULL,
+    last_fence       BIGINT                         NOT NULL DEFAULT 0,
+    UNIQUE (coupon_id, shard_index),
+
+    CONSTRAINT shard_remaining_amount_must_be_positive CHECK(remaining_amount >= 0)
+);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS coupon_shards;
+
+ALTER TABLE coupons
+    DROP COLUMN IF EXISTS shard_count;
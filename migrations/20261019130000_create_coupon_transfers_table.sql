@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE user_claims
+    ADD COLUMN IF NOT EXISTS transfer_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS redeemed_at    TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS coupon_transfers
+(
+    id            SERIAL PRIMARY KEY,
+    created_at    TIMESTAMP                          NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMP                          NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    user_claim_id BIGINT REFERENCES user_claims (id) NOT NULL,
+    coupon_id     BIGINT REFERENCES coupons (id)     NOT NULL,
+    from_user_id  BIGINT REFERENCES users (id)       NOT NULL,
+    to_user_id    BIGINT REFERENCES users (id)       NOT NULL
+);
+
+CREATE INDEX coupon_transfers_user_claim_id_idx ON coupon_transfers(user_claim_id);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS coupon_transfers;
+
+ALTER TABLE user_claims
+    DROP COLUMN IF EXISTS redeemed_at,
+    DROP COLUMN IF EXISTS transfer_count;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'customer'
+        CHECK (role IN ('admin', 'operator', 'customer'));
+
+CREATE INDEX users_role_idx ON users(role);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP INDEX IF EXISTS users_role_idx;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS role;
@@ -0,0 +1,73 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: token.go
+//
+// Generated by this command:
+//
+//	mockgen -package mock -source=token.go -destination=../../mock/auth_token.go *
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	domain "coupon_be/domain"
+	auth "coupon_be/shared/auth"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockITokenManager is a mock of ITokenManager interface.
+type MockITokenManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockITokenManagerMockRecorder
+	isgomock struct{}
+}
+
+// MockITokenManagerMockRecorder is the mock recorder for MockITokenManager.
+type MockITokenManagerMockRecorder struct {
+	mock *MockITokenManager
+}
+
+// NewMockITokenManager creates a new mock instance.
+func NewMockITokenManager(ctrl *gomock.Controller) *MockITokenManager {
+	mock := &MockITokenManager{ctrl: ctrl}
+	mock.recorder = &MockITokenManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockITokenManager) EXPECT() *MockITokenManagerMockRecorder {
+	return m.recorder
+}
+
+// Issue mocks base method.
+func (m *MockITokenManager) Issue(ctx context.Context, user *domain.User) (*auth.TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Issue", ctx, user)
+	ret0, _ := ret[0].(*auth.TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Issue indicates an expected call of Issue.
+func (mr *MockITokenManagerMockRecorder) Issue(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Issue", reflect.TypeOf((*MockITokenManager)(nil).Issue), ctx, user)
+}
+
+// Parse mocks base method.
+func (m *MockITokenManager) Parse(ctx context.Context, token string, tokenType auth.TokenType) (*auth.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Parse", ctx, token, tokenType)
+	ret0, _ := ret[0].(*auth.Claims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Parse indicates an expected call of Parse.
+func (mr *MockITokenManagerMockRecorder) Parse(ctx, token, tokenType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Parse", reflect.TypeOf((*MockITokenManager)(nil).Parse), ctx, token, tokenType)
+}
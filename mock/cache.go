@@ -0,0 +1,107 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: cache.go
+//
+// Generated by this command:
+//
+//	mockgen -package mock -source=cache.go -destination=../../../mock/cache.go *
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockICache is a mock of ICache interface.
+type MockICache struct {
+	ctrl     *gomock.Controller
+	recorder *MockICacheMockRecorder
+	isgomock struct{}
+}
+
+// MockICacheMockRecorder is the mock recorder for MockICache.
+type MockICacheMockRecorder struct {
+	mock *MockICache
+}
+
+// NewMockICache creates a new mock instance.
+func NewMockICache(ctrl *gomock.Controller) *MockICache {
+	mock := &MockICache{ctrl: ctrl}
+	mock.recorder = &MockICacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockICache) EXPECT() *MockICacheMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockICache) Get(ctx context.Context, key string, dst any) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key, dst)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockICacheMockRecorder) Get(ctx, key, dst any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockICache)(nil).Get), ctx, key, dst)
+}
+
+// Invalidate mocks base method.
+func (m *MockICache) Invalidate(ctx context.Context, tags ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range tags {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Invalidate", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Invalidate indicates an expected call of Invalidate.
+func (mr *MockICacheMockRecorder) Invalidate(ctx any, tags ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, tags...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invalidate", reflect.TypeOf((*MockICache)(nil).Invalidate), varargs...)
+}
+
+// Listen mocks base method.
+func (m *MockICache) Listen(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Listen", ctx)
+}
+
+// Listen indicates an expected call of Listen.
+func (mr *MockICacheMockRecorder) Listen(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Listen", reflect.TypeOf((*MockICache)(nil).Listen), ctx)
+}
+
+// Set mocks base method.
+func (m *MockICache) Set(ctx context.Context, key string, value any, ttl time.Duration, tags ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, key, value, ttl}
+	for _, a := range tags {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Set", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockICacheMockRecorder) Set(ctx, key, value, ttl any, tags ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, key, value, ttl}, tags...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockICache)(nil).Set), varargs...)
+}
@@ -2,6 +2,7 @@ package mock
 
 import (
 	"coupon_be/domain"
+	"coupon_be/domain/enums"
 	"fmt"
 	"time"
 
@@ -53,6 +54,7 @@ func InitUserDomain() *domain.User {
 		Username: "username",
 		FullName: "full_name",
 		Password: "password",
+		Role:     enums.RoleCustomer,
 	}
 }
 
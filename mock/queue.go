@@ -0,0 +1,159 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: queue.go
+//
+// Generated by this command:
+//
+//	mockgen -package mock -source=queue.go -destination=../../../mock/queue.go *
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	queue "coupon_be/shared/external/queue"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIQueue is a mock of IQueue interface.
+type MockIQueue struct {
+	ctrl     *gomock.Controller
+	recorder *MockIQueueMockRecorder
+	isgomock struct{}
+}
+
+// MockIQueueMockRecorder is the mock recorder for MockIQueue.
+type MockIQueueMockRecorder struct {
+	mock *MockIQueue
+}
+
+// NewMockIQueue creates a new mock instance.
+func NewMockIQueue(ctrl *gomock.Controller) *MockIQueue {
+	mock := &MockIQueue{ctrl: ctrl}
+	mock.recorder = &MockIQueueMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIQueue) EXPECT() *MockIQueueMockRecorder {
+	return m.recorder
+}
+
+// Ack mocks base method.
+func (m *MockIQueue) Ack(ctx context.Context, msg *queue.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ack", ctx, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ack indicates an expected call of Ack.
+func (mr *MockIQueueMockRecorder) Ack(ctx, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ack", reflect.TypeOf((*MockIQueue)(nil).Ack), ctx, msg)
+}
+
+// DeadLetter mocks base method.
+func (m *MockIQueue) DeadLetter(ctx context.Context, msg *queue.Message, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeadLetter", ctx, msg, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeadLetter indicates an expected call of DeadLetter.
+func (mr *MockIQueueMockRecorder) DeadLetter(ctx, msg, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeadLetter", reflect.TypeOf((*MockIQueue)(nil).DeadLetter), ctx, msg, reason)
+}
+
+// DeadLetters mocks base method.
+func (m *MockIQueue) DeadLetters(ctx context.Context, limit int) ([]*queue.DeadLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeadLetters", ctx, limit)
+	ret0, _ := ret[0].([]*queue.DeadLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeadLetters indicates an expected call of DeadLetters.
+func (mr *MockIQueueMockRecorder) DeadLetters(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeadLetters", reflect.TypeOf((*MockIQueue)(nil).DeadLetters), ctx, limit)
+}
+
+// FindDeadLetter mocks base method.
+func (m *MockIQueue) FindDeadLetter(ctx context.Context, id string) (*queue.DeadLetter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDeadLetter", ctx, id)
+	ret0, _ := ret[0].(*queue.DeadLetter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDeadLetter indicates an expected call of FindDeadLetter.
+func (mr *MockIQueueMockRecorder) FindDeadLetter(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDeadLetter", reflect.TypeOf((*MockIQueue)(nil).FindDeadLetter), ctx, id)
+}
+
+// Partitions mocks base method.
+func (m *MockIQueue) Partitions() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Partitions")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Partitions indicates an expected call of Partitions.
+func (mr *MockIQueueMockRecorder) Partitions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Partitions", reflect.TypeOf((*MockIQueue)(nil).Partitions))
+}
+
+// Publish mocks base method.
+func (m *MockIQueue) Publish(ctx context.Context, key string, payload []byte) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, key, payload)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockIQueueMockRecorder) Publish(ctx, key, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockIQueue)(nil).Publish), ctx, key, payload)
+}
+
+// Receive mocks base method.
+func (m *MockIQueue) Receive(ctx context.Context, partition int) (*queue.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Receive", ctx, partition)
+	ret0, _ := ret[0].(*queue.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Receive indicates an expected call of Receive.
+func (mr *MockIQueueMockRecorder) Receive(ctx, partition any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Receive", reflect.TypeOf((*MockIQueue)(nil).Receive), ctx, partition)
+}
+
+// Replay mocks base method.
+func (m *MockIQueue) Replay(ctx context.Context, id string) (*queue.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replay", ctx, id)
+	ret0, _ := ret[0].(*queue.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Replay indicates an expected call of Replay.
+func (mr *MockIQueueMockRecorder) Replay(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replay", reflect.TypeOf((*MockIQueue)(nil).Replay), ctx, id)
+}
@@ -0,0 +1,58 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ratelimit.go
+//
+// Generated by this command:
+//
+//	mockgen -package mock -source=ratelimit.go -destination=../../../mock/ratelimit.go *
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	ratelimit "coupon_be/shared/external/ratelimit"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockILimiter is a mock of ILimiter interface.
+type MockILimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockILimiterMockRecorder
+	isgomock struct{}
+}
+
+// MockILimiterMockRecorder is the mock recorder for MockILimiter.
+type MockILimiterMockRecorder struct {
+	mock *MockILimiter
+}
+
+// NewMockILimiter creates a new mock instance.
+func NewMockILimiter(ctrl *gomock.Controller) *MockILimiter {
+	mock := &MockILimiter{ctrl: ctrl}
+	mock.recorder = &MockILimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockILimiter) EXPECT() *MockILimiterMockRecorder {
+	return m.recorder
+}
+
+// Allow mocks base method.
+func (m *MockILimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (*ratelimit.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Allow", ctx, key, limit, window)
+	ret0, _ := ret[0].(*ratelimit.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Allow indicates an expected call of Allow.
+func (mr *MockILimiterMockRecorder) Allow(ctx, key, limit, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Allow", reflect.TypeOf((*MockILimiter)(nil).Allow), ctx, key, limit, window)
+}
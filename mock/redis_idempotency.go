@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: idempotency.go
+//
+// Generated by this command:
+//
+//	mockgen -package mock -source=idempotency.go -destination=../../../mock/redis_idempotency.go *
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	redis "coupon_be/shared/external/redis"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIIdempotencyStore is a mock of IIdempotencyStore interface.
+type MockIIdempotencyStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockIIdempotencyStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockIIdempotencyStoreMockRecorder is the mock recorder for MockIIdempotencyStore.
+type MockIIdempotencyStoreMockRecorder struct {
+	mock *MockIIdempotencyStore
+}
+
+// NewMockIIdempotencyStore creates a new mock instance.
+func NewMockIIdempotencyStore(ctrl *gomock.Controller) *MockIIdempotencyStore {
+	mock := &MockIIdempotencyStore{ctrl: ctrl}
+	mock.recorder = &MockIIdempotencyStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIIdempotencyStore) EXPECT() *MockIIdempotencyStoreMockRecorder {
+	return m.recorder
+}
+
+// Complete mocks base method.
+func (m *MockIIdempotencyStore) Complete(ctx context.Context, key string, record *redis.IdempotentRecord, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, key, record, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockIIdempotencyStoreMockRecorder) Complete(ctx, key, record, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockIIdempotencyStore)(nil).Complete), ctx, key, record, ttl)
+}
+
+// Release mocks base method.
+func (m *MockIIdempotencyStore) Release(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockIIdempotencyStoreMockRecorder) Release(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockIIdempotencyStore)(nil).Release), ctx, key)
+}
+
+// Reserve mocks base method.
+func (m *MockIIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*redis.IdempotentRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, key, fingerprint, ttl)
+	ret0, _ := ret[0].(*redis.IdempotentRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockIIdempotencyStoreMockRecorder) Reserve(ctx, key, fingerprint, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockIIdempotencyStore)(nil).Reserve), ctx, key, fingerprint, ttl)
+}
@@ -0,0 +1,61 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: lock.go
+//
+// Generated by this command:
+//
+//	mockgen -package mock -source=lock.go -destination=../../../mock/redis_lock.go *
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	redis "coupon_be/shared/external/redis"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockILock is a mock of ILock interface.
+type MockILock struct {
+	ctrl     *gomock.Controller
+	recorder *MockILockMockRecorder
+	isgomock struct{}
+}
+
+// MockILockMockRecorder is the mock recorder for MockILock.
+type MockILockMockRecorder struct {
+	mock *MockILock
+}
+
+// NewMockILock creates a new mock instance.
+func NewMockILock(ctrl *gomock.Controller) *MockILock {
+	mock := &MockILock{ctrl: ctrl}
+	mock.recorder = &MockILockMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockILock) EXPECT() *MockILockMockRecorder {
+	return m.recorder
+}
+
+// WithLock mocks base method.
+func (m *MockILock) WithLock(ctx context.Context, key string, fn func(context.Context, int64) error, options ...redis.LockOption) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, key, fn}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WithLock", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithLock indicates an expected call of WithLock.
+func (mr *MockILockMockRecorder) WithLock(ctx, key, fn any, options ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, key, fn}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLock", reflect.TypeOf((*MockILock)(nil).WithLock), varargs...)
+}
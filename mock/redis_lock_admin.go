@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: lock_admin.go
+//
+// Generated by this command:
+//
+//	mockgen -package mock -source=lock_admin.go -destination=../../../mock/redis_lock_admin.go *
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	redis "coupon_be/shared/external/redis"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockILockInspector is a mock of ILockInspector interface.
+type MockILockInspector struct {
+	ctrl     *gomock.Controller
+	recorder *MockILockInspectorMockRecorder
+	isgomock struct{}
+}
+
+// MockILockInspectorMockRecorder is the mock recorder for MockILockInspector.
+type MockILockInspectorMockRecorder struct {
+	mock *MockILockInspector
+}
+
+// NewMockILockInspector creates a new mock instance.
+func NewMockILockInspector(ctrl *gomock.Controller) *MockILockInspector {
+	mock := &MockILockInspector{ctrl: ctrl}
+	mock.recorder = &MockILockInspectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockILockInspector) EXPECT() *MockILockInspectorMockRecorder {
+	return m.recorder
+}
+
+// ForceRelease mocks base method.
+func (m *MockILockInspector) ForceRelease(ctx context.Context, key string) (*redis.HeldLock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceRelease", ctx, key)
+	ret0, _ := ret[0].(*redis.HeldLock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForceRelease indicates an expected call of ForceRelease.
+func (mr *MockILockInspectorMockRecorder) ForceRelease(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceRelease", reflect.TypeOf((*MockILockInspector)(nil).ForceRelease), ctx, key)
+}
+
+// HeldLocks mocks base method.
+func (m *MockILockInspector) HeldLocks(ctx context.Context) ([]*redis.HeldLock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HeldLocks", ctx)
+	ret0, _ := ret[0].([]*redis.HeldLock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HeldLocks indicates an expected call of HeldLocks.
+func (mr *MockILockInspectorMockRecorder) HeldLocks(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HeldLocks", reflect.TypeOf((*MockILockInspector)(nil).HeldLocks), ctx)
+}
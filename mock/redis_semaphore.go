@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: semaphore.go
+//
+// Generated by this command:
+//
+//	mockgen -package mock -source=semaphore.go -destination=../../../mock/redis_semaphore.go *
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	redis "coupon_be/shared/external/redis"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockISemaphore is a mock of ISemaphore interface.
+type MockISemaphore struct {
+	ctrl     *gomock.Controller
+	recorder *MockISemaphoreMockRecorder
+	isgomock struct{}
+}
+
+// MockISemaphoreMockRecorder is the mock recorder for MockISemaphore.
+type MockISemaphoreMockRecorder struct {
+	mock *MockISemaphore
+}
+
+// NewMockISemaphore creates a new mock instance.
+func NewMockISemaphore(ctrl *gomock.Controller) *MockISemaphore {
+	mock := &MockISemaphore{ctrl: ctrl}
+	mock.recorder = &MockISemaphoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockISemaphore) EXPECT() *MockISemaphoreMockRecorder {
+	return m.recorder
+}
+
+// Acquire mocks base method.
+func (m *MockISemaphore) Acquire(ctx context.Context, key string, limit int, options ...redis.LockOption) (*redis.Permit, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, key, limit}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Acquire", varargs...)
+	ret0, _ := ret[0].(*redis.Permit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Acquire indicates an expected call of Acquire.
+func (mr *MockISemaphoreMockRecorder) Acquire(ctx, key, limit any, options ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, key, limit}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Acquire", reflect.TypeOf((*MockISemaphore)(nil).Acquire), varargs...)
+}
+
+// Release mocks base method.
+func (m *MockISemaphore) Release(ctx context.Context, permit *redis.Permit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, permit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockISemaphoreMockRecorder) Release(ctx, permit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockISemaphore)(nil).Release), ctx, permit)
+}
+
+// WithPermit mocks base method.
+func (m *MockISemaphore) WithPermit(ctx context.Context, key string, limit int, fn func(context.Context) error, options ...redis.LockOption) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, key, limit, fn}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WithPermit", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithPermit indicates an expected call of WithPermit.
+func (mr *MockISemaphoreMockRecorder) WithPermit(ctx, key, limit, fn any, options ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, key, limit, fn}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithPermit", reflect.TypeOf((*MockISemaphore)(nil).WithPermit), varargs...)
+}
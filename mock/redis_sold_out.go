@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: sold_out.go
+//
+// Generated by this command:
+//
+//	mockgen -package mock -source=sold_out.go -destination=../../../mock/redis_sold_out.go *
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockISoldOutMarker is a mock of ISoldOutMarker interface.
+type MockISoldOutMarker struct {
+	ctrl     *gomock.Controller
+	recorder *MockISoldOutMarkerMockRecorder
+	isgomock struct{}
+}
+
+// MockISoldOutMarkerMockRecorder is the mock recorder for MockISoldOutMarker.
+type MockISoldOutMarkerMockRecorder struct {
+	mock *MockISoldOutMarker
+}
+
+// NewMockISoldOutMarker creates a new mock instance.
+func NewMockISoldOutMarker(ctrl *gomock.Controller) *MockISoldOutMarker {
+	mock := &MockISoldOutMarker{ctrl: ctrl}
+	mock.recorder = &MockISoldOutMarkerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockISoldOutMarker) EXPECT() *MockISoldOutMarkerMockRecorder {
+	return m.recorder
+}
+
+// Clear mocks base method.
+func (m *MockISoldOutMarker) Clear(ctx context.Context, couponName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clear", ctx, couponName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Clear indicates an expected call of Clear.
+func (mr *MockISoldOutMarkerMockRecorder) Clear(ctx, couponName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clear", reflect.TypeOf((*MockISoldOutMarker)(nil).Clear), ctx, couponName)
+}
+
+// IsMarked mocks base method.
+func (m *MockISoldOutMarker) IsMarked(ctx context.Context, couponName string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsMarked", ctx, couponName)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsMarked indicates an expected call of IsMarked.
+func (mr *MockISoldOutMarkerMockRecorder) IsMarked(ctx, couponName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsMarked", reflect.TypeOf((*MockISoldOutMarker)(nil).IsMarked), ctx, couponName)
+}
+
+// Mark mocks base method.
+func (m *MockISoldOutMarker) Mark(ctx context.Context, couponName string, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Mark", ctx, couponName, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Mark indicates an expected call of Mark.
+func (mr *MockISoldOutMarkerMockRecorder) Mark(ctx, couponName, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Mark", reflect.TypeOf((*MockISoldOutMarker)(nil).Mark), ctx, couponName, ttl)
+}
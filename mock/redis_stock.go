@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: stock.go
+//
+// Generated by this command:
+//
+//	mockgen -package mock -source=stock.go -destination=../../../mock/redis_stock.go *
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	redis "coupon_be/shared/external/redis"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIStockCounter is a mock of IStockCounter interface.
+type MockIStockCounter struct {
+	ctrl     *gomock.Controller
+	recorder *MockIStockCounterMockRecorder
+	isgomock struct{}
+}
+
+// MockIStockCounterMockRecorder is the mock recorder for MockIStockCounter.
+type MockIStockCounterMockRecorder struct {
+	mock *MockIStockCounter
+}
+
+// NewMockIStockCounter creates a new mock instance.
+func NewMockIStockCounter(ctrl *gomock.Controller) *MockIStockCounter {
+	mock := &MockIStockCounter{ctrl: ctrl}
+	mock.recorder = &MockIStockCounterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIStockCounter) EXPECT() *MockIStockCounterMockRecorder {
+	return m.recorder
+}
+
+// Ack mocks base method.
+func (m *MockIStockCounter) Ack(ctx context.Context, count int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ack", ctx, count)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ack indicates an expected call of Ack.
+func (mr *MockIStockCounterMockRecorder) Ack(ctx, count any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ack", reflect.TypeOf((*MockIStockCounter)(nil).Ack), ctx, count)
+}
+
+// Pending mocks base method.
+func (m *MockIStockCounter) Pending(ctx context.Context, count int) ([]*redis.PendingTake, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pending", ctx, count)
+	ret0, _ := ret[0].([]*redis.PendingTake)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pending indicates an expected call of Pending.
+func (mr *MockIStockCounterMockRecorder) Pending(ctx, count any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pending", reflect.TypeOf((*MockIStockCounter)(nil).Pending), ctx, count)
+}
+
+// Rebuild mocks base method.
+func (m *MockIStockCounter) Rebuild(ctx context.Context, key string, amount int64, members []string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rebuild", ctx, key, amount, members)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rebuild indicates an expected call of Rebuild.
+func (mr *MockIStockCounterMockRecorder) Rebuild(ctx, key, amount, members any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rebuild", reflect.TypeOf((*MockIStockCounter)(nil).Rebuild), ctx, key, amount, members)
+}
+
+// Take mocks base method.
+func (m *MockIStockCounter) Take(ctx context.Context, key, member string) (redis.TakeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Take", ctx, key, member)
+	ret0, _ := ret[0].(redis.TakeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Take indicates an expected call of Take.
+func (mr *MockIStockCounterMockRecorder) Take(ctx, key, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Take", reflect.TypeOf((*MockIStockCounter)(nil).Take), ctx, key, member)
+}
@@ -12,6 +12,7 @@ package mock
 import (
 	context "context"
 	domain "coupon_be/domain"
+	enums "coupon_be/domain/enums"
 	util "coupon_be/util"
 	reflect "reflect"
 
@@ -42,6 +43,35 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CorrectCouponRemainingAmount mocks base method.
+func (m *MockRepository) CorrectCouponRemainingAmount(ctx context.Context, id, observed, remaining uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CorrectCouponRemainingAmount", ctx, id, observed, remaining)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CorrectCouponRemainingAmount indicates an expected call of CorrectCouponRemainingAmount.
+func (mr *MockRepositoryMockRecorder) CorrectCouponRemainingAmount(ctx, id, observed, remaining any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CorrectCouponRemainingAmount", reflect.TypeOf((*MockRepository)(nil).CorrectCouponRemainingAmount), ctx, id, observed, remaining)
+}
+
+// CreateClaimTicket mocks base method.
+func (m *MockRepository) CreateClaimTicket(ctx context.Context, data *domain.ClaimTicket) (*domain.ClaimTicket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateClaimTicket", ctx, data)
+	ret0, _ := ret[0].(*domain.ClaimTicket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateClaimTicket indicates an expected call of CreateClaimTicket.
+func (mr *MockRepositoryMockRecorder) CreateClaimTicket(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateClaimTicket", reflect.TypeOf((*MockRepository)(nil).CreateClaimTicket), ctx, data)
+}
+
 // CreateCoupon mocks base method.
 func (m *MockRepository) CreateCoupon(ctx context.Context, data *domain.Coupon) (*domain.Coupon, error) {
 	m.ctrl.T.Helper()
@@ -57,6 +87,50 @@ func (mr *MockRepositoryMockRecorder) CreateCoupon(ctx, data any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCoupon", reflect.TypeOf((*MockRepository)(nil).CreateCoupon), ctx, data)
 }
 
+// CreateCouponShards mocks base method.
+func (m *MockRepository) CreateCouponShards(ctx context.Context, data []*domain.CouponShard) ([]*domain.CouponShard, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCouponShards", ctx, data)
+	ret0, _ := ret[0].([]*domain.CouponShard)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCouponShards indicates an expected call of CreateCouponShards.
+func (mr *MockRepositoryMockRecorder) CreateCouponShards(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCouponShards", reflect.TypeOf((*MockRepository)(nil).CreateCouponShards), ctx, data)
+}
+
+// CreateCouponStockDrifts mocks base method.
+func (m *MockRepository) CreateCouponStockDrifts(ctx context.Context, data []*domain.CouponStockDrift) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCouponStockDrifts", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCouponStockDrifts indicates an expected call of CreateCouponStockDrifts.
+func (mr *MockRepositoryMockRecorder) CreateCouponStockDrifts(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCouponStockDrifts", reflect.TypeOf((*MockRepository)(nil).CreateCouponStockDrifts), ctx, data)
+}
+
+// CreateCouponTransfer mocks base method.
+func (m *MockRepository) CreateCouponTransfer(ctx context.Context, data *domain.CouponTransfer) (*domain.CouponTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCouponTransfer", ctx, data)
+	ret0, _ := ret[0].(*domain.CouponTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCouponTransfer indicates an expected call of CreateCouponTransfer.
+func (mr *MockRepositoryMockRecorder) CreateCouponTransfer(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCouponTransfer", reflect.TypeOf((*MockRepository)(nil).CreateCouponTransfer), ctx, data)
+}
+
 // CreateUser mocks base method.
 func (m *MockRepository) CreateUser(ctx context.Context, data *domain.User) (*domain.User, error) {
 	m.ctrl.T.Helper()
@@ -87,33 +161,105 @@ func (mr *MockRepositoryMockRecorder) CreateUserClaim(ctx, data any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserClaim", reflect.TypeOf((*MockRepository)(nil).CreateUserClaim), ctx, data)
 }
 
+// CreateUserClaims mocks base method.
+func (m *MockRepository) CreateUserClaims(ctx context.Context, data []*domain.UserClaim) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserClaims", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUserClaims indicates an expected call of CreateUserClaims.
+func (mr *MockRepositoryMockRecorder) CreateUserClaims(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserClaims", reflect.TypeOf((*MockRepository)(nil).CreateUserClaims), ctx, data)
+}
+
 // DecrementCouponRemainingAmount mocks base method.
-func (m *MockRepository) DecrementCouponRemainingAmount(ctx context.Context, id uint64) error {
+func (m *MockRepository) DecrementCouponRemainingAmount(ctx context.Context, id uint64, fence int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DecrementCouponRemainingAmount", ctx, id)
+	ret := m.ctrl.Call(m, "DecrementCouponRemainingAmount", ctx, id, fence)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DecrementCouponRemainingAmount indicates an expected call of DecrementCouponRemainingAmount.
-func (mr *MockRepositoryMockRecorder) DecrementCouponRemainingAmount(ctx, id any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) DecrementCouponRemainingAmount(ctx, id, fence any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecrementCouponRemainingAmount", reflect.TypeOf((*MockRepository)(nil).DecrementCouponRemainingAmount), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecrementCouponRemainingAmount", reflect.TypeOf((*MockRepository)(nil).DecrementCouponRemainingAmount), ctx, id, fence)
 }
 
-// FindCountUserClaimByCouponID mocks base method.
-func (m *MockRepository) FindUserClaimCountByCouponID(ctx context.Context, couponID uint64) (int64, error) {
+// DecrementCouponRemainingAmountByVersion mocks base method.
+func (m *MockRepository) DecrementCouponRemainingAmountByVersion(ctx context.Context, id, version uint64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindUserClaimCountByCouponID", ctx, couponID)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "DecrementCouponRemainingAmountByVersion", ctx, id, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecrementCouponRemainingAmountByVersion indicates an expected call of DecrementCouponRemainingAmountByVersion.
+func (mr *MockRepositoryMockRecorder) DecrementCouponRemainingAmountByVersion(ctx, id, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecrementCouponRemainingAmountByVersion", reflect.TypeOf((*MockRepository)(nil).DecrementCouponRemainingAmountByVersion), ctx, id, version)
+}
+
+// DecrementCouponShardRemainingAmount mocks base method.
+func (m *MockRepository) DecrementCouponShardRemainingAmount(ctx context.Context, id uint64, fence int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecrementCouponShardRemainingAmount", ctx, id, fence)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecrementCouponShardRemainingAmount indicates an expected call of DecrementCouponShardRemainingAmount.
+func (mr *MockRepositoryMockRecorder) DecrementCouponShardRemainingAmount(ctx, id, fence any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecrementCouponShardRemainingAmount", reflect.TypeOf((*MockRepository)(nil).DecrementCouponShardRemainingAmount), ctx, id, fence)
+}
+
+// FindClaimTicketByTicketID mocks base method.
+func (m *MockRepository) FindClaimTicketByTicketID(ctx context.Context, ticketID string) (*domain.ClaimTicket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindClaimTicketByTicketID", ctx, ticketID)
+	ret0, _ := ret[0].(*domain.ClaimTicket)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindCountUserClaimByCouponID indicates an expected call of FindCountUserClaimByCouponID.
-func (mr *MockRepositoryMockRecorder) FindCountUserClaimByCouponID(ctx, couponID any) *gomock.Call {
+// FindClaimTicketByTicketID indicates an expected call of FindClaimTicketByTicketID.
+func (mr *MockRepositoryMockRecorder) FindClaimTicketByTicketID(ctx, ticketID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserClaimCountByCouponID", reflect.TypeOf((*MockRepository)(nil).FindUserClaimCountByCouponID), ctx, couponID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindClaimTicketByTicketID", reflect.TypeOf((*MockRepository)(nil).FindClaimTicketByTicketID), ctx, ticketID)
+}
+
+// FindClaimedUserIDsByCouponID mocks base method.
+func (m *MockRepository) FindClaimedUserIDsByCouponID(ctx context.Context, couponID uint64) ([]uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindClaimedUserIDsByCouponID", ctx, couponID)
+	ret0, _ := ret[0].([]uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindClaimedUserIDsByCouponID indicates an expected call of FindClaimedUserIDsByCouponID.
+func (mr *MockRepositoryMockRecorder) FindClaimedUserIDsByCouponID(ctx, couponID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindClaimedUserIDsByCouponID", reflect.TypeOf((*MockRepository)(nil).FindClaimedUserIDsByCouponID), ctx, couponID)
+}
+
+// FindClaimedUserIDsByCouponIDAndUserIDs mocks base method.
+func (m *MockRepository) FindClaimedUserIDsByCouponIDAndUserIDs(ctx context.Context, couponID uint64, userIDs []uint64) ([]uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindClaimedUserIDsByCouponIDAndUserIDs", ctx, couponID, userIDs)
+	ret0, _ := ret[0].([]uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindClaimedUserIDsByCouponIDAndUserIDs indicates an expected call of FindClaimedUserIDsByCouponIDAndUserIDs.
+func (mr *MockRepositoryMockRecorder) FindClaimedUserIDsByCouponIDAndUserIDs(ctx, couponID, userIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindClaimedUserIDsByCouponIDAndUserIDs", reflect.TypeOf((*MockRepository)(nil).FindClaimedUserIDsByCouponIDAndUserIDs), ctx, couponID, userIDs)
 }
 
 // FindCouponByID mocks base method.
@@ -131,6 +277,21 @@ func (mr *MockRepositoryMockRecorder) FindCouponByID(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCouponByID", reflect.TypeOf((*MockRepository)(nil).FindCouponByID), ctx, id)
 }
 
+// FindCouponByIDForUpdate mocks base method.
+func (m *MockRepository) FindCouponByIDForUpdate(ctx context.Context, id uint64) (*domain.Coupon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCouponByIDForUpdate", ctx, id)
+	ret0, _ := ret[0].(*domain.Coupon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCouponByIDForUpdate indicates an expected call of FindCouponByIDForUpdate.
+func (mr *MockRepositoryMockRecorder) FindCouponByIDForUpdate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCouponByIDForUpdate", reflect.TypeOf((*MockRepository)(nil).FindCouponByIDForUpdate), ctx, id)
+}
+
 // FindCouponByName mocks base method.
 func (m *MockRepository) FindCouponByName(ctx context.Context, name string, withClaimBy bool) (*domain.Coupon, error) {
 	m.ctrl.T.Helper()
@@ -146,6 +307,66 @@ func (mr *MockRepositoryMockRecorder) FindCouponByName(ctx, name, withClaimBy an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCouponByName", reflect.TypeOf((*MockRepository)(nil).FindCouponByName), ctx, name, withClaimBy)
 }
 
+// FindCouponShardRemainingAmount mocks base method.
+func (m *MockRepository) FindCouponShardRemainingAmount(ctx context.Context, couponID uint64) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCouponShardRemainingAmount", ctx, couponID)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCouponShardRemainingAmount indicates an expected call of FindCouponShardRemainingAmount.
+func (mr *MockRepositoryMockRecorder) FindCouponShardRemainingAmount(ctx, couponID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCouponShardRemainingAmount", reflect.TypeOf((*MockRepository)(nil).FindCouponShardRemainingAmount), ctx, couponID)
+}
+
+// FindCouponShards mocks base method.
+func (m *MockRepository) FindCouponShards(ctx context.Context, couponID uint64) ([]*domain.CouponShard, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCouponShards", ctx, couponID)
+	ret0, _ := ret[0].([]*domain.CouponShard)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCouponShards indicates an expected call of FindCouponShards.
+func (mr *MockRepositoryMockRecorder) FindCouponShards(ctx, couponID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCouponShards", reflect.TypeOf((*MockRepository)(nil).FindCouponShards), ctx, couponID)
+}
+
+// FindCouponStocks mocks base method.
+func (m *MockRepository) FindCouponStocks(ctx context.Context) ([]*domain.CouponStock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCouponStocks", ctx)
+	ret0, _ := ret[0].([]*domain.CouponStock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCouponStocks indicates an expected call of FindCouponStocks.
+func (mr *MockRepositoryMockRecorder) FindCouponStocks(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCouponStocks", reflect.TypeOf((*MockRepository)(nil).FindCouponStocks), ctx)
+}
+
+// FindCouponsByClaimStrategy mocks base method.
+func (m *MockRepository) FindCouponsByClaimStrategy(ctx context.Context, strategies []enums.ClaimStrategy) ([]*domain.Coupon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCouponsByClaimStrategy", ctx, strategies)
+	ret0, _ := ret[0].([]*domain.Coupon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCouponsByClaimStrategy indicates an expected call of FindCouponsByClaimStrategy.
+func (mr *MockRepositoryMockRecorder) FindCouponsByClaimStrategy(ctx, strategies any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCouponsByClaimStrategy", reflect.TypeOf((*MockRepository)(nil).FindCouponsByClaimStrategy), ctx, strategies)
+}
+
 // FindCouponsPaginated mocks base method.
 func (m *MockRepository) FindCouponsPaginated(ctx context.Context, search string, p *util.Pagination) ([]*domain.Coupon, error) {
 	m.ctrl.T.Helper()
@@ -206,6 +427,109 @@ func (mr *MockRepositoryMockRecorder) FindUserClaimByUserIDAndCouponID(ctx, user
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserClaimByUserIDAndCouponID", reflect.TypeOf((*MockRepository)(nil).FindUserClaimByUserIDAndCouponID), ctx, userID, couponID)
 }
 
+// FindUserClaimCountByCouponID mocks base method.
+func (m *MockRepository) FindUserClaimCountByCouponID(ctx context.Context, couponID uint64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserClaimCountByCouponID", ctx, couponID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserClaimCountByCouponID indicates an expected call of FindUserClaimCountByCouponID.
+func (mr *MockRepositoryMockRecorder) FindUserClaimCountByCouponID(ctx, couponID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserClaimCountByCouponID", reflect.TypeOf((*MockRepository)(nil).FindUserClaimCountByCouponID), ctx, couponID)
+}
+
+// FindUserCountByRole mocks base method.
+func (m *MockRepository) FindUserCountByRole(ctx context.Context, role enums.Role) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserCountByRole", ctx, role)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserCountByRole indicates an expected call of FindUserCountByRole.
+func (mr *MockRepositoryMockRecorder) FindUserCountByRole(ctx, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserCountByRole", reflect.TypeOf((*MockRepository)(nil).FindUserCountByRole), ctx, role)
+}
+
+// FindUsersByIDs mocks base method.
+func (m *MockRepository) FindUsersByIDs(ctx context.Context, ids []uint64) ([]*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUsersByIDs", ctx, ids)
+	ret0, _ := ret[0].([]*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUsersByIDs indicates an expected call of FindUsersByIDs.
+func (mr *MockRepositoryMockRecorder) FindUsersByIDs(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUsersByIDs", reflect.TypeOf((*MockRepository)(nil).FindUsersByIDs), ctx, ids)
+}
+
+// FindUsersByUsernames mocks base method.
+func (m *MockRepository) FindUsersByUsernames(ctx context.Context, usernames []string) ([]*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUsersByUsernames", ctx, usernames)
+	ret0, _ := ret[0].([]*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUsersByUsernames indicates an expected call of FindUsersByUsernames.
+func (mr *MockRepositoryMockRecorder) FindUsersByUsernames(ctx, usernames any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUsersByUsernames", reflect.TypeOf((*MockRepository)(nil).FindUsersByUsernames), ctx, usernames)
+}
+
+// GrantCouponRemainingAmount mocks base method.
+func (m *MockRepository) GrantCouponRemainingAmount(ctx context.Context, id, consumed, overQuota uint64, fence int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantCouponRemainingAmount", ctx, id, consumed, overQuota, fence)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GrantCouponRemainingAmount indicates an expected call of GrantCouponRemainingAmount.
+func (mr *MockRepositoryMockRecorder) GrantCouponRemainingAmount(ctx, id, consumed, overQuota, fence any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantCouponRemainingAmount", reflect.TypeOf((*MockRepository)(nil).GrantCouponRemainingAmount), ctx, id, consumed, overQuota, fence)
+}
+
+// TransferUserClaim mocks base method.
+func (m *MockRepository) TransferUserClaim(ctx context.Context, id, fromUserID, toUserID uint64, maxTransfers int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferUserClaim", ctx, id, fromUserID, toUserID, maxTransfers)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TransferUserClaim indicates an expected call of TransferUserClaim.
+func (mr *MockRepositoryMockRecorder) TransferUserClaim(ctx, id, fromUserID, toUserID, maxTransfers any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferUserClaim", reflect.TypeOf((*MockRepository)(nil).TransferUserClaim), ctx, id, fromUserID, toUserID, maxTransfers)
+}
+
+// UpdateClaimTicket mocks base method.
+func (m *MockRepository) UpdateClaimTicket(ctx context.Context, data *domain.ClaimTicket) (*domain.ClaimTicket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateClaimTicket", ctx, data)
+	ret0, _ := ret[0].(*domain.ClaimTicket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateClaimTicket indicates an expected call of UpdateClaimTicket.
+func (mr *MockRepositoryMockRecorder) UpdateClaimTicket(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateClaimTicket", reflect.TypeOf((*MockRepository)(nil).UpdateClaimTicket), ctx, data)
+}
+
 // UpdateCoupon mocks base method.
 func (m *MockRepository) UpdateCoupon(ctx context.Context, data *domain.Coupon) (*domain.Coupon, error) {
 	m.ctrl.T.Helper()
@@ -220,3 +544,17 @@ func (mr *MockRepositoryMockRecorder) UpdateCoupon(ctx, data any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCoupon", reflect.TypeOf((*MockRepository)(nil).UpdateCoupon), ctx, data)
 }
+
+// UpdateUserRole mocks base method.
+func (m *MockRepository) UpdateUserRole(ctx context.Context, id uint64, role enums.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRole", ctx, id, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserRole indicates an expected call of UpdateUserRole.
+func (mr *MockRepositoryMockRecorder) UpdateUserRole(ctx, id, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockRepository)(nil).UpdateUserRole), ctx, id, role)
+}
@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/shared/external/cache"
+	"coupon_be/shared/external/database"
+	"coupon_be/util"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"fmt"
+	"time"
+)
+
+const (
+	couponDetailCacheKey         = "coupon:name:%s"
+	couponShardRemainingCacheKey = "coupon:%d:shard_remaining"
+	couponListCacheKey           = "coupons:page:%d:%d:search:%s"
+
+	// couponCacheTag tags the cached reads of a single coupon.
+	couponCacheTag = "coupon:%d"
+	// couponListCacheTag tags the cached listings.
+	couponListCacheTag = "coupons"
+	// couponShardsCacheTag tags the cached sums of the shards, as a shard is written by its own id only.
+	couponShardsCacheTag = "coupon_shards"
+	// userClaimsCacheTag tags the cached claimants, as a claim is transferred by its own id only.
+	userClaimsCacheTag = "user_claims"
+)
+
+// CacheTTL is how long the cached reads live.
+type CacheTTL struct {
+	Detail time.Duration
+	List   time.Duration
+}
+
+// cachedRepo reads the coupon detail and listing through the cache, and invalidates them on the writes changing them.
+// The reads checking the stock of a claim are never cached, and neither are the reads made in a transaction or with
+// the cache bypassed.
+//
+// The listing carries the name and amount of the coupons only, so it is not invalidated by the claims, and its
+// remaining amounts may be as old as its ttl. The writes are invalidated once committed, and a read racing with a
+// write, i.e. started before the commit and cached after its invalidation, may still cache the value before the write
+// for its ttl.
+type cachedRepo struct {
+	Repository
+	cache cache.ICache
+	ttl   CacheTTL
+}
+
+func NewCachedRepository(repository Repository, c cache.ICache, ttl CacheTTL) Repository {
+	return &cachedRepo{Repository: repository, cache: c, ttl: ttl}
+}
+
+type cachedCouponList struct {
+	Coupons []*domain.Coupon
+	Total   int64
+}
+
+func (r *cachedRepo) FindCouponByName(ctx context.Context, name string, withClaimBy bool) (*domain.Coupon, error) {
+	if !withClaimBy || !r.cacheable(ctx) {
+		return r.Repository.FindCouponByName(ctx, name, withClaimBy)
+	}
+
+	key := fmt.Sprintf(couponDetailCacheKey, name)
+
+	var cached domain.Coupon
+	if r.get(ctx, key, &cached) {
+		return &cached, nil
+	}
+
+	result, err := r.Repository.FindCouponByName(ctx, name, withClaimBy)
+	if err != nil {
+		return nil, err
+	}
+
+	// the detail is returned as cached, so it does not depend on whether the cache is hit
+	result = cachedCouponOf(result)
+	r.set(ctx, key, result, r.ttl.Detail, fmt.Sprintf(couponCacheTag, result.ID), userClaimsCacheTag)
+
+	return result, nil
+}
+
+// cachedCouponOf returns a copy of the coupon whose claimants carry their id and username only, so the password hashes
+// and roles of the users are never written to the shared cache.
+func cachedCouponOf(coupon *domain.Coupon) *domain.Coupon {
+	cached := *coupon
+	if coupon.ClaimedBy == nil {
+		return &cached
+	}
+
+	cached.ClaimedBy = make([]*domain.User, len(coupon.ClaimedBy))
+	for i, user := range coupon.ClaimedBy {
+		cached.ClaimedBy[i] = &domain.User{BaseModel: domain.BaseModel{ID: user.ID}, Username: user.Username}
+	}
+
+	return &cached
+}
+
+func (r *cachedRepo) FindCouponShardRemainingAmount(ctx context.Context, couponID uint64) (uint64, error) {
+	if !r.cacheable(ctx) {
+		return r.Repository.FindCouponShardRemainingAmount(ctx, couponID)
+	}
+
+	key := fmt.Sprintf(couponShardRemainingCacheKey, couponID)
+
+	var cached uint64
+	if r.get(ctx, key, &cached) {
+		return cached, nil
+	}
+
+	result, err := r.Repository.FindCouponShardRemainingAmount(ctx, couponID)
+	if err != nil {
+		return 0, err
+	}
+
+	r.set(ctx, key, result, r.ttl.Detail, fmt.Sprintf(couponCacheTag, couponID), couponShardsCacheTag)
+
+	return result, nil
+}
+
+func (r *cachedRepo) FindCouponsPaginated(ctx context.Context, search string, p *util.Pagination) ([]*domain.Coupon, error) {
+	if !r.cacheable(ctx) {
+		return r.Repository.FindCouponsPaginated(ctx, search, p)
+	}
+
+	key := fmt.Sprintf(couponListCacheKey, p.Page(), p.Limit(), search)
+
+	var cached cachedCouponList
+	if r.get(ctx, key, &cached) {
+		p.SetTotal(cached.Total)
+		return cached.Coupons, nil
+	}
+
+	result, err := r.Repository.FindCouponsPaginated(ctx, search, p)
+	if err != nil {
+		return nil, err
+	}
+
+	r.set(ctx, key, &cachedCouponList{Coupons: result, Total: p.Total()}, r.ttl.List, couponListCacheTag)
+
+	return result, nil
+}
+
+func (r *cachedRepo) CreateCoupon(ctx context.Context, data *domain.Coupon) (*domain.Coupon, error) {
+	result, err := r.Repository.CreateCoupon(ctx, data)
+	if err == nil {
+		r.invalidate(ctx, couponListCacheTag)
+	}
+
+	return result, err
+}
+
+func (r *cachedRepo) UpdateCoupon(ctx context.Context, data *domain.Coupon) (*domain.Coupon, error) {
+	result, err := r.Repository.UpdateCoupon(ctx, data)
+	if err == nil {
+		r.invalidate(ctx, fmt.Sprintf(couponCacheTag, data.ID), couponListCacheTag)
+	}
+
+	return result, err
+}
+
+func (r *cachedRepo) DecrementCouponRemainingAmount(ctx context.Context, id uint64, fence int64) error {
+	err := r.Repository.DecrementCouponRemainingAmount(ctx, id, fence)
+	if err == nil {
+		r.invalidate(ctx, fmt.Sprintf(couponCacheTag, id))
+	}
+
+	return err
+}
+
+func (r *cachedRepo) DecrementCouponRemainingAmountByVersion(ctx context.Context, id, version uint64) error {
+	err := r.Repository.DecrementCouponRemainingAmountByVersion(ctx, id, version)
+	if err == nil {
+		r.invalidate(ctx, fmt.Sprintf(couponCacheTag, id))
+	}
+
+	return err
+}
+
+func (r *cachedRepo) GrantCouponRemainingAmount(ctx context.Context, id, consumed, overQuota uint64, fence int64) error {
+	err := r.Repository.GrantCouponRemainingAmount(ctx, id, consumed, overQuota, fence)
+	if err == nil {
+		r.invalidate(ctx, fmt.Sprintf(couponCacheTag, id), couponListCacheTag)
+	}
+
+	return err
+}
+
+func (r *cachedRepo) CorrectCouponRemainingAmount(ctx context.Context, id, observed, remaining uint64) error {
+	err := r.Repository.CorrectCouponRemainingAmount(ctx, id, observed, remaining)
+	if err == nil {
+		r.invalidate(ctx, fmt.Sprintf(couponCacheTag, id))
+	}
+
+	return err
+}
+
+func (r *cachedRepo) DecrementCouponShardRemainingAmount(ctx context.Context, id uint64, fence int64) error {
+	err := r.Repository.DecrementCouponShardRemainingAmount(ctx, id, fence)
+	if err == nil {
+		r.invalidate(ctx, couponShardsCacheTag)
+	}
+
+	return err
+}
+
+func (r *cachedRepo) CreateUserClaim(ctx context.Context, data *domain.UserClaim) (*domain.UserClaim, error) {
+	result, err := r.Repository.CreateUserClaim(ctx, data)
+	if err == nil {
+		r.invalidate(ctx, fmt.Sprintf(couponCacheTag, data.CouponID))
+	}
+
+	return result, err
+}
+
+func (r *cachedRepo) CreateUserClaims(ctx context.Context, data []*domain.UserClaim) error {
+	err := r.Repository.CreateUserClaims(ctx, data)
+	if err == nil {
+		tags := make([]string, 0, len(data))
+		seen := make(map[uint64]bool, len(data))
+		for _, claim := range data {
+			if !seen[claim.CouponID] {
+				seen[claim.CouponID] = true
+				tags = append(tags, fmt.Sprintf(couponCacheTag, claim.CouponID))
+			}
+		}
+
+		r.invalidate(ctx, tags...)
+	}
+
+	return err
+}
+
+func (r *cachedRepo) TransferUserClaim(ctx context.Context, id, fromUserID, toUserID uint64, maxTransfers int) error {
+	err := r.Repository.TransferUserClaim(ctx, id, fromUserID, toUserID, maxTransfers)
+	if err == nil {
+		r.invalidate(ctx, userClaimsCacheTag)
+	}
+
+	return err
+}
+
+func (r *cachedRepo) cacheable(ctx context.Context) bool {
+	if constant.CacheBypassFromCtx(ctx) {
+		logger.Debug(ctx, "cache is bypassed")
+		return false
+	}
+
+	_, inTx := database.GetTxFromCtx(ctx)
+
+	return !inTx
+}
+
+// get reports a cache failure as a miss, the read falling back to the database.
+func (r *cachedRepo) get(ctx context.Context, key string, dst any) bool {
+	found, err := r.cache.Get(ctx, key, dst)
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on read cache %s : %v", key, err)
+		return false
+	}
+
+	return found
+}
+
+func (r *cachedRepo) set(ctx context.Context, key string, value any, ttl time.Duration, tags ...string) {
+	if err := r.cache.Set(ctx, key, value, ttl, tags...); err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on write cache %s : %v", key, err)
+	}
+}
+
+// invalidate does not fail the write, the stale entries expiring with their ttl. A write made in a transaction is
+// invalidated once the transaction is committed, since a read between the invalidation and the commit would cache the
+// value before the write again.
+func (r *cachedRepo) invalidate(ctx context.Context, tags ...string) {
+	database.AfterCommit(ctx, func() {
+		if err := r.cache.Invalidate(ctx, tags...); err != nil {
+			logger.Error(ctx, "[REPOSITORY] Failed on invalidate cache %v : %v", tags, err)
+		}
+	})
+}
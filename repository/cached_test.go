@@ -0,0 +1,396 @@
+package repository
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	m "coupon_be/mock"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/database"
+	"coupon_be/util"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var testCacheTTL = CacheTTL{Detail: 5 * time.Second, List: 10 * time.Second}
+
+func TestCachedRepository_FindCouponByName(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+	coupon := m.InitCouponDomain()
+
+	var (
+		repo *m.MockRepository
+		c    *m.MockICache
+	)
+
+	testCases := []struct {
+		name        string
+		ctx         context.Context
+		withClaimBy bool
+		prepareMock func()
+		wantErr     bool
+		// expectedClaimedBy are the usernames of the claimants, when the coupon read has any.
+		expectedClaimedBy []string
+	}{
+		{
+			name:        "cache hit",
+			ctx:         ctx,
+			withClaimBy: true,
+			prepareMock: func() {
+				c.EXPECT().Get(ctx, gomock.Eq("coupon:name:COUPON_TEST"), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, dst any) (bool, error) {
+						*dst.(*domain.Coupon) = *coupon
+						return true, nil
+					}).
+					Times(1)
+				repo.EXPECT().FindCouponByName(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name:        "cache miss",
+			ctx:         ctx,
+			withClaimBy: true,
+			prepareMock: func() {
+				c.EXPECT().Get(ctx, gomock.Eq("coupon:name:COUPON_TEST"), gomock.Any()).
+					Return(false, nil).
+					Times(1)
+				repo.EXPECT().FindCouponByName(ctx, gomock.Eq("COUPON_TEST"), gomock.Eq(true)).
+					Return(coupon, nil).
+					Times(1)
+				c.EXPECT().Set(ctx, gomock.Eq("coupon:name:COUPON_TEST"), gomock.Eq(coupon), gomock.Eq(5*time.Second),
+					gomock.Eq("coupon:1"), gomock.Eq("user_claims")).
+					Return(nil).
+					Times(1)
+			},
+		},
+		{
+			name:        "cache miss caches the claimants without their password",
+			ctx:         ctx,
+			withClaimBy: true,
+			prepareMock: func() {
+				c.EXPECT().Get(ctx, gomock.Eq("coupon:name:COUPON_TEST"), gomock.Any()).
+					Return(false, nil).
+					Times(1)
+				claimed := *coupon
+				claimed.ClaimedBy = []*domain.User{
+					{BaseModel: domain.BaseModel{ID: 2}, Username: "bob", Password: "$2a$10$hash", Role: enums.RoleCustomer},
+				}
+				repo.EXPECT().FindCouponByName(ctx, gomock.Eq("COUPON_TEST"), gomock.Eq(true)).
+					Return(&claimed, nil).
+					Times(1)
+				c.EXPECT().Set(ctx, gomock.Eq("coupon:name:COUPON_TEST"), gomock.Any(), gomock.Eq(5*time.Second),
+					gomock.Eq("coupon:1"), gomock.Eq("user_claims")).
+					DoAndReturn(func(_ context.Context, _ string, value any, _ time.Duration, _ ...string) error {
+						assert.Equal(t, []*domain.User{{BaseModel: domain.BaseModel{ID: 2}, Username: "bob"}},
+							value.(*domain.Coupon).ClaimedBy)
+						return nil
+					}).
+					Times(1)
+			},
+			expectedClaimedBy: []string{"bob"},
+		},
+		{
+			name:        "cache failure falls back to the database",
+			ctx:         ctx,
+			withClaimBy: true,
+			prepareMock: func() {
+				c.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).
+					Return(false, sharedErrs.New(sharedErrs.ErrKindRedis, "redis is down")).
+					Times(1)
+				repo.EXPECT().FindCouponByName(ctx, gomock.Eq("COUPON_TEST"), gomock.Eq(true)).
+					Return(coupon, nil).
+					Times(1)
+				c.EXPECT().Set(ctx, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(sharedErrs.New(sharedErrs.ErrKindRedis, "redis is down")).
+					Times(1)
+			},
+		},
+		{
+			name:        "not found is not cached",
+			ctx:         ctx,
+			withClaimBy: true,
+			prepareMock: func() {
+				c.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).
+					Return(false, nil).
+					Times(1)
+				repo.EXPECT().FindCouponByName(ctx, gomock.Eq("COUPON_TEST"), gomock.Eq(true)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				c.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr: true,
+		},
+		{
+			name:        "claim read is not cached",
+			ctx:         ctx,
+			withClaimBy: false,
+			prepareMock: func() {
+				c.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				repo.EXPECT().FindCouponByName(ctx, gomock.Eq("COUPON_TEST"), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+			},
+		},
+		{
+			name:        "bypassed",
+			ctx:         context.WithValue(ctx, constant.XCacheBypassKey, true),
+			withClaimBy: true,
+			prepareMock: func() {
+				c.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				repo.EXPECT().FindCouponByName(gomock.Any(), gomock.Eq("COUPON_TEST"), gomock.Eq(true)).
+					Return(coupon, nil).
+					Times(1)
+				c.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ctrl := gomock.NewController(t)
+			repo = m.NewMockRepository(ctrl)
+			c = m.NewMockICache(ctrl)
+			tc.prepareMock()
+
+			// Act
+			result, err := NewCachedRepository(repo, c, testCacheTTL).FindCouponByName(tc.ctx, "COUPON_TEST", tc.withClaimBy)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if !tc.wantErr && tc.expectedClaimedBy == nil {
+				assert.Equal(t, coupon, result)
+			}
+			for i, username := range tc.expectedClaimedBy {
+				assert.Equal(t, username, result.ClaimedBy[i].Username)
+				assert.Empty(t, result.ClaimedBy[i].Password)
+			}
+		})
+	}
+}
+
+func TestCachedRepository_FindCouponsPaginated(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+	coupons := []*domain.Coupon{m.InitCouponDomain()}
+
+	ctrl := gomock.NewController(t)
+	repo := m.NewMockRepository(ctrl)
+	c := m.NewMockICache(ctrl)
+
+	c.EXPECT().Get(ctx, gomock.Eq("coupons:page:2:10:search:COUPON"), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, dst any) (bool, error) {
+			*dst.(*cachedCouponList) = cachedCouponList{Coupons: coupons, Total: 11}
+			return true, nil
+		}).
+		Times(1)
+	repo.EXPECT().FindCouponsPaginated(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	var p util.Pagination
+	p.SetPage(2)
+	p.SetLimit(10)
+
+	result, err := NewCachedRepository(repo, c, testCacheTTL).FindCouponsPaginated(ctx, "COUPON", &p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, coupons, result)
+	assert.Equal(t, int64(11), p.Total())
+}
+
+func TestCachedRepository_InTransaction(t *testing.T) {
+	logger.Initialise()
+
+	writeDB, _, err := m.NewMockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	repo := m.NewMockRepository(ctrl)
+	c := m.NewMockICache(ctrl)
+
+	tCtx, _ := database.InitTx(context.Background(), writeDB)
+
+	c.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	repo.EXPECT().FindCouponShardRemainingAmount(tCtx, gomock.Eq(uint64(1))).
+		Return(uint64(5), nil).
+		Times(1)
+
+	result, err := NewCachedRepository(repo, c, testCacheTTL).FindCouponShardRemainingAmount(tCtx, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), result)
+}
+
+func TestCachedRepository_Invalidate(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+
+	var (
+		repo *m.MockRepository
+		c    *m.MockICache
+	)
+
+	testCases := []struct {
+		name          string
+		prepareMock   func()
+		write         func(r Repository) error
+		expectedError error
+	}{
+		{
+			name: "claim invalidates the coupon",
+			prepareMock: func() {
+				repo.EXPECT().DecrementCouponRemainingAmount(ctx, gomock.Eq(uint64(1)), gomock.Eq(int64(3))).
+					Return(nil).
+					Times(1)
+				c.EXPECT().Invalidate(ctx, gomock.Eq("coupon:1")).
+					Return(nil).
+					Times(1)
+			},
+			write: func(r Repository) error {
+				return r.DecrementCouponRemainingAmount(ctx, 1, 3)
+			},
+		},
+		{
+			name: "grant invalidates the coupon and the listing",
+			prepareMock: func() {
+				repo.EXPECT().GrantCouponRemainingAmount(ctx, gomock.Eq(uint64(1)), gomock.Eq(uint64(2)), gomock.Eq(uint64(1)), gomock.Eq(int64(3))).
+					Return(nil).
+					Times(1)
+				c.EXPECT().Invalidate(ctx, gomock.Eq("coupon:1"), gomock.Eq("coupons")).
+					Return(nil).
+					Times(1)
+			},
+			write: func(r Repository) error {
+				return r.GrantCouponRemainingAmount(ctx, 1, 2, 1, 3)
+			},
+		},
+		{
+			name: "claims invalidate every claimed coupon once",
+			prepareMock: func() {
+				repo.EXPECT().CreateUserClaims(ctx, gomock.Any()).
+					Return(nil).
+					Times(1)
+				c.EXPECT().Invalidate(ctx, gomock.Eq("coupon:1"), gomock.Eq("coupon:2")).
+					Return(nil).
+					Times(1)
+			},
+			write: func(r Repository) error {
+				return r.CreateUserClaims(ctx, []*domain.UserClaim{
+					{UserID: 1, CouponID: 1},
+					{UserID: 2, CouponID: 1},
+					{UserID: 1, CouponID: 2},
+				})
+			},
+		},
+		{
+			name: "failed write invalidates nothing",
+			prepareMock: func() {
+				repo.EXPECT().DecrementCouponShardRemainingAmount(ctx, gomock.Eq(uint64(1)), gomock.Eq(int64(3))).
+					Return(sharedErrs.NotFoundErr).
+					Times(1)
+				c.EXPECT().Invalidate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			write: func(r Repository) error {
+				return r.DecrementCouponShardRemainingAmount(ctx, 1, 3)
+			},
+			expectedError: sharedErrs.NotFoundErr,
+		},
+		{
+			name: "cache failure does not fail the write",
+			prepareMock: func() {
+				repo.EXPECT().TransferUserClaim(ctx, gomock.Eq(uint64(1)), gomock.Eq(uint64(2)), gomock.Eq(uint64(3)), gomock.Eq(1)).
+					Return(nil).
+					Times(1)
+				c.EXPECT().Invalidate(ctx, gomock.Eq("user_claims")).
+					Return(sharedErrs.New(sharedErrs.ErrKindRedis, "redis is down")).
+					Times(1)
+			},
+			write: func(r Repository) error {
+				return r.TransferUserClaim(ctx, 1, 2, 3, 1)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ctrl := gomock.NewController(t)
+			repo = m.NewMockRepository(ctrl)
+			c = m.NewMockICache(ctrl)
+			tc.prepareMock()
+
+			// Act
+			err := tc.write(NewCachedRepository(repo, c, testCacheTTL))
+
+			// Assert
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestCachedRepository_InvalidateAfterCommit(t *testing.T) {
+	logger.Initialise()
+
+	testCases := []struct {
+		name   string
+		commit bool
+	}{
+		{
+			name:   "committed write is invalidated after the commit",
+			commit: true,
+		},
+		{
+			name: "rolled back write is not invalidated",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			writeDB, sqlMock, err := m.NewMockDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctrl := gomock.NewController(t)
+			repo := m.NewMockRepository(ctrl)
+			c := m.NewMockICache(ctrl)
+
+			sqlMock.ExpectBegin()
+			tCtx, tx := database.InitTx(context.Background(), writeDB)
+
+			repo.EXPECT().DecrementCouponRemainingAmount(tCtx, gomock.Eq(uint64(1)), gomock.Eq(int64(3))).
+				Return(nil).
+				Times(1)
+
+			// Act
+			err = NewCachedRepository(repo, c, testCacheTTL).DecrementCouponRemainingAmount(tCtx, 1, 3)
+			assert.NoError(t, err)
+
+			// Assert
+			if !tc.commit {
+				c.EXPECT().Invalidate(gomock.Any(), gomock.Any()).Times(0)
+				sqlMock.ExpectRollback()
+				assert.NoError(t, tx.Rollback().Error)
+				return
+			}
+
+			// nothing is invalidated before the commit
+			c.EXPECT().Invalidate(tCtx, gomock.Eq("coupon:1")).
+				Return(nil).
+				Times(1)
+			sqlMock.ExpectCommit()
+			assert.NoError(t, database.Commit(tCtx, tx))
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"coupon_be/domain"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/database"
+	"coupon_be/util/logger"
+
+	"gorm.io/gorm/clause"
+)
+
+func (r *repo) FindClaimTicketByTicketID(ctx context.Context, ticketID string) (*domain.ClaimTicket, error) {
+	var result *domain.ClaimTicket
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	err := db.WithContext(ctx).
+		Where("ticket_id = ?", ticketID).
+		First(&result).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on find claim ticket by ticket id : %v", err)
+
+		return nil, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return result, nil
+}
+
+func (r *repo) CreateClaimTicket(ctx context.Context, data *domain.ClaimTicket) (*domain.ClaimTicket, error) {
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	err := db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Create(&data).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on create claim ticket: %v", err)
+
+		return nil, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return data, nil
+}
+
+func (r *repo) UpdateClaimTicket(ctx context.Context, data *domain.ClaimTicket) (*domain.ClaimTicket, error) {
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	err := db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Select("status", "reason", "attempts", "updated_at").
+		Updates(&data).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on update claim ticket: %v", err)
+
+		return nil, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return data, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"coupon_be/domain"
+	"coupon_be/domain/enums"
 	"coupon_be/util"
 )
 
@@ -12,18 +13,49 @@ type Repository interface {
 	// User
 	FindUserByUsername(ctx context.Context, username string) (*domain.User, error)
 	FindUserByID(ctx context.Context, id uint64) (*domain.User, error)
+	FindUsersByUsernames(ctx context.Context, usernames []string) ([]*domain.User, error)
+	FindUsersByIDs(ctx context.Context, ids []uint64) ([]*domain.User, error)
 	CreateUser(ctx context.Context, data *domain.User) (*domain.User, error)
+	FindUserCountByRole(ctx context.Context, role enums.Role) (int64, error)
+	UpdateUserRole(ctx context.Context, id uint64, role enums.Role) error
 
 	// Coupon
 	FindCouponByID(ctx context.Context, id uint64) (*domain.Coupon, error)
+	FindCouponByIDForUpdate(ctx context.Context, id uint64) (*domain.Coupon, error)
 	FindCouponByName(ctx context.Context, name string, withClaimBy bool) (*domain.Coupon, error)
 	FindCouponsPaginated(ctx context.Context, search string, p *util.Pagination) ([]*domain.Coupon, error)
+	FindCouponsByClaimStrategy(ctx context.Context, strategies []enums.ClaimStrategy) ([]*domain.Coupon, error)
 	CreateCoupon(ctx context.Context, data *domain.Coupon) (*domain.Coupon, error)
 	UpdateCoupon(ctx context.Context, data *domain.Coupon) (*domain.Coupon, error)
-	DecrementCouponRemainingAmount(ctx context.Context, id uint64) error
+	DecrementCouponRemainingAmount(ctx context.Context, id uint64, fence int64) error
+	DecrementCouponRemainingAmountByVersion(ctx context.Context, id, version uint64) error
+	GrantCouponRemainingAmount(ctx context.Context, id, consumed, overQuota uint64, fence int64) error
+
+	// Coupon Shard
+	FindCouponShards(ctx context.Context, couponID uint64) ([]*domain.CouponShard, error)
+	FindCouponShardRemainingAmount(ctx context.Context, couponID uint64) (uint64, error)
+	CreateCouponShards(ctx context.Context, data []*domain.CouponShard) ([]*domain.CouponShard, error)
+	DecrementCouponShardRemainingAmount(ctx context.Context, id uint64, fence int64) error
 
 	// User Claim
 	FindUserClaimByUserIDAndCouponID(ctx context.Context, userID, couponID uint64) (*domain.UserClaim, error)
 	FindUserClaimCountByCouponID(ctx context.Context, couponID uint64) (int64, error)
+	FindClaimedUserIDsByCouponID(ctx context.Context, couponID uint64) ([]uint64, error)
+	FindClaimedUserIDsByCouponIDAndUserIDs(ctx context.Context, couponID uint64, userIDs []uint64) ([]uint64, error)
 	CreateUserClaim(ctx context.Context, data *domain.UserClaim) (*domain.UserClaim, error)
+	CreateUserClaims(ctx context.Context, data []*domain.UserClaim) error
+
+	// Coupon Transfer
+	TransferUserClaim(ctx context.Context, id, fromUserID, toUserID uint64, maxTransfers int) error
+	CreateCouponTransfer(ctx context.Context, data *domain.CouponTransfer) (*domain.CouponTransfer, error)
+
+	// Claim Ticket
+	FindClaimTicketByTicketID(ctx context.Context, ticketID string) (*domain.ClaimTicket, error)
+	CreateClaimTicket(ctx context.Context, data *domain.ClaimTicket) (*domain.ClaimTicket, error)
+	UpdateClaimTicket(ctx context.Context, data *domain.ClaimTicket) (*domain.ClaimTicket, error)
+
+	// Coupon Stock Drift
+	FindCouponStocks(ctx context.Context) ([]*domain.CouponStock, error)
+	CorrectCouponRemainingAmount(ctx context.Context, id, observed, remaining uint64) error
+	CreateCouponStockDrifts(ctx context.Context, data []*domain.CouponStockDrift) error
 }
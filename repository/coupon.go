@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"coupon_be/domain"
+	"coupon_be/domain/enums"
 	sharedErrs "coupon_be/shared/errors"
 	"coupon_be/shared/external/database"
 	"coupon_be/util"
@@ -29,6 +30,25 @@ func (r *repo) FindCouponByID(ctx context.Context, id uint64) (*domain.Coupon, e
 	return result, nil
 }
 
+// FindCouponByIDForUpdate finds the coupon and locks its row until the end of the transaction in ctx.
+func (r *repo) FindCouponByIDForUpdate(ctx context.Context, id uint64) (*domain.Coupon, error) {
+	var result *domain.Coupon
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	err := db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&result, id).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on find coupon by id for update : %v", err)
+
+		return nil, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return result, nil
+}
+
 func (r *repo) FindCouponByName(ctx context.Context, name string, withClaimBy bool) (*domain.Coupon, error) {
 	var result *domain.Coupon
 
@@ -84,6 +104,24 @@ func (r *repo) FindCouponsPaginated(ctx context.Context, search string, p *util.
 	return result, nil
 }
 
+func (r *repo) FindCouponsByClaimStrategy(ctx context.Context, strategies []enums.ClaimStrategy) ([]*domain.Coupon, error) {
+	var result []*domain.Coupon
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	err := db.WithContext(ctx).
+		Where("claim_strategy IN ?", strategies).
+		Find(&result).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on find coupons by claim strategy: %v", err)
+
+		return nil, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return result, nil
+}
+
 func (r *repo) CreateCoupon(ctx context.Context, data *domain.Coupon) (*domain.Coupon, error) {
 	db, _ := database.ConnFromCtx(ctx, r.DB)
 
@@ -116,21 +154,130 @@ func (r *repo) UpdateCoupon(ctx context.Context, data *domain.Coupon) (*domain.C
 	return data, nil
 }
 
-func (r *repo) DecrementCouponRemainingAmount(ctx context.Context, id uint64) error {
+// DecrementCouponRemainingAmount decrements the remaining amount only when there is stock left.
+// It returns NotFoundErr when no stock is left, since no row matches the condition.
+//
+// A positive fence is the fencing token of the coupon claim lock. The decrement is rejected with StaleFenceErr when a
+// newer token has been stored already, otherwise the token is stored as the last fence of the coupon.
+func (r *repo) DecrementCouponRemainingAmount(ctx context.Context, id uint64, fence int64) error {
 	var result *domain.Coupon
 
 	db, _ := database.ConnFromCtx(ctx, r.DB)
 
-	err := db.WithContext(ctx).
+	columns := map[string]any{
+		"remaining_amount": gorm.Expr("remaining_amount - 1"),
+		"version":          gorm.Expr("version + 1"),
+	}
+
+	query := db.WithContext(ctx).
 		Model(&result).
-		Where("id = ? AND remaining_amount > 0", id).
-		UpdateColumn("remaining_amount", gorm.Expr("remaining_amount - 1")).
-		Error
-	if err != nil {
+		Where("id = ? AND remaining_amount > 0", id)
+	if fence > 0 {
+		query = query.Where("last_fence <= ?", fence)
+		columns["last_fence"] = fence
+	}
+
+	query = query.UpdateColumns(columns)
+	if err := query.Error; err != nil {
 		logger.Error(ctx, "[REPOSITORY] Failed on decrement coupon remaining amount: %v", err)
 
 		return sharedErrs.NewRepositoryErr(err, "%s", err.Error())
 	}
 
+	if query.RowsAffected > 0 {
+		return nil
+	}
+
+	return decrementRejectedErr(ctx, db, id, fence)
+}
+
+// GrantCouponRemainingAmount consumes the given amount of stock granted to users, and raises the amount of the coupon
+// by the grants over its quota. It returns NotFoundErr when less stock than consumed is left, the fence is checked as
+// in DecrementCouponRemainingAmount.
+func (r *repo) GrantCouponRemainingAmount(ctx context.Context, id, consumed, overQuota uint64, fence int64) error {
+	var result *domain.Coupon
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	columns := map[string]any{
+		"amount":           gorm.Expr("amount + ?", overQuota),
+		"remaining_amount": gorm.Expr("remaining_amount - ?", consumed),
+		"version":          gorm.Expr("version + 1"),
+	}
+
+	query := db.WithContext(ctx).
+		Model(&result).
+		Where("id = ? AND remaining_amount >= ?", id, consumed)
+	if fence > 0 {
+		query = query.Where("last_fence <= ?", fence)
+		columns["last_fence"] = fence
+	}
+
+	query = query.UpdateColumns(columns)
+	if err := query.Error; err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on grant coupon remaining amount: %v", err)
+
+		return sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	if query.RowsAffected > 0 {
+		return nil
+	}
+
+	return decrementRejectedErr(ctx, db, id, fence)
+}
+
+// decrementRejectedErr tells why a fenced stock update of the coupon matched no row: StaleFenceErr when a newer fence
+// is stored already, NotFoundErr otherwise.
+func decrementRejectedErr(ctx context.Context, db *gorm.DB, id uint64, fence int64) error {
+	if fence > 0 {
+		var lastFence int64
+
+		err := db.WithContext(ctx).
+			Model(&domain.Coupon{}).
+			Select("last_fence").
+			Where("id = ?", id).
+			Scan(&lastFence).
+			Error
+		if err != nil {
+			logger.Error(ctx, "[REPOSITORY] Failed on find coupon last fence: %v", err)
+
+			return sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+		}
+
+		if lastFence > fence {
+			logger.Warn(ctx, "[REPOSITORY] Rejected decrement of coupon %d with fence %d, last fence is %d", id, fence, lastFence)
+
+			return sharedErrs.StaleFenceErr
+		}
+	}
+
+	return sharedErrs.NotFoundErr
+}
+
+// DecrementCouponRemainingAmountByVersion decrements the remaining amount only when the coupon still has the given
+// version. It returns NotFoundErr when the coupon has been modified in the meantime or no stock is left.
+func (r *repo) DecrementCouponRemainingAmountByVersion(ctx context.Context, id, version uint64) error {
+	var result *domain.Coupon
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	query := db.WithContext(ctx).
+		Model(&result).
+		Where("id = ? AND version = ? AND remaining_amount > 0", id, version).
+		UpdateColumns(map[string]any{
+			"remaining_amount": gorm.Expr("remaining_amount - 1"),
+			"version":          gorm.Expr("version + 1"),
+		})
+	if err := query.Error; err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on decrement coupon remaining amount by version: %v", err)
+
+		return sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	if query.RowsAffected == 0 {
+		return sharedErrs.NotFoundErr
+	}
+
 	return nil
 }
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"coupon_be/domain"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/database"
+	"coupon_be/util/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *repo) FindCouponShards(ctx context.Context, couponID uint64) ([]*domain.CouponShard, error) {
+	var result []*domain.CouponShard
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	err := db.WithContext(ctx).
+		Where("coupon_id = ?", couponID).
+		Order("shard_index").
+		Find(&result).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on find coupon shards: %v", err)
+
+		return nil, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return result, nil
+}
+
+// FindCouponShardRemainingAmount returns the sum of the remaining amounts of the shards of the coupon.
+func (r *repo) FindCouponShardRemainingAmount(ctx context.Context, couponID uint64) (uint64, error) {
+	var result uint64
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	err := db.WithContext(ctx).
+		Model(&domain.CouponShard{}).
+		Select("COALESCE(SUM(remaining_amount), 0)").
+		Where("coupon_id = ?", couponID).
+		Scan(&result).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on find coupon shard remaining amount: %v", err)
+
+		return 0, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return result, nil
+}
+
+func (r *repo) CreateCouponShards(ctx context.Context, data []*domain.CouponShard) ([]*domain.CouponShard, error) {
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	err := db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Create(&data).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on create coupon shards: %v", err)
+
+		return nil, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return data, nil
+}
+
+// DecrementCouponShardRemainingAmount decrements the remaining amount of the shard only when there is stock left.
+// It returns NotFoundErr when no stock is left, and StaleFenceErr when a newer fencing token has been stored already.
+// A zero fence decrements the shard unfenced, when it is claimed without its lock.
+func (r *repo) DecrementCouponShardRemainingAmount(ctx context.Context, id uint64, fence int64) error {
+	var result *domain.CouponShard
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	columns := map[string]any{
+		"remaining_amount": gorm.Expr("remaining_amount - 1"),
+	}
+
+	query := db.WithContext(ctx).
+		Model(&result).
+		Where("id = ? AND remaining_amount > 0", id)
+	if fence > 0 {
+		query = query.Where("last_fence <= ?", fence)
+		columns["last_fence"] = fence
+	}
+
+	query = query.UpdateColumns(columns)
+	if err := query.Error; err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on decrement coupon shard remaining amount: %v", err)
+
+		return sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	if query.RowsAffected > 0 {
+		return nil
+	}
+
+	if fence == 0 {
+		return sharedErrs.NotFoundErr
+	}
+
+	var lastFence int64
+
+	err := db.WithContext(ctx).
+		Model(&domain.CouponShard{}).
+		Select("last_fence").
+		Where("id = ?", id).
+		Scan(&lastFence).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on find coupon shard last fence: %v", err)
+
+		return sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	if lastFence > fence {
+		logger.Warn(ctx, "[REPOSITORY] Rejected decrement of coupon shard %d with fence %d, last fence is %d", id, fence, lastFence)
+
+		return sharedErrs.StaleFenceErr
+	}
+
+	return sharedErrs.NotFoundErr
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"coupon_be/domain"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/database"
+	"coupon_be/util/logger"
+
+	"gorm.io/gorm"
+)
+
+// FindCouponStocks returns the stock of every coupon next to its claim count, read in a single statement so both
+// sides are taken from the same snapshot. The stock of a sharded coupon is the sum of its shards.
+func (r *repo) FindCouponStocks(ctx context.Context) ([]*domain.CouponStock, error) {
+	var result []*domain.CouponStock
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	err := db.WithContext(ctx).
+		Table("coupons").
+		Select("coupons.id AS coupon_id, coupons.name AS coupon_name, coupons.amount, coupons.shard_count, " +
+			"CASE WHEN coupons.shard_count > 1 THEN (SELECT COALESCE(SUM(coupon_shards.remaining_amount), 0) " +
+			"FROM coupon_shards WHERE coupon_shards.coupon_id = coupons.id) ELSE coupons.remaining_amount END AS remaining_amount, " +
+			"COUNT(user_claims.id) AS claimed_count").
+		Joins("LEFT JOIN user_claims ON user_claims.coupon_id = coupons.id").
+		Where("coupons.deleted_at IS NULL").
+		Group("coupons.id").
+		Order("coupons.id").
+		Scan(&result).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on find coupon stocks: %v", err)
+
+		return nil, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return result, nil
+}
+
+// CorrectCouponRemainingAmount overwrites the remaining amount only when it is still the observed one, so a claim
+// committed in the meantime is never lost. It returns NotFoundErr when the remaining amount has changed.
+func (r *repo) CorrectCouponRemainingAmount(ctx context.Context, id, observed, remaining uint64) error {
+	var result *domain.Coupon
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	query := db.WithContext(ctx).
+		Model(&result).
+		Where("id = ? AND remaining_amount = ?", id, observed).
+		UpdateColumns(map[string]any{
+			"remaining_amount": remaining,
+			"version":          gorm.Expr("version + 1"),
+		})
+	if err := query.Error; err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on correct coupon remaining amount: %v", err)
+
+		return sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	if query.RowsAffected == 0 {
+		return sharedErrs.NotFoundErr
+	}
+
+	return nil
+}
+
+func (r *repo) CreateCouponStockDrifts(ctx context.Context, data []*domain.CouponStockDrift) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	if err := db.WithContext(ctx).Create(&data).Error; err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on create coupon stock drifts: %v", err)
+
+		return sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return nil
+}
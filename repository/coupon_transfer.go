@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"coupon_be/domain"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/database"
+	"coupon_be/util/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TransferUserClaim moves the user claim to another user, as long as it is still held by the sender, not redeemed and
+// transferred fewer than maxTransfers times. It returns NotFoundErr when the claim does not match these conditions
+// anymore, and ConflictErr when the recipient already holds the coupon.
+func (r *repo) TransferUserClaim(ctx context.Context, id, fromUserID, toUserID uint64, maxTransfers int) error {
+	var result *domain.UserClaim
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	query := db.WithContext(ctx).
+		Model(&result).
+		Where("id = ? AND user_id = ? AND redeemed_at IS NULL AND transfer_count < ?", id, fromUserID, maxTransfers).
+		UpdateColumns(map[string]any{
+			"user_id":        toUserID,
+			"transfer_count": gorm.Expr("transfer_count + 1"),
+			"updated_at":     gorm.Expr("CURRENT_TIMESTAMP"),
+		})
+	if err := query.Error; err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on transfer user claim: %v", err)
+
+		return sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	if query.RowsAffected == 0 {
+		return sharedErrs.NotFoundErr
+	}
+
+	return nil
+}
+
+func (r *repo) CreateCouponTransfer(ctx context.Context, data *domain.CouponTransfer) (*domain.CouponTransfer, error) {
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	err := db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Create(&data).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on create coupon transfer: %v", err)
+
+		return nil, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return data, nil
+}
@@ -3,10 +3,12 @@ package repository
 import (
 	"context"
 	"coupon_be/domain"
+	"coupon_be/domain/enums"
 	sharedErrs "coupon_be/shared/errors"
 	"coupon_be/shared/external/database"
 	"coupon_be/util/logger"
 
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
@@ -45,6 +47,42 @@ func (r *repo) FindUserByID(ctx context.Context, id uint64) (*domain.User, error
 	return result, nil
 }
 
+func (r *repo) FindUsersByUsernames(ctx context.Context, usernames []string) ([]*domain.User, error) {
+	var result []*domain.User
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	err := db.WithContext(ctx).
+		Where("username IN ?", usernames).
+		Find(&result).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on find users by usernames : %v", err)
+
+		return nil, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return result, nil
+}
+
+func (r *repo) FindUsersByIDs(ctx context.Context, ids []uint64) ([]*domain.User, error) {
+	var result []*domain.User
+
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	err := db.WithContext(ctx).
+		Where("id IN ?", ids).
+		Find(&result).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on find users by ids : %v", err)
+
+		return nil, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return result, nil
+}
+
 func (r *repo) CreateUser(ctx context.Context, data *domain.User) (*domain.User, error) {
 	err := r.DB.WithContext(ctx).
 		Clauses(clause.Returning{}).
@@ -58,3 +96,46 @@ func (r *repo) CreateUser(ctx context.Context, data *domain.User) (*domain.User,
 
 	return data, nil
 }
+
+func (r *repo) FindUserCountByRole(ctx context.Context, role enums.Role) (int64, error) {
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	var count int64
+
+	err := db.WithContext(ctx).
+		Model(&domain.User{}).
+		Where("role = ?", role).
+		Count(&count).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on find user count by role : %v", err)
+
+		return 0, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return count, nil
+}
+
+// UpdateUserRole sets the role of the user. It returns NotFoundErr when the user does not exist.
+func (r *repo) UpdateUserRole(ctx context.Context, id uint64, role enums.Role) error {
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	query := db.WithContext(ctx).
+		Model(&domain.User{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]any{
+			"role":       role,
+			"updated_at": gorm.Expr("CURRENT_TIMESTAMP"),
+		})
+	if err := query.Error; err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on update user role : %v", err)
+
+		return sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	if query.RowsAffected == 0 {
+		return sharedErrs.NotFoundErr
+	}
+
+	return nil
+}
@@ -44,6 +44,21 @@ func (r *repo) CreateUserClaim(ctx context.Context, data *domain.UserClaim) (*do
 	return data, nil
 }
 
+func (r *repo) CreateUserClaims(ctx context.Context, data []*domain.UserClaim) error {
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	err := db.WithContext(ctx).
+		Create(&data).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on create user claims: %v", err)
+
+		return sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return nil
+}
+
 func (r *repo) FindUserClaimCountByCouponID(ctx context.Context, couponID uint64) (int64, error) {
 	db, _ := database.ConnFromCtx(ctx, r.DB)
 
@@ -62,3 +77,41 @@ func (r *repo) FindUserClaimCountByCouponID(ctx context.Context, couponID uint64
 
 	return count, nil
 }
+
+func (r *repo) FindClaimedUserIDsByCouponID(ctx context.Context, couponID uint64) ([]uint64, error) {
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	var result []uint64
+
+	err := db.WithContext(ctx).
+		Model(&domain.UserClaim{}).
+		Where("coupon_id = ?", couponID).
+		Pluck("user_id", &result).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on find claimed user ids by coupon id: %v", err)
+
+		return nil, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return result, nil
+}
+
+func (r *repo) FindClaimedUserIDsByCouponIDAndUserIDs(ctx context.Context, couponID uint64, userIDs []uint64) ([]uint64, error) {
+	db, _ := database.ConnFromCtx(ctx, r.DB)
+
+	var result []uint64
+
+	err := db.WithContext(ctx).
+		Model(&domain.UserClaim{}).
+		Where("coupon_id = ? AND user_id IN ?", couponID, userIDs).
+		Pluck("user_id", &result).
+		Error
+	if err != nil {
+		logger.Error(ctx, "[REPOSITORY] Failed on find claimed user ids by coupon id and user ids: %v", err)
+
+		return nil, sharedErrs.NewRepositoryErr(err, "%s", err.Error())
+	}
+
+	return result, nil
+}
@@ -9,11 +9,36 @@ type FilterCoupon struct {
 type UpsertCoupon struct {
 	ID uint64 `json:"-"`
 
-	Name   string `json:"name" validate:"required"`
-	Amount uint64 `json:"amount" validate:"required,gt=0"`
+	Name          string `json:"name" validate:"required"`
+	Amount        uint64 `json:"amount" validate:"required,gt=0"`
+	ClaimStrategy string `json:"claim_strategy" validate:"omitempty,oneof=redis_lock row_lock optimistic redis_atomic"`
+	Shards        int    `json:"shards" validate:"omitempty,min=1,max=64"`
 }
 
+// ClaimCoupon is made as the authenticated user, unless an admin names another user as user_id.
 type ClaimCoupon struct {
 	Username   string `json:"user_id" validate:"required"`
 	CouponName string `json:"coupon_name" validate:"required"`
 }
+
+// ClaimBundle is made as the authenticated user, unless an admin names another user as user_id.
+type ClaimBundle struct {
+	Username    string   `json:"user_id" validate:"required"`
+	CouponNames []string `json:"coupon_names" validate:"required,min=1,max=20,dive,required"`
+}
+
+// GrantCoupon lists the users a coupon is granted to, by username or by user id.
+type GrantCoupon struct {
+	CouponName  string `json:"-"`
+	ExceedQuota bool   `json:"-"`
+
+	Usernames []string `json:"usernames" validate:"omitempty,dive,required"`
+	UserIDs   []uint64 `json:"user_ids" validate:"omitempty,dive,gt=0"`
+}
+
+// TransferCoupon moves a coupon of the authenticated user, unless an admin names another user as user_id.
+type TransferCoupon struct {
+	Username          string `json:"user_id" validate:"required"`
+	RecipientUsername string `json:"recipient_user_id" validate:"required,nefield=Username"`
+	CouponName        string `json:"coupon_name" validate:"required"`
+}
@@ -0,0 +1,25 @@
+package request
+
+import "coupon_be/domain/enums"
+
+type Register struct {
+	Username string `json:"username" validate:"required,min=3,max=50,printascii,excludesall= "`
+	FullName string `json:"full_name" validate:"required,max=100"`
+	// bcrypt ignores everything beyond 72 bytes
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type Login struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type Refresh struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type AssignRole struct {
+	Username string `json:"-"`
+
+	Role enums.Role `json:"role" validate:"required,oneof=admin operator customer"`
+}
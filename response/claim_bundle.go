@@ -0,0 +1,10 @@
+package response
+
+type ClaimBundle struct {
+	Username    string   `json:"user_id"`
+	CouponNames []string `json:"coupon_names"`
+	Claimed     bool     `json:"claimed"`
+	// BlockedBy is the coupon which failed the bundle, the reason is in Reason.
+	BlockedBy string `json:"blocked_by,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
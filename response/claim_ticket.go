@@ -0,0 +1,43 @@
+package response
+
+import (
+	"coupon_be/domain"
+	"time"
+)
+
+type ClaimTicket struct {
+	TicketID   string    `json:"ticket_id"`
+	CouponName string    `json:"coupon_name"`
+	Username   string    `json:"user_id"`
+	Status     string    `json:"status"`
+	Reason     string    `json:"reason,omitempty"`
+	Attempts   int       `json:"attempts"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type ClaimDeadLetter struct {
+	ID         string    `json:"id"`
+	TicketID   string    `json:"ticket_id"`
+	CouponName string    `json:"coupon_name"`
+	Username   string    `json:"user_id"`
+	Reason     string    `json:"reason"`
+	FailedAt   time.Time `json:"failed_at"`
+}
+
+func NewClaimTicketFromDomain(t *domain.ClaimTicket) *ClaimTicket {
+	if t == nil {
+		return nil
+	}
+
+	return &ClaimTicket{
+		TicketID:   t.TicketID,
+		CouponName: t.CouponName,
+		Username:   t.Username,
+		Status:     t.Status.String(),
+		Reason:     t.Reason,
+		Attempts:   t.Attempts,
+		CreatedAt:  t.CreatedAt,
+		UpdatedAt:  t.UpdatedAt,
+	}
+}
@@ -6,6 +6,8 @@ type Coupon struct {
 	Name            string   `json:"name"`
 	Amount          uint64   `json:"amount"`
 	RemainingAmount uint64   `json:"remaining_amount"`
+	ClaimStrategy   string   `json:"claim_strategy,omitempty"`
+	Shards          int      `json:"shards,omitempty"`
 	ClaimedBy       []string `json:"claimed_by"`
 }
 
@@ -28,6 +30,8 @@ func NewCouponFromDomain(c *domain.Coupon) *Coupon {
 		Name:            c.Name,
 		Amount:          c.Amount,
 		RemainingAmount: c.RemainingAmount,
+		ClaimStrategy:   c.ClaimStrategy.String(),
+		Shards:          c.ShardCount,
 		ClaimedBy:       claimedBy,
 	}
 }
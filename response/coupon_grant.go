@@ -0,0 +1,19 @@
+package response
+
+type CouponGrant struct {
+	CouponName string `json:"coupon_name"`
+	Granted    int    `json:"granted"`
+	// OverQuota is the number of grants beyond the remaining amount, which raised the amount of the coupon.
+	OverQuota int                  `json:"over_quota"`
+	Skipped   int                  `json:"skipped"`
+	Failed    int                  `json:"failed"`
+	Results   []*CouponGrantResult `json:"results"`
+}
+
+type CouponGrantResult struct {
+	Username  string `json:"username,omitempty"`
+	UserID    uint64 `json:"user_id,omitempty"`
+	Status    string `json:"status"`
+	OverQuota bool   `json:"over_quota,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
@@ -0,0 +1,11 @@
+package response
+
+import "time"
+
+type CouponTransfer struct {
+	CouponName    string    `json:"coupon_name"`
+	FromUsername  string    `json:"from_user_id"`
+	ToUsername    string    `json:"to_user_id"`
+	TransferCount int       `json:"transfer_count"`
+	TransferredAt time.Time `json:"transferred_at"`
+}
@@ -0,0 +1,39 @@
+package response
+
+import (
+	"coupon_be/domain"
+	"time"
+)
+
+type StockReconciliation struct {
+	CheckedCoupons int           `json:"checked_coupons"`
+	AutoCorrect    bool          `json:"auto_correct"`
+	Drifts         []*StockDrift `json:"drifts"`
+	ReconciledAt   time.Time     `json:"reconciled_at"`
+}
+
+type StockDrift struct {
+	CouponID                uint64 `json:"coupon_id"`
+	CouponName              string `json:"coupon_name"`
+	Amount                  uint64 `json:"amount"`
+	ClaimedCount            uint64 `json:"claimed_count"`
+	RemainingAmount         uint64 `json:"remaining_amount"`
+	ExpectedRemainingAmount int64  `json:"expected_remaining_amount"`
+	Corrected               bool   `json:"corrected"`
+}
+
+func NewStockDrift(stock *domain.CouponStock, corrected bool) *StockDrift {
+	if stock == nil {
+		return nil
+	}
+
+	return &StockDrift{
+		CouponID:                stock.CouponID,
+		CouponName:              stock.CouponName,
+		Amount:                  stock.Amount,
+		ClaimedCount:            stock.ClaimedCount,
+		RemainingAmount:         stock.RemainingAmount,
+		ExpectedRemainingAmount: stock.ExpectedRemainingAmount(),
+		Corrected:               corrected,
+	}
+}
@@ -0,0 +1,22 @@
+package response
+
+import (
+	"coupon_be/domain/enums"
+	"time"
+)
+
+type User struct {
+	ID        uint64     `json:"id"`
+	Username  string     `json:"username"`
+	FullName  string     `json:"full_name"`
+	Role      enums.Role `json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type Login struct {
+	TokenType             string    `json:"token_type"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
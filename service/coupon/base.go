@@ -2,13 +2,37 @@ package coupon
 
 import (
 	"context"
+	"coupon_be/domain/enums"
 	"coupon_be/repository"
 	"coupon_be/request"
 	"coupon_be/response"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/queue"
+	"coupon_be/shared/external/redis"
+	"coupon_be/util/config"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+const (
+	defaultOptimisticMaxRetries = 5
+	defaultClaimWriterInterval  = 500 * time.Millisecond
+	defaultClaimWriterBatchSize = 100
+	defaultClaimMaxAttempts     = 3
+	defaultClaimRetryDelay      = 200 * time.Millisecond
+	defaultReconcileInterval    = time.Minute
+	defaultMaxTransfers         = 1
+	defaultSoldOutTTL           = time.Minute
+)
+
+const (
+	// LockUnavailableFailClosed fails the claims while the redis lock is unavailable.
+	LockUnavailableFailClosed = "fail_closed"
+	// LockUnavailableFallback claims through the postgres row lock while the redis lock is unavailable.
+	LockUnavailableFallback = "fallback"
+)
+
 type Service interface {
 	Filter(ctx context.Context, input *request.FilterCoupon) (*response.BasePagination[[]*response.CouponList], error)
 
@@ -17,16 +41,148 @@ type Service interface {
 	Store(ctx context.Context, input *request.UpsertCoupon) (*response.Coupon, error)
 
 	Claim(ctx context.Context, input *request.ClaimCoupon) error
+
+	// CheckSoldOut rejects the claims of a coupon marked as sold out.
+	CheckSoldOut(ctx context.Context, couponName string) error
+
+	// ClaimBundle claims every coupon of the bundle for the user, or none of them.
+	ClaimBundle(ctx context.Context, input *request.ClaimBundle) (*response.ClaimBundle, error)
+
+	// Transfer moves a claimed coupon from its holder to another user.
+	Transfer(ctx context.Context, input *request.TransferCoupon) (*response.CouponTransfer, error)
+
+	// Grant grants the coupon to a list of users in batches, and reports the outcome of every user.
+	Grant(ctx context.Context, input *request.GrantCoupon) (*response.CouponGrant, error)
+
+	// RunClaimWriter persists the claims taken by the redis_atomic strategy until ctx is done.
+	RunClaimWriter(ctx context.Context)
+
+	// EnqueueClaim queues the claim to be processed by the claim workers and returns its ticket.
+	EnqueueClaim(ctx context.Context, input *request.ClaimCoupon) (*response.ClaimTicket, error)
+
+	ClaimTicket(ctx context.Context, ticketID string) (*response.ClaimTicket, error)
+
+	ClaimDeadLetters(ctx context.Context, limit int) ([]*response.ClaimDeadLetter, error)
+
+	ReplayClaimDeadLetter(ctx context.Context, id string) (*response.ClaimTicket, error)
+
+	// ReconcileRemainingAmounts reports, and optionally corrects, the coupons whose remaining amount drifted from
+	// their claims.
+	ReconcileRemainingAmounts(ctx context.Context, autoCorrect bool) (*response.StockReconciliation, error)
+
+	// RunStockReconciler reconciles the remaining amounts periodically until ctx is done.
+	RunStockReconciler(ctx context.Context)
+
+	// RunClaimWorkers processes the queued claims, one worker per queue partition, until ctx is done.
+	RunClaimWorkers(ctx context.Context)
 }
 
 type base struct {
 	repository repository.Repository
 	writeDB    *gorm.DB
+	redisLock  redis.ILock
+	stock      redis.IStockCounter
+	claimQueue queue.IQueue
+	soldOut    redis.ISoldOutMarker
+
+	defaultStrategy      enums.ClaimStrategy
+	strategies           map[enums.ClaimStrategy]ClaimStrategy
+	claimWriterInterval  time.Duration
+	claimWriterBatchSize int
+	claimMaxAttempts     int
+	claimRetryDelay      time.Duration
+	reconcileInterval    time.Duration
+	reconcileAutoCorrect bool
+	maxTransfers         int
+	lockFallback         bool
+	soldOutTTL           time.Duration
 }
 
-func NewService(repository repository.Repository, writerDB *gorm.DB) (Service, error) {
-	return &base{
-		repository: repository,
-		writeDB:    writerDB,
-	}, nil
+func NewService(repository repository.Repository, writerDB *gorm.DB, options ...Option) (Service, error) {
+	b := &base{
+		repository:           repository,
+		writeDB:              writerDB,
+		defaultStrategy:      enums.ClaimStrategyRedisLock,
+		claimWriterInterval:  defaultClaimWriterInterval,
+		claimWriterBatchSize: defaultClaimWriterBatchSize,
+		claimMaxAttempts:     defaultClaimMaxAttempts,
+		claimRetryDelay:      defaultClaimRetryDelay,
+		reconcileInterval:    defaultReconcileInterval,
+		maxTransfers:         defaultMaxTransfers,
+		soldOutTTL:           defaultSoldOutTTL,
+	}
+
+	for _, option := range options {
+		if err := option(b); err != nil {
+			return nil, sharedErrs.Wrap(err, "coupon service option")
+		}
+	}
+
+	maxRetries := defaultOptimisticMaxRetries
+	if cfg := config.Env(); cfg != nil {
+		if cfg.Coupon.ClaimStrategy != "" {
+			b.defaultStrategy = enums.ClaimStrategy(cfg.Coupon.ClaimStrategy)
+		}
+		if cfg.Coupon.OptimisticMaxRetries > 0 {
+			maxRetries = cfg.Coupon.OptimisticMaxRetries
+		}
+		if cfg.Coupon.ClaimWriterInterval != "" {
+			interval, err := time.ParseDuration(cfg.Coupon.ClaimWriterInterval)
+			if err != nil {
+				return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplicationPermanent, "Invalid claim writer interval", err)
+			}
+			b.claimWriterInterval = interval
+		}
+		if cfg.Coupon.ClaimWriterBatchSize > 0 {
+			b.claimWriterBatchSize = cfg.Coupon.ClaimWriterBatchSize
+		}
+		if cfg.Coupon.ReconcileInterval != "" {
+			interval, err := time.ParseDuration(cfg.Coupon.ReconcileInterval)
+			if err != nil {
+				return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplicationPermanent, "Invalid reconcile interval", err)
+			}
+			b.reconcileInterval = interval
+		}
+		b.reconcileAutoCorrect = cfg.Coupon.ReconcileAutoCorrect
+		if cfg.Coupon.MaxTransfers > 0 {
+			b.maxTransfers = cfg.Coupon.MaxTransfers
+		}
+		if cfg.Coupon.SoldOutTTL != "" {
+			ttl, err := time.ParseDuration(cfg.Coupon.SoldOutTTL)
+			if err != nil || ttl <= 0 {
+				return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplicationPermanent, "Invalid sold out ttl", err)
+			}
+			b.soldOutTTL = ttl
+		}
+		switch cfg.Coupon.LockUnavailableMode {
+		case "", LockUnavailableFailClosed:
+		case LockUnavailableFallback:
+			b.lockFallback = true
+		default:
+			return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "Unknown lock unavailable mode %s", cfg.Coupon.LockUnavailableMode)
+		}
+		if cfg.Queue.MaxAttempts > 0 {
+			b.claimMaxAttempts = cfg.Queue.MaxAttempts
+		}
+		if cfg.Queue.RetryDelay != "" {
+			delay, err := time.ParseDuration(cfg.Queue.RetryDelay)
+			if err != nil {
+				return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplicationPermanent, "Invalid queue retry delay", err)
+			}
+			b.claimRetryDelay = delay
+		}
+	}
+
+	b.strategies = map[enums.ClaimStrategy]ClaimStrategy{
+		enums.ClaimStrategyRedisLock:   &redisLockStrategy{base: b},
+		enums.ClaimStrategyRowLock:     &rowLockStrategy{base: b},
+		enums.ClaimStrategyOptimistic:  &optimisticStrategy{base: b, maxRetries: maxRetries},
+		enums.ClaimStrategyRedisAtomic: &redisAtomicStrategy{base: b},
+	}
+
+	if _, ok := b.strategies[b.defaultStrategy]; !ok {
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "Unknown claim strategy %s", b.defaultStrategy)
+	}
+
+	return b, nil
 }
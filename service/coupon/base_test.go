@@ -18,12 +18,13 @@ func TestNewService_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	repoMock := m.NewMockRepository(ctrl)
+	redisLockMock := m.NewMockILock(ctrl)
 	writeDB, _, err := m.NewMockDB()
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	couponService, err := NewService(repoMock, writeDB)
+	couponService, err := NewService(repoMock, writeDB, WithRedisLock(redisLockMock))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -34,10 +35,13 @@ func TestNewService_Success(t *testing.T) {
 
 type CouponServiceTestSuite struct {
 	suite.Suite
-	repo    *m.MockRepository
-	writeDB *gorm.DB
-	sqlMock sqlmock.Sqlmock
-	ctx     context.Context
+	repo      *m.MockRepository
+	redisLock *m.MockILock
+	stock     *m.MockIStockCounter
+	queue     *m.MockIQueue
+	writeDB   *gorm.DB
+	sqlMock   sqlmock.Sqlmock
+	ctx       context.Context
 
 	couponService Service
 }
@@ -52,12 +56,19 @@ func (suite *CouponServiceTestSuite) Before(t *testing.T) {
 
 	suite.ctx = context.Background()
 	suite.repo = m.NewMockRepository(ctrl)
+	suite.redisLock = m.NewMockILock(ctrl)
+	suite.stock = m.NewMockIStockCounter(ctrl)
+	suite.queue = m.NewMockIQueue(ctrl)
 	suite.writeDB, suite.sqlMock, err = m.NewMockDB()
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	suite.couponService, err = NewService(suite.repo, suite.writeDB)
+	suite.couponService, err = NewService(suite.repo, suite.writeDB,
+		WithRedisLock(suite.redisLock),
+		WithStockCounter(suite.stock),
+		WithClaimQueue(suite.queue),
+	)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -21,43 +21,63 @@ func (b *base) Claim(ctx context.Context, input *request.ClaimCoupon) error {
 		return err
 	}
 
-	logger.Info(ctx, "resync coupon %s remaining amount ...", coupon.Name)
-	if err = b.resyncCouponRemainingAmount(ctx, coupon); err != nil {
-		return err
+	if coupon.IsSharded() {
+		logger.Info(ctx, "claiming coupon %s from %d shards ...", coupon.Name, coupon.ShardCount)
+
+		return b.claimSharded(ctx, coupon, input)
 	}
 
-	logger.Info(ctx, "coupon %s usable amount: %d remaining", coupon.Name, coupon.RemainingAmount)
-	if valid := coupon.IsUsable(); !valid {
-		logger.Warn(ctx, "coupon %s is not usable", coupon.Name)
-		return sharedErrs.NewBusinessValidationErr("Coupon %s is not usable because no stock remaining", coupon.Name)
+	strategy := b.claimStrategy(coupon)
+	logger.Info(ctx, "claiming coupon %s using %s strategy ...", coupon.Name, strategy.Name())
+
+	return strategy.Claim(ctx, coupon, input)
+}
+
+// claimStrategy returns the strategy configured on the coupon, or the global default if the coupon has none.
+func (b *base) claimStrategy(coupon *domain.Coupon) ClaimStrategy {
+	if strategy, ok := b.strategies[coupon.ClaimStrategy]; ok {
+		return strategy
 	}
 
-	user, err := b.repository.FindUserByUsername(ctx, input.Username)
+	return b.strategies[b.defaultStrategy]
+}
+
+// findClaimant returns the user with the given username, as long as the user has not claimed the coupon yet.
+func (b *base) findClaimant(ctx context.Context, coupon *domain.Coupon, username string) (*domain.User, error) {
+	user, err := b.repository.FindUserByUsername(ctx, username)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	logger.Debug(ctx, "checking whether coupon %s is already claimed by user id %d ...", input.CouponName, user.ID)
+	logger.Debug(ctx, "checking whether coupon %s is already claimed by user id %d ...", coupon.Name, user.ID)
 	userClaimExists, err := b.repository.FindUserClaimByUserIDAndCouponID(ctx, user.ID, coupon.ID)
 	if err != nil && !errors.Is(err, sharedErrs.NotFoundErr) {
-		return err
+		return nil, err
 	}
 	if userClaimExists != nil {
 		logger.Warn(ctx, "coupon %s is already claimed by user id %d with user claim id %d", coupon.Name, user.ID, userClaimExists.ID)
 
-		return sharedErrs.New(sharedErrs.ErrKindConflict, "Coupon %s is already claimed by user %s",
-			coupon.Name, user.Username)
+		return nil, alreadyClaimedErr(coupon, user)
 	}
 
+	return user, nil
+}
+
+// claimInTx consumes one stock of the coupon through decrement and creates the user claim in a single transaction.
+func (b *base) claimInTx(ctx context.Context, coupon *domain.Coupon, user *domain.User, decrement func(tCtx context.Context) error) (err error) {
 	logger.Info(ctx, "claiming coupon %s for user id %d ...", coupon.Name, user.ID)
 
 	tCtx, tx := database.InitTx(ctx, b.writeDB)
 	defer func() {
-		if err = tx.Rollback().Error; err != nil && !errors.Is(err, sql.ErrTxDone) {
+		if err := tx.Rollback().Error; err != nil && !errors.Is(err, sql.ErrTxDone) {
 			logger.Error(ctx, "Repository Error on executing b.Claim: ROLLBACK TXN: %v", err)
 		}
 	}()
 
+	if err = decrement(tCtx); err != nil {
+		return err
+	}
+
 	now := time.Now()
 	_, err = b.repository.CreateUserClaim(tCtx, &domain.UserClaim{
 		BaseModel: domain.BaseModel{
@@ -67,41 +87,27 @@ func (b *base) Claim(ctx context.Context, input *request.ClaimCoupon) error {
 		UserID:   user.ID,
 		CouponID: coupon.ID,
 	})
-	if err != nil {
-		return err
+	if errors.Is(err, sharedErrs.ConflictErr) {
+		return alreadyClaimedErr(coupon, user)
 	}
-
-	if err = b.repository.DecrementCouponRemainingAmount(tCtx, coupon.ID); err != nil {
+	if err != nil {
 		return err
 	}
 
-	if err = tx.Commit().Error; err != nil {
+	if err = database.Commit(tCtx, tx); err != nil {
 		logger.Error(ctx, "Repository Error on executing b.Claim: COMMIT TXN: %v", err)
+
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindDatabase, "Failed to claim coupon", err)
 	}
 
 	return nil
 }
 
-func (b *base) resyncCouponRemainingAmount(ctx context.Context, coupon *domain.Coupon) error {
-	claimCount, err := b.repository.FindUserClaimCountByCouponID(ctx, coupon.ID)
-	if err != nil {
-		return err
-	}
-
-	actualRemainingAmount := coupon.Amount - uint64(claimCount)
-
-	logger.Info(ctx, "coupon %d amount: %d | remaining amount: %d | claim count: %d | actual remaining: %d",
-		coupon.ID, claimCount, coupon.Amount, coupon.RemainingAmount, actualRemainingAmount)
-	if actualRemainingAmount != coupon.RemainingAmount {
-		logger.Info(ctx, "updating coupon %d remaining amount to %d", coupon.ID, actualRemainingAmount)
-
-		coupon.RemainingAmount = actualRemainingAmount
-		if _, err = b.repository.UpdateCoupon(ctx, coupon); err != nil {
-			return err
-		}
-	} else {
-		logger.Debug(ctx, "coupon %d remaining amount is correct", coupon.ID)
-	}
+func notUsableErr(coupon *domain.Coupon) error {
+	return sharedErrs.NewBusinessValidationErr("Coupon %s is not usable because no stock remaining", coupon.Name)
+}
 
-	return nil
+func alreadyClaimedErr(coupon *domain.Coupon, user *domain.User) error {
+	return sharedErrs.New(sharedErrs.ErrKindConflict, "Coupon %s is already claimed by user %s",
+		coupon.Name, user.Username)
 }
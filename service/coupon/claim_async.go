@@ -0,0 +1,352 @@
+package coupon
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	"coupon_be/request"
+	"coupon_be/response"
+	"coupon_be/shared/auth"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/queue"
+	"coupon_be/util"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const ClaimQueueName = "claim:queue"
+
+// claimMessage is the payload of a queued claim.
+type claimMessage struct {
+	TicketID      string `json:"ticket_id"`
+	CouponName    string `json:"coupon_name"`
+	Username      string `json:"username"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// LegacyUsername is the username of the messages queued, or dead-lettered, before it was encoded as username.
+	LegacyUsername string `json:"user_id,omitempty"`
+}
+
+// decodeClaimMessage decodes the payload of a queued claim.
+func decodeClaimMessage(payload []byte) (*claimMessage, error) {
+	var claim claimMessage
+	if err := json.Unmarshal(payload, &claim); err != nil {
+		return nil, err
+	}
+
+	if claim.Username == "" {
+		claim.Username = claim.LegacyUsername
+	}
+
+	return &claim, nil
+}
+
+func (b *base) EnqueueClaim(ctx context.Context, input *request.ClaimCoupon) (*response.ClaimTicket, error) {
+	logger.Info(ctx, "Enqueue Claim Coupon with req: %v", input)
+
+	if b.claimQueue == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "Asynchronous claim is not enabled")
+	}
+
+	now := time.Now()
+	ticket, err := b.repository.CreateClaimTicket(ctx, &domain.ClaimTicket{
+		BaseModel: domain.BaseModel{
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		TicketID:   uuid.New().String(),
+		CouponName: util.SanitizeString(input.CouponName),
+		Username:   input.Username,
+		Status:     enums.ClaimTicketStatusPending,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(&claimMessage{
+		TicketID:      ticket.TicketID,
+		CouponName:    ticket.CouponName,
+		Username:      ticket.Username,
+		CorrelationID: constant.CorrelationIDFromCtx(ctx),
+	})
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplication, "Failed to encode claim message", err)
+	}
+
+	// the coupon name is the message key, so claims of the same coupon are processed sequentially
+	if _, err = b.claimQueue.Publish(ctx, ticket.CouponName, payload); err != nil {
+		logger.Error(ctx, "failed to enqueue claim ticket %s: %v", ticket.TicketID, err)
+
+		b.finishClaimTicket(ctx, ticket, enums.ClaimTicketStatusFailed, "Failed to enqueue the claim")
+
+		return nil, err
+	}
+
+	logger.Info(ctx, "claim of coupon %s by user %s is enqueued with ticket %s", ticket.CouponName, ticket.Username, ticket.TicketID)
+
+	return response.NewClaimTicketFromDomain(ticket), nil
+}
+
+func (b *base) ClaimTicket(ctx context.Context, ticketID string) (*response.ClaimTicket, error) {
+	logger.Info(ctx, "Get Claim Ticket with id: %s", ticketID)
+
+	if err := uuid.Validate(ticketID); err != nil {
+		return nil, sharedErrs.NotFoundErr
+	}
+
+	ticket, err := b.repository.FindClaimTicketByTicketID(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the tickets of other users are not found, unless the user may act as them, i.e. an admin
+	if ticket.Username != constant.UsernameFromCtx(ctx) && !auth.Can(ctx, auth.PermissionActAsUser) {
+		logger.Warn(ctx, "user %s is denied claim ticket %s of user %s",
+			constant.UsernameFromCtx(ctx), ticketID, ticket.Username)
+		return nil, sharedErrs.NotFoundErr
+	}
+
+	return response.NewClaimTicketFromDomain(ticket), nil
+}
+
+func (b *base) ClaimDeadLetters(ctx context.Context, limit int) ([]*response.ClaimDeadLetter, error) {
+	logger.Info(ctx, "Get Claim Dead Letters with limit: %d", limit)
+
+	if b.claimQueue == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "Asynchronous claim is not enabled")
+	}
+
+	deadLetters, err := b.claimQueue.DeadLetters(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*response.ClaimDeadLetter, 0, len(deadLetters))
+	for _, deadLetter := range deadLetters {
+		claim, err := decodeClaimMessage(deadLetter.Message.Payload)
+		if err != nil {
+			logger.Warn(ctx, "dead letter %s has an invalid payload: %v", deadLetter.ID, err)
+			claim = &claimMessage{}
+		}
+
+		result = append(result, &response.ClaimDeadLetter{
+			ID:         deadLetter.ID,
+			TicketID:   claim.TicketID,
+			CouponName: claim.CouponName,
+			Username:   claim.Username,
+			Reason:     deadLetter.Reason,
+			FailedAt:   deadLetter.FailedAt,
+		})
+	}
+
+	return result, nil
+}
+
+func (b *base) ReplayClaimDeadLetter(ctx context.Context, id string) (*response.ClaimTicket, error) {
+	logger.Info(ctx, "Replay Claim Dead Letter with id: %s", id)
+
+	if b.claimQueue == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "Asynchronous claim is not enabled")
+	}
+
+	deadLetter, err := b.claimQueue.FindDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	claim, err := decodeClaimMessage(deadLetter.Message.Payload)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplicationPermanent, "Invalid claim message", err)
+	}
+
+	ticket, err := b.repository.FindClaimTicketByTicketID(ctx, claim.TicketID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the ticket is pending before the message is republished, since the workers skip the messages of the tickets
+	// which are not pending
+	failed := *ticket
+	ticket.Status = enums.ClaimTicketStatusPending
+	ticket.Reason = ""
+	ticket.Attempts = 0
+	ticket.UpdatedAt = time.Now()
+	if ticket, err = b.repository.UpdateClaimTicket(ctx, ticket); err != nil {
+		return nil, err
+	}
+
+	msg, err := b.claimQueue.Replay(ctx, id)
+	if err != nil {
+		// the dead letter is kept, so the ticket is failed again until it is replayed
+		failed.UpdatedAt = time.Now()
+		if _, updateErr := b.repository.UpdateClaimTicket(ctx, &failed); updateErr != nil {
+			logger.Error(ctx, "failed to restore ticket %s after its replay failed: %v", failed.TicketID, updateErr)
+		}
+
+		return nil, err
+	}
+
+	logger.Info(ctx, "dead letter %s is replayed as message %s for ticket %s", id, msg.ID, ticket.TicketID)
+
+	return response.NewClaimTicketFromDomain(ticket), nil
+}
+
+func (b *base) RunClaimWorkers(ctx context.Context) {
+	if b.claimQueue == nil {
+		logger.Info(ctx, "claim workers are disabled, claim queue is not initialised")
+		return
+	}
+
+	var wg sync.WaitGroup
+	for partition := 0; partition < b.claimQueue.Partitions(); partition++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.runClaimWorker(ctx, partition)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// runClaimWorker processes the messages of a single partition one by one until ctx is done.
+func (b *base) runClaimWorker(ctx context.Context, partition int) {
+	logger.Info(ctx, "claim worker of partition %d is started", partition)
+
+	for {
+		msg, err := b.claimQueue.Receive(ctx, partition)
+		if ctx.Err() != nil {
+			logger.Info(ctx, "claim worker of partition %d is stopped", partition)
+			return
+		}
+		if err != nil {
+			logger.Error(ctx, "claim worker of partition %d failed to receive message: %v", partition, err)
+
+			sleep(ctx, b.claimRetryDelay)
+			continue
+		}
+
+		b.processClaimMessage(ctx, msg)
+	}
+}
+
+// processClaimMessage claims the coupon of the message, retrying on transient errors. The message is moved to the
+// dead-letter queue once its attempts are exhausted.
+func (b *base) processClaimMessage(ctx context.Context, msg *queue.Message) {
+	claim, err := decodeClaimMessage(msg.Payload)
+	if err != nil {
+		logger.Error(ctx, "claim message %s has an invalid payload: %v", msg.ID, err)
+		b.deadLetterClaimMessage(ctx, msg, "Invalid claim message")
+		return
+	}
+
+	if claim.CorrelationID != "" {
+		ctx = context.WithValue(ctx, constant.XCorrelationIDKey, claim.CorrelationID)
+	}
+
+	ticket, err := b.repository.FindClaimTicketByTicketID(ctx, claim.TicketID)
+	if err != nil {
+		logger.Error(ctx, "failed to find claim ticket %s: %v", claim.TicketID, err)
+		b.deadLetterClaimMessage(ctx, msg, errorReason(err))
+		return
+	}
+
+	// a message delivered again after it has been processed, e.g. on a crash before the ack
+	if ticket.Status != enums.ClaimTicketStatusPending {
+		logger.Info(ctx, "claim ticket %s is already %s, skipping", ticket.TicketID, ticket.Status)
+		b.ackClaimMessage(ctx, msg)
+		return
+	}
+
+	input := &request.ClaimCoupon{Username: claim.Username, CouponName: claim.CouponName}
+
+	var claimErr error
+	for attempt := 1; attempt <= b.claimMaxAttempts; attempt++ {
+		ticket.Attempts = attempt
+
+		if claimErr = b.Claim(ctx, input); claimErr == nil || !isRetryable(claimErr) {
+			break
+		}
+
+		logger.Warn(ctx, "attempt %d of claim ticket %s failed: %v", attempt, ticket.TicketID, claimErr)
+		if attempt < b.claimMaxAttempts {
+			sleep(ctx, b.claimRetryDelay*time.Duration(attempt))
+		}
+	}
+
+	switch {
+	case claimErr == nil:
+		b.finishClaimTicket(ctx, ticket, enums.ClaimTicketStatusSucceeded, "")
+		b.ackClaimMessage(ctx, msg)
+	case isRetryable(claimErr):
+		b.finishClaimTicket(ctx, ticket, enums.ClaimTicketStatusFailed, errorReason(claimErr))
+		b.deadLetterClaimMessage(ctx, msg, errorReason(claimErr))
+	default:
+		b.finishClaimTicket(ctx, ticket, enums.ClaimTicketStatusFailed, errorReason(claimErr))
+		b.ackClaimMessage(ctx, msg)
+	}
+}
+
+func (b *base) finishClaimTicket(ctx context.Context, ticket *domain.ClaimTicket, status enums.ClaimTicketStatus, reason string) {
+	ticket.Status = status
+	ticket.Reason = reason
+	ticket.UpdatedAt = time.Now()
+
+	if _, err := b.repository.UpdateClaimTicket(ctx, ticket); err != nil {
+		logger.Error(ctx, "failed to update claim ticket %s to %s: %v", ticket.TicketID, status, err)
+	}
+}
+
+func (b *base) ackClaimMessage(ctx context.Context, msg *queue.Message) {
+	if err := b.claimQueue.Ack(ctx, msg); err != nil {
+		logger.Error(ctx, "failed to ack claim message %s: %v", msg.ID, err)
+	}
+}
+
+func (b *base) deadLetterClaimMessage(ctx context.Context, msg *queue.Message, reason string) {
+	logger.Warn(ctx, "moving claim message %s to the dead-letter queue: %s", msg.ID, reason)
+
+	if err := b.claimQueue.DeadLetter(ctx, msg, reason); err != nil {
+		logger.Error(ctx, "failed to dead letter claim message %s: %v", msg.ID, err)
+	}
+}
+
+// isRetryable reports whether the claim might succeed by retrying it later.
+func isRetryable(err error) bool {
+	var baseErr sharedErrs.BaseError
+	if !errors.As(err, &baseErr) {
+		return true
+	}
+
+	switch baseErr.Kind() {
+	case sharedErrs.ErrKindBusinessValidation, sharedErrs.ErrKindConflict, sharedErrs.ErrKindDataNotFound,
+		sharedErrs.ErrKindValidation, sharedErrs.ErrKindApplicationPermanent:
+		return false
+	default:
+		return true
+	}
+}
+
+func errorReason(err error) string {
+	var baseErr sharedErrs.BaseError
+	if errors.As(err, &baseErr) && baseErr.Message() != "" {
+		return baseErr.Message()
+	}
+
+	return err.Error()
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,388 @@
+package coupon
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	m "coupon_be/mock"
+	"coupon_be/request"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/queue"
+	"coupon_be/util/constant"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func initClaimTicket() *domain.ClaimTicket {
+	return &domain.ClaimTicket{
+		BaseModel:  domain.BaseModel{ID: 1},
+		TicketID:   "1f0f8a2e-6c1a-4b7e-9d43-0a1c5b4e2f11",
+		CouponName: "COUPON_TEST",
+		Username:   "user_123",
+		Status:     enums.ClaimTicketStatusPending,
+	}
+}
+
+func (suite *CouponServiceTestSuite) Test_EnqueueClaim() {
+	input := &request.ClaimCoupon{
+		CouponName: "COUPON_TEST",
+		Username:   "user_123",
+	}
+
+	testCases := []struct {
+		name           string
+		prepareMock    func()
+		expectedStatus string
+		wantErr        bool
+	}{
+		{
+			name: "success",
+			prepareMock: func() {
+				suite.repo.EXPECT().CreateClaimTicket(suite.ctx, gomock.Any()).
+					Return(initClaimTicket(), nil).
+					Times(1)
+				suite.queue.EXPECT().Publish(suite.ctx, gomock.Eq(input.CouponName), gomock.Any()).
+					Return("1", nil).
+					Times(1)
+			},
+			expectedStatus: enums.ClaimTicketStatusPending.String(),
+		},
+		{
+			name: "publish failed",
+			prepareMock: func() {
+				suite.repo.EXPECT().CreateClaimTicket(suite.ctx, gomock.Any()).
+					Return(initClaimTicket(), nil).
+					Times(1)
+				suite.queue.EXPECT().Publish(suite.ctx, gomock.Eq(input.CouponName), gomock.Any()).
+					Return("", sharedErrs.New(sharedErrs.ErrKindRedis, "Failed to publish message")).
+					Times(1)
+				suite.repo.EXPECT().UpdateClaimTicket(suite.ctx, gomock.Any()).
+					DoAndReturn(func(_ any, t *domain.ClaimTicket) (*domain.ClaimTicket, error) {
+						assert.Equal(suite.T(), enums.ClaimTicketStatusFailed, t.Status)
+						return t, nil
+					}).
+					Times(1)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			suite.Before(t)
+			tc.prepareMock()
+
+			result, err := suite.couponService.EnqueueClaim(suite.ctx, input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedStatus, result.Status)
+			}
+		})
+	}
+}
+
+func (suite *CouponServiceTestSuite) Test_ProcessClaimMessage() {
+	user := m.InitUserDomain()
+	coupon := m.InitCouponDomain()
+
+	ticket := initClaimTicket()
+	payload, _ := json.Marshal(&claimMessage{
+		TicketID:   ticket.TicketID,
+		CouponName: ticket.CouponName,
+		Username:   ticket.Username,
+	})
+	msg := &queue.Message{ID: "1", Key: ticket.CouponName, Payload: payload}
+
+	expectTicketStatus := func(status enums.ClaimTicketStatus) {
+		suite.repo.EXPECT().UpdateClaimTicket(suite.ctx, gomock.Any()).
+			DoAndReturn(func(_ any, t *domain.ClaimTicket) (*domain.ClaimTicket, error) {
+				assert.Equal(suite.T(), status, t.Status)
+				return t, nil
+			}).
+			Times(1)
+	}
+
+	testCases := []struct {
+		name        string
+		prepareMock func()
+	}{
+		{
+			name: "succeeded",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindClaimTicketByTicketID(suite.ctx, gomock.Eq(ticket.TicketID)).
+					Return(initClaimTicket(), nil).
+					Times(1)
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(ticket.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(2)
+				suite.expectWithLock("claim:coupon:COUPON_TEST")
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(ticket.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(coupon.ID), gomock.Eq(user.ID)).
+					Return(nil, nil).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Any()).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(nil, nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+				expectTicketStatus(enums.ClaimTicketStatusSucceeded)
+				suite.queue.EXPECT().Ack(suite.ctx, gomock.Eq(msg)).Return(nil).Times(1)
+			},
+		},
+		{
+			name: "failed without retry",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindClaimTicketByTicketID(suite.ctx, gomock.Eq(ticket.TicketID)).
+					Return(initClaimTicket(), nil).
+					Times(1)
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(ticket.CouponName), gomock.Eq(false)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				expectTicketStatus(enums.ClaimTicketStatusFailed)
+				suite.queue.EXPECT().Ack(suite.ctx, gomock.Eq(msg)).Return(nil).Times(1)
+				suite.queue.EXPECT().DeadLetter(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "retries exhausted",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindClaimTicketByTicketID(suite.ctx, gomock.Eq(ticket.TicketID)).
+					Return(initClaimTicket(), nil).
+					Times(1)
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(ticket.CouponName), gomock.Eq(false)).
+					Return(nil, sharedErrs.New(sharedErrs.ErrKindDatabase, "Connection refused")).
+					Times(defaultClaimMaxAttempts)
+				expectTicketStatus(enums.ClaimTicketStatusFailed)
+				suite.queue.EXPECT().DeadLetter(suite.ctx, gomock.Eq(msg), gomock.Eq("Connection refused")).
+					Return(nil).
+					Times(1)
+				suite.queue.EXPECT().Ack(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "already processed",
+			prepareMock: func() {
+				processed := initClaimTicket()
+				processed.Status = enums.ClaimTicketStatusSucceeded
+
+				suite.repo.EXPECT().FindClaimTicketByTicketID(suite.ctx, gomock.Eq(ticket.TicketID)).
+					Return(processed, nil).
+					Times(1)
+				suite.repo.EXPECT().FindCouponByName(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				suite.queue.EXPECT().Ack(suite.ctx, gomock.Eq(msg)).Return(nil).Times(1)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			suite.Before(t)
+			suite.couponService.(*base).claimRetryDelay = 0
+			tc.prepareMock()
+
+			suite.couponService.(*base).processClaimMessage(suite.ctx, msg)
+		})
+	}
+}
+
+func (suite *CouponServiceTestSuite) Test_ReplayClaimDeadLetter() {
+	ticket := initClaimTicket()
+	payload, _ := json.Marshal(&claimMessage{
+		TicketID:   ticket.TicketID,
+		CouponName: ticket.CouponName,
+		Username:   ticket.Username,
+	})
+	deadLetter := &queue.DeadLetter{
+		ID:      "1-0",
+		Message: &queue.Message{ID: "1", Key: ticket.CouponName, Payload: payload},
+		Reason:  "Connection refused",
+	}
+
+	failedTicket := func() *domain.ClaimTicket {
+		failed := initClaimTicket()
+		failed.Status = enums.ClaimTicketStatusFailed
+		failed.Reason = deadLetter.Reason
+		return failed
+	}
+
+	expectTicketStatus := func(status enums.ClaimTicketStatus) *gomock.Call {
+		return suite.repo.EXPECT().UpdateClaimTicket(suite.ctx, gomock.Any()).
+			DoAndReturn(func(_ any, t *domain.ClaimTicket) (*domain.ClaimTicket, error) {
+				assert.Equal(suite.T(), status, t.Status)
+				return t, nil
+			}).
+			Times(1)
+	}
+
+	testCases := []struct {
+		name          string
+		prepareMock   func()
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name: "ticket is pending before the message is replayed",
+			prepareMock: func() {
+				suite.queue.EXPECT().FindDeadLetter(suite.ctx, gomock.Eq(deadLetter.ID)).
+					Return(deadLetter, nil).
+					Times(1)
+				suite.repo.EXPECT().FindClaimTicketByTicketID(suite.ctx, gomock.Eq(ticket.TicketID)).
+					Return(failedTicket(), nil).
+					Times(1)
+				gomock.InOrder(
+					expectTicketStatus(enums.ClaimTicketStatusPending),
+					suite.queue.EXPECT().Replay(suite.ctx, gomock.Eq(deadLetter.ID)).
+						Return(&queue.Message{ID: "2", Key: ticket.CouponName, Payload: payload}, nil).
+						Times(1),
+				)
+			},
+		},
+		{
+			name: "ticket is failed again when the replay fails",
+			prepareMock: func() {
+				suite.queue.EXPECT().FindDeadLetter(suite.ctx, gomock.Eq(deadLetter.ID)).
+					Return(deadLetter, nil).
+					Times(1)
+				suite.repo.EXPECT().FindClaimTicketByTicketID(suite.ctx, gomock.Eq(ticket.TicketID)).
+					Return(failedTicket(), nil).
+					Times(1)
+				gomock.InOrder(
+					expectTicketStatus(enums.ClaimTicketStatusPending),
+					suite.queue.EXPECT().Replay(suite.ctx, gomock.Eq(deadLetter.ID)).
+						Return(nil, sharedErrs.InternalServerErr).
+						Times(1),
+					expectTicketStatus(enums.ClaimTicketStatusFailed),
+				)
+			},
+			wantErr:       true,
+			expectedError: sharedErrs.InternalServerErr,
+		},
+		{
+			name: "dead letter not found",
+			prepareMock: func() {
+				suite.queue.EXPECT().FindDeadLetter(suite.ctx, gomock.Eq(deadLetter.ID)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.queue.EXPECT().Replay(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr:       true,
+			expectedError: sharedErrs.NotFoundErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			suite.Before(t)
+			tc.prepareMock()
+
+			result, err := suite.couponService.ReplayClaimDeadLetter(suite.ctx, deadLetter.ID)
+
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.Nil(t, result)
+				assert.Equal(t, tc.expectedError, err)
+			} else {
+				assert.Equal(t, enums.ClaimTicketStatusPending.String(), result.Status)
+			}
+		})
+	}
+}
+
+func (suite *CouponServiceTestSuite) Test_ClaimTicket() {
+	ticket := initClaimTicket()
+
+	asUser := func(username string, role enums.Role) context.Context {
+		ctx := context.WithValue(suite.ctx, constant.XUsernameKey, username)
+		return context.WithValue(ctx, constant.XRoleKey, role.String())
+	}
+
+	testCases := []struct {
+		name          string
+		ctx           func() context.Context
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name: "ticket of the user",
+			ctx:  func() context.Context { return asUser(ticket.Username, enums.RoleCustomer) },
+		},
+		{
+			name:          "ticket of another user",
+			ctx:           func() context.Context { return asUser("user_456", enums.RoleCustomer) },
+			wantErr:       true,
+			expectedError: sharedErrs.NotFoundErr,
+		},
+		{
+			name: "admin reads the ticket of another user",
+			ctx:  func() context.Context { return asUser("admin", enums.RoleAdmin) },
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			suite.Before(t)
+			ctx := tc.ctx()
+
+			suite.repo.EXPECT().FindClaimTicketByTicketID(ctx, gomock.Eq(ticket.TicketID)).
+				Return(initClaimTicket(), nil).
+				Times(1)
+
+			result, err := suite.couponService.ClaimTicket(ctx, ticket.TicketID)
+
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.Nil(t, result)
+				assert.Equal(t, tc.expectedError, err)
+			} else {
+				assert.Equal(t, ticket.TicketID, result.TicketID)
+			}
+		})
+	}
+}
+
+func Test_decodeClaimMessage(t *testing.T) {
+	testCases := []struct {
+		name             string
+		payload          string
+		expectedUsername string
+		wantErr          bool
+	}{
+		{
+			name:             "username",
+			payload:          `{"ticket_id":"t1","coupon_name":"COUPON_TEST","username":"user_123"}`,
+			expectedUsername: "user_123",
+		},
+		{
+			name:             "username of a message queued before it was encoded as username",
+			payload:          `{"ticket_id":"t1","coupon_name":"COUPON_TEST","user_id":"user_123"}`,
+			expectedUsername: "user_123",
+		},
+		{
+			name:    "invalid payload",
+			payload: `not json`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			claim, err := decodeClaimMessage([]byte(tc.payload))
+
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if !tc.wantErr {
+				assert.Equal(t, tc.expectedUsername, claim.Username)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package coupon
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	"coupon_be/request"
+	"coupon_be/response"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/database"
+	"coupon_be/util"
+	"coupon_be/util/logger"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// bundleBlockedError is returned when a coupon of the bundle can not be claimed.
+type bundleBlockedError struct {
+	couponName string
+	cause      error
+}
+
+func (e *bundleBlockedError) Error() string {
+	return fmt.Sprintf("bundle is blocked by coupon %s: %v", e.couponName, e.cause)
+}
+
+func (e *bundleBlockedError) Unwrap() error {
+	return e.cause
+}
+
+// ClaimBundle claims every coupon of the bundle for the user, or none of them. The claim locks of the coupons are
+// acquired in the sorted order of their names, so concurrent bundles never wait for each other in a cycle.
+func (b *base) ClaimBundle(ctx context.Context, input *request.ClaimBundle) (*response.ClaimBundle, error) {
+	logger.Info(ctx, "Claim Coupon Bundle with req: %v", input)
+
+	names := make([]string, 0, len(input.CouponNames))
+	for _, name := range input.CouponNames {
+		names = append(names, util.SanitizeString(name))
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	result := &response.ClaimBundle{Username: input.Username, CouponNames: names}
+
+	coupons := make([]*domain.Coupon, len(names))
+	for i, name := range names {
+		coupon, err := b.repository.FindCouponByName(ctx, name, false)
+		if errors.Is(err, sharedErrs.NotFoundErr) {
+			return blockBundle(ctx, result, &bundleBlockedError{couponName: name, cause: err})
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if coupon.IsSharded() || b.claimStrategy(coupon).Name() == enums.ClaimStrategyRedisAtomic {
+			return blockBundle(ctx, result, &bundleBlockedError{
+				couponName: name,
+				cause:      sharedErrs.NewBusinessValidationErr("Coupon %s can not be claimed in a bundle", name),
+			})
+		}
+
+		// fail fast on a sold-out coupon, the conditional decrement is the authoritative check
+		if !coupon.IsUsable() {
+			return blockBundle(ctx, result, &bundleBlockedError{couponName: name, cause: notUsableErr(coupon)})
+		}
+
+		coupons[i] = coupon
+	}
+
+	user, err := b.repository.FindUserByUsername(ctx, input.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	fences := make([]int64, len(coupons))
+	err = b.withBundleLocks(ctx, coupons, fences, 0, func(ctx context.Context) error {
+		return b.claimBundleInTx(ctx, coupons, fences, user)
+	})
+
+	var blocked *bundleBlockedError
+	if errors.As(err, &blocked) {
+		return blockBundle(ctx, result, blocked)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(ctx, "coupon bundle %v is claimed by user %s", names, user.Username)
+	result.Claimed = true
+
+	return result, nil
+}
+
+// withBundleLocks acquires the claim locks of coupons[i:] in order, then runs fn while holding all of them. A lock of
+// coupons[i] which can not be acquired, or whose lease is lost, blocks the bundle by coupons[i].
+func (b *base) withBundleLocks(ctx context.Context, coupons []*domain.Coupon, fences []int64, i int, fn func(ctx context.Context) error) error {
+	if i == len(coupons) {
+		return fn(ctx)
+	}
+
+	var innerErr error
+	err := b.withClaimLock(ctx, fmt.Sprintf(couponClaimLockKey, coupons[i].Name), func(ctx context.Context, fence int64) error {
+		fences[i] = fence
+		innerErr = b.withBundleLocks(ctx, coupons, fences, i+1, fn)
+		return innerErr
+	})
+	if err != nil && err != innerErr {
+		return &bundleBlockedError{couponName: coupons[i].Name, cause: err}
+	}
+
+	return err
+}
+
+// claimBundleInTx consumes one stock of every coupon and creates every user claim in a single transaction.
+func (b *base) claimBundleInTx(ctx context.Context, coupons []*domain.Coupon, fences []int64, user *domain.User) (err error) {
+	tCtx, tx := database.InitTx(ctx, b.writeDB)
+	defer func() {
+		if err := tx.Rollback().Error; err != nil && !errors.Is(err, sql.ErrTxDone) {
+			logger.Error(ctx, "Repository Error on executing b.ClaimBundle: ROLLBACK TXN: %v", err)
+		}
+	}()
+
+	now := time.Now()
+	for i, coupon := range coupons {
+		if fences[i] == 0 {
+			// claimed without the claim lock, the row of the coupon is locked in the sorted order of the names instead
+			if _, err = b.repository.FindCouponByIDForUpdate(tCtx, coupon.ID); err != nil {
+				return err
+			}
+		}
+
+		err = b.repository.DecrementCouponRemainingAmount(tCtx, coupon.ID, fences[i])
+		if errors.Is(err, sharedErrs.NotFoundErr) {
+			return &bundleBlockedError{couponName: coupon.Name, cause: notUsableErr(coupon)}
+		}
+		if errors.Is(err, sharedErrs.StaleFenceErr) {
+			return &bundleBlockedError{couponName: coupon.Name, cause: err}
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = b.repository.CreateUserClaim(tCtx, &domain.UserClaim{
+			BaseModel: domain.BaseModel{
+				CreatedAt: now,
+				UpdatedAt: now,
+			},
+			UserID:   user.ID,
+			CouponID: coupon.ID,
+		})
+		if errors.Is(err, sharedErrs.ConflictErr) {
+			return &bundleBlockedError{couponName: coupon.Name, cause: alreadyClaimedErr(coupon, user)}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = database.Commit(tCtx, tx); err != nil {
+		logger.Error(ctx, "Repository Error on executing b.ClaimBundle: COMMIT TXN: %v", err)
+
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindDatabase, "Failed to claim coupon bundle", err)
+	}
+
+	return nil
+}
+
+func blockBundle(ctx context.Context, result *response.ClaimBundle, blocked *bundleBlockedError) (*response.ClaimBundle, error) {
+	logger.Warn(ctx, "coupon bundle %v of user %s is blocked by coupon %s: %v",
+		result.CouponNames, result.Username, blocked.couponName, blocked.cause)
+
+	result.BlockedBy = blocked.couponName
+	result.Reason = errorReason(blocked.cause)
+
+	return result, nil
+}
@@ -0,0 +1,175 @@
+package coupon
+
+import (
+	"context"
+	"coupon_be/domain"
+	m "coupon_be/mock"
+	"coupon_be/request"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func (suite *CouponServiceTestSuite) Test_ClaimBundle() {
+	user := m.InitUserDomain()
+	input := &request.ClaimBundle{
+		Username:    "user_123",
+		CouponNames: []string{"COUPON_B", "COUPON_A", "COUPON_B"},
+	}
+
+	couponA := m.InitCouponDomain()
+	couponA.ID, couponA.Name = 1, "COUPON_A"
+	couponB := m.InitCouponDomain()
+	couponB.ID, couponB.Name = 2, "COUPON_B"
+
+	expectCoupons := func(coupons ...*domain.Coupon) {
+		for _, coupon := range coupons {
+			suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(coupon.Name), gomock.Eq(false)).
+				Return(coupon, nil).
+				Times(1)
+		}
+	}
+
+	expectLocks := func() {
+		gomock.InOrder(
+			suite.redisLock.EXPECT().WithLock(suite.ctx, gomock.Eq("claim:coupon:COUPON_A"), gomock.Any()).
+				DoAndReturn(withLockFence(1)),
+			suite.redisLock.EXPECT().WithLock(suite.ctx, gomock.Eq("claim:coupon:COUPON_B"), gomock.Any()).
+				DoAndReturn(withLockFence(2)),
+		)
+	}
+
+	testCases := []struct {
+		name              string
+		prepareMock       func()
+		expectedClaimed   bool
+		expectedBlockedBy string
+	}{
+		{
+			name: "success",
+			prepareMock: func() {
+				expectCoupons(couponA, couponB)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).Return(user, nil).Times(1)
+				expectLocks()
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(couponA.ID), gomock.Eq(int64(1))).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(couponB.ID), gomock.Eq(int64(2))).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(m.InitUserClaimDomain(), nil).
+					Times(2)
+				suite.sqlMock.ExpectCommit()
+			},
+			expectedClaimed: true,
+		},
+		{
+			name: "blocked by a coupon sold out while claiming",
+			prepareMock: func() {
+				expectCoupons(couponA, couponB)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).Return(user, nil).Times(1)
+				expectLocks()
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(couponA.ID), gomock.Any()).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(m.InitUserClaimDomain(), nil).
+					Times(1)
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(couponB.ID), gomock.Any()).
+					Return(sharedErrs.NotFoundErr).
+					Times(1)
+				suite.sqlMock.ExpectRollback()
+			},
+			expectedBlockedBy: "COUPON_B",
+		},
+		{
+			name: "blocked by a coupon already claimed",
+			prepareMock: func() {
+				expectCoupons(couponA, couponB)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).Return(user, nil).Times(1)
+				expectLocks()
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(couponA.ID), gomock.Any()).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(nil, sharedErrs.ConflictErr).
+					Times(1)
+				suite.sqlMock.ExpectRollback()
+			},
+			expectedBlockedBy: "COUPON_A",
+		},
+		{
+			name: "blocked by a coupon whose lock is held",
+			prepareMock: func() {
+				expectCoupons(couponA, couponB)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).Return(user, nil).Times(1)
+				gomock.InOrder(
+					suite.redisLock.EXPECT().WithLock(suite.ctx, gomock.Eq("claim:coupon:COUPON_A"), gomock.Any()).
+						DoAndReturn(withLockFence(1)),
+					suite.redisLock.EXPECT().WithLock(suite.ctx, gomock.Eq("claim:coupon:COUPON_B"), gomock.Any()).
+						Return(sharedErrs.New(sharedErrs.ErrKindAcquireRedisLock, "Error acquiring redis lock: lock already taken")),
+				)
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedBlockedBy: "COUPON_B",
+		},
+		{
+			name: "blocked by a coupon whose lease is lost",
+			prepareMock: func() {
+				expectCoupons(couponA, couponB)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).Return(user, nil).Times(1)
+				gomock.InOrder(
+					suite.redisLock.EXPECT().WithLock(suite.ctx, gomock.Eq("claim:coupon:COUPON_A"), gomock.Any()).
+						DoAndReturn(func(ctx context.Context, key string, fn func(context.Context, int64) error, options ...redis.LockOption) error {
+							_ = withLockFence(1)(ctx, key, fn, options...)
+							return redis.ErrLockLeaseLost
+						}),
+					suite.redisLock.EXPECT().WithLock(suite.ctx, gomock.Eq("claim:coupon:COUPON_B"), gomock.Any()).
+						DoAndReturn(withLockFence(2)),
+				)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil).
+					Times(2)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(m.InitUserClaimDomain(), nil).
+					Times(2)
+				suite.sqlMock.ExpectCommit()
+			},
+			expectedBlockedBy: "COUPON_A",
+		},
+		{
+			name: "blocked by a coupon not found",
+			prepareMock: func() {
+				expectCoupons(couponA)
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(couponB.Name), gomock.Eq(false)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.redisLock.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedBlockedBy: "COUPON_B",
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			suite.Before(t)
+			tc.prepareMock()
+
+			result, err := suite.couponService.ClaimBundle(suite.ctx, input)
+
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"COUPON_A", "COUPON_B"}, result.CouponNames)
+			assert.Equal(t, tc.expectedClaimed, result.Claimed)
+			assert.Equal(t, tc.expectedBlockedBy, result.BlockedBy)
+			assert.NoError(t, suite.sqlMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -1,15 +1,32 @@
 package coupon
 
 import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
 	m "coupon_be/mock"
 	"coupon_be/request"
 	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
 
+func (suite *CouponServiceTestSuite) expectWithLock(key string) {
+	suite.redisLock.EXPECT().WithLock(suite.ctx, gomock.Eq(key), gomock.Any()).
+		DoAndReturn(withLockFence(1)).
+		Times(1)
+}
+
+// withLockFence runs the critical section of a mocked WithLock with the given fencing token.
+func withLockFence(fence int64) func(context.Context, string, func(context.Context, int64) error, ...redis.LockOption) error {
+	return func(ctx context.Context, _ string, fn func(ctx context.Context, fence int64) error, _ ...redis.LockOption) error {
+		return fn(ctx, fence)
+	}
+}
+
 func (suite *CouponServiceTestSuite) Test_Claim() {
 	user := m.InitUserDomain()
 	coupon := m.InitCouponDomain()
@@ -29,23 +46,46 @@ func (suite *CouponServiceTestSuite) Test_Claim() {
 			prepareMock: func() {
 				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
 					Return(coupon, nil).
-					Times(1)
-				suite.repo.EXPECT().FindCountUserClaimByCouponID(suite.ctx, gomock.Eq(coupon.ID)).
-					Return(int64(0), nil).
-					Times(1)
+					Times(2)
+				suite.expectWithLock("claim:coupon:COUPON_TEST")
 				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
 					Return(user, nil).
 					Times(1)
 				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(coupon.ID), gomock.Eq(user.ID)).
 					Return(nil, nil).
 					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq(int64(1))).
+					Return(nil).
+					Times(1)
 				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
 					Return(nil, nil).
 					Times(1)
-				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID)).
-					Return(nil).
+				suite.sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "stale fencing token",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(2)
+				suite.expectWithLock("claim:coupon:COUPON_TEST")
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
 					Times(1)
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(coupon.ID), gomock.Eq(user.ID)).
+					Return(nil, nil).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq(int64(1))).
+					Return(sharedErrs.StaleFenceErr).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).Times(0)
+				suite.sqlMock.ExpectRollback()
 			},
+			wantErr:       true,
+			expectedError: sharedErrs.StaleFenceErr,
 		},
 		{
 			name: "coupon not found",
@@ -53,12 +93,13 @@ func (suite *CouponServiceTestSuite) Test_Claim() {
 				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
 					Return(nil, sharedErrs.NotFoundErr).
 					Times(1)
+				suite.redisLock.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
 					Times(0)
 				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(coupon.ID), gomock.Eq(user.ID)).
 					Times(0)
 				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).Times(0)
-				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID)).
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Any()).
 					Times(0)
 			},
 			wantErr:       true,
@@ -72,16 +113,14 @@ func (suite *CouponServiceTestSuite) Test_Claim() {
 
 				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
 					Return(c, nil).
-					Times(1)
-				suite.repo.EXPECT().FindCountUserClaimByCouponID(suite.ctx, gomock.Eq(coupon.ID)).
-					Return(int64(c.Amount), nil).
-					Times(1)
+					Times(2)
+				suite.expectWithLock("claim:coupon:COUPON_TEST")
 				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
 					Times(0)
 				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(coupon.ID), gomock.Eq(user.ID)).
 					Times(0)
 				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).Times(0)
-				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID)).
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Any()).
 					Times(0)
 			},
 			wantErr: true,
@@ -93,17 +132,15 @@ func (suite *CouponServiceTestSuite) Test_Claim() {
 			prepareMock: func() {
 				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
 					Return(coupon, nil).
-					Times(1)
-				suite.repo.EXPECT().FindCountUserClaimByCouponID(suite.ctx, gomock.Eq(coupon.ID)).
-					Return(int64(0), nil).
-					Times(1)
+					Times(2)
+				suite.expectWithLock("claim:coupon:COUPON_TEST")
 				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
 					Return(nil, sharedErrs.NotFoundErr).
 					Times(1)
 				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(coupon.ID), gomock.Eq(user.ID)).
 					Times(0)
 				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).Times(0)
-				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID)).
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Any()).
 					Times(0)
 			},
 			wantErr:       true,
@@ -114,10 +151,8 @@ func (suite *CouponServiceTestSuite) Test_Claim() {
 			prepareMock: func() {
 				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
 					Return(coupon, nil).
-					Times(1)
-				suite.repo.EXPECT().FindCountUserClaimByCouponID(suite.ctx, gomock.Eq(coupon.ID)).
-					Return(int64(0), nil).
-					Times(1)
+					Times(2)
+				suite.expectWithLock("claim:coupon:COUPON_TEST")
 				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
 					Return(user, nil).
 					Times(1)
@@ -126,7 +161,7 @@ func (suite *CouponServiceTestSuite) Test_Claim() {
 					Times(1)
 				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
 					Times(0)
-				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID)).
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Any()).
 					Times(0)
 			},
 			wantErr: true,
@@ -149,7 +184,573 @@ func (suite *CouponServiceTestSuite) Test_Claim() {
 			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
 			if tc.wantErr {
 				assert.Error(t, err)
+				assert.EqualError(t, err, tc.expectedError.Error())
+			}
+		})
+	}
+}
+
+func (suite *CouponServiceTestSuite) Test_Claim_RowLock() {
+	user := m.InitUserDomain()
+	coupon := m.InitCouponDomain()
+	coupon.ClaimStrategy = enums.ClaimStrategyRowLock
+	input := &request.ClaimCoupon{
+		CouponName: "COUPON_TEST",
+		Username:   "user_123",
+	}
+
+	testCases := []struct {
+		name          string
+		prepareMock   func()
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name: "success",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(user.ID), gomock.Eq(coupon.ID)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Any()).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(m.InitUserClaimDomain(), nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "sold out while claiming",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(user.ID), gomock.Eq(coupon.ID)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Any()).
+					Return(sharedErrs.NotFoundErr).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).Times(0)
+				suite.sqlMock.ExpectRollback()
+			},
+			wantErr: true,
+			expectedError: sharedErrs.NewBusinessValidationErr(
+				"Coupon %s is not usable because no stock remaining", coupon.Name),
+		},
+		{
+			name: "concurrent claim by the same user",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(user.ID), gomock.Eq(coupon.ID)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Any()).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(nil, sharedErrs.ConflictErr).
+					Times(1)
+				suite.sqlMock.ExpectRollback()
+			},
+			wantErr: true,
+			expectedError: sharedErrs.New(sharedErrs.ErrKindConflict, "Coupon %s is already claimed by user %s",
+				coupon.Name, user.Username),
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			tc.prepareMock()
+
+			// Act
+			err := suite.couponService.Claim(suite.ctx, input)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			}
+			assert.NoError(t, suite.sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func (suite *CouponServiceTestSuite) Test_Claim_Optimistic() {
+	user := m.InitUserDomain()
+	coupon := m.InitCouponDomain()
+	coupon.ClaimStrategy = enums.ClaimStrategyOptimistic
+	coupon.Version = 3
+	input := &request.ClaimCoupon{
+		CouponName: "COUPON_TEST",
+		Username:   "user_123",
+	}
+
+	testCases := []struct {
+		name          string
+		prepareMock   func()
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name: "success after a version conflict",
+			prepareMock: func() {
+				reloaded := *coupon
+				reloaded.Version = 4
+				reloaded.RemainingAmount = coupon.RemainingAmount - 1
+
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(user.ID), gomock.Eq(coupon.ID)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmountByVersion(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq(uint64(3))).
+					Return(sharedErrs.NotFoundErr).
+					Times(1)
+				suite.sqlMock.ExpectRollback()
+				suite.repo.EXPECT().FindCouponByID(suite.ctx, gomock.Eq(coupon.ID)).
+					Return(&reloaded, nil).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmountByVersion(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq(uint64(4))).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(m.InitUserClaimDomain(), nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "sold out after reload",
+			prepareMock: func() {
+				reloaded := *coupon
+				reloaded.Version = 4
+				reloaded.RemainingAmount = 0
+
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(user.ID), gomock.Eq(coupon.ID)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmountByVersion(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq(uint64(3))).
+					Return(sharedErrs.NotFoundErr).
+					Times(1)
+				suite.sqlMock.ExpectRollback()
+				suite.repo.EXPECT().FindCouponByID(suite.ctx, gomock.Eq(coupon.ID)).
+					Return(&reloaded, nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr: true,
+			expectedError: sharedErrs.NewBusinessValidationErr(
+				"Coupon %s is not usable because no stock remaining", coupon.Name),
+		},
+		{
+			name: "retries exhausted",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(user.ID), gomock.Eq(coupon.ID)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				for range defaultOptimisticMaxRetries {
+					suite.sqlMock.ExpectBegin()
+					suite.sqlMock.ExpectRollback()
+				}
+				suite.repo.EXPECT().DecrementCouponRemainingAmountByVersion(gomock.Any(), gomock.Eq(coupon.ID), gomock.Any()).
+					Return(sharedErrs.NotFoundErr).
+					Times(defaultOptimisticMaxRetries)
+				suite.repo.EXPECT().FindCouponByID(suite.ctx, gomock.Eq(coupon.ID)).
+					Return(coupon, nil).
+					Times(defaultOptimisticMaxRetries)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr: true,
+			expectedError: sharedErrs.New(sharedErrs.ErrKindConflict,
+				"Coupon %s is being claimed by too many users, please try again", coupon.Name),
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			tc.prepareMock()
+
+			// Act
+			err := suite.couponService.Claim(suite.ctx, input)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			}
+			assert.NoError(t, suite.sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func (suite *CouponServiceTestSuite) Test_Claim_RedisAtomic() {
+	user := m.InitUserDomain()
+	coupon := m.InitCouponDomain()
+	coupon.ClaimStrategy = enums.ClaimStrategyRedisAtomic
+	input := &request.ClaimCoupon{
+		CouponName: "COUPON_TEST",
+		Username:   "user_123",
+	}
+
+	testCases := []struct {
+		name          string
+		prepareMock   func()
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name: "success",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.stock.EXPECT().Take(suite.ctx, gomock.Eq("coupon:1"), gomock.Eq("1")).
+					Return(redis.TakeSuccess, nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "success after rebuilding the stock",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				gomock.InOrder(
+					suite.stock.EXPECT().Take(suite.ctx, gomock.Eq("coupon:1"), gomock.Eq("1")).
+						Return(redis.TakeNotLoaded, nil),
+					suite.stock.EXPECT().Take(suite.ctx, gomock.Eq("coupon:1"), gomock.Eq("1")).
+						Return(redis.TakeSuccess, nil),
+				)
+				suite.expectWithLock("claim:writer")
+				suite.repo.EXPECT().FindClaimedUserIDsByCouponID(suite.ctx, gomock.Eq(coupon.ID)).
+					Return([]uint64{2, 3}, nil).
+					Times(1)
+				suite.stock.EXPECT().Rebuild(suite.ctx, gomock.Eq("coupon:1"), gomock.Eq(int64(coupon.Amount)), gomock.Eq([]string{"2", "3"})).
+					Return(int64(coupon.Amount-2), nil).
+					Times(1)
+			},
+		},
+		{
+			name: "user already claimed",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.stock.EXPECT().Take(suite.ctx, gomock.Eq("coupon:1"), gomock.Eq("1")).
+					Return(redis.TakeDuplicate, nil).
+					Times(1)
+			},
+			wantErr: true,
+			expectedError: sharedErrs.New(sharedErrs.ErrKindConflict, "Coupon %s is already claimed by user %s",
+				coupon.Name, user.Username),
+		},
+		{
+			name: "sold out",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.stock.EXPECT().Take(suite.ctx, gomock.Eq("coupon:1"), gomock.Eq("1")).
+					Return(redis.TakeSoldOut, nil).
+					Times(1)
+			},
+			wantErr: true,
+			expectedError: sharedErrs.NewBusinessValidationErr(
+				"Coupon %s is not usable because no stock remaining", coupon.Name),
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			tc.prepareMock()
+
+			// Act
+			err := suite.couponService.Claim(suite.ctx, input)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			}
+		})
+	}
+}
+
+func (suite *CouponServiceTestSuite) Test_Claim_LockUnavailable() {
+	user := m.InitUserDomain()
+	coupon := m.InitCouponDomain()
+	input := &request.ClaimCoupon{
+		CouponName: "COUPON_TEST",
+		Username:   "user_123",
+	}
+
+	testCases := []struct {
+		name          string
+		lockFallback  bool
+		prepareMock   func()
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name:         "falls back to the row lock",
+			lockFallback: true,
+			prepareMock: func() {
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.redisLock.EXPECT().WithLock(suite.ctx, gomock.Eq("claim:coupon:COUPON_TEST"), gomock.Any()).
+					Return(redis.ErrLockUnavailable).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(user.ID), gomock.Eq(coupon.ID)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq(int64(0))).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(m.InitUserClaimDomain(), nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "fails closed",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.redisLock.EXPECT().WithLock(suite.ctx, gomock.Eq("claim:coupon:COUPON_TEST"), gomock.Any()).
+					Return(redis.ErrLockUnavailable).
+					Times(1)
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr:       true,
+			expectedError: redis.ErrLockUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			suite.couponService.(*base).lockFallback = tc.lockFallback
+			tc.prepareMock()
+
+			// Act
+			err := suite.couponService.Claim(suite.ctx, input)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+			assert.NoError(t, suite.sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func (suite *CouponServiceTestSuite) Test_LockUnavailable_Fallback() {
+	user := m.InitUserDomain()
+
+	couponA := m.InitCouponDomain()
+	couponA.ID, couponA.Name = 1, "COUPON_A"
+	couponB := m.InitCouponDomain()
+	couponB.ID, couponB.Name = 2, "COUPON_B"
+
+	testCases := []struct {
+		name          string
+		lockFallback  bool
+		withoutLock   bool
+		act           func() error
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name:         "bundle falls back to the row locks",
+			lockFallback: true,
+			act: func() error {
+				for _, coupon := range []*domain.Coupon{couponA, couponB} {
+					suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(coupon.Name), gomock.Eq(false)).
+						Return(coupon, nil).
+						Times(1)
+				}
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(user.Username)).Return(user, nil).Times(1)
+				suite.redisLock.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(redis.ErrLockUnavailable).
+					Times(2)
+				suite.sqlMock.ExpectBegin()
+				gomock.InOrder(
+					suite.repo.EXPECT().FindCouponByIDForUpdate(gomock.Any(), gomock.Eq(couponA.ID)).Return(couponA, nil),
+					suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(couponA.ID), gomock.Eq(int64(0))).Return(nil),
+					suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).Return(m.InitUserClaimDomain(), nil),
+					suite.repo.EXPECT().FindCouponByIDForUpdate(gomock.Any(), gomock.Eq(couponB.ID)).Return(couponB, nil),
+					suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(couponB.ID), gomock.Eq(int64(0))).Return(nil),
+					suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).Return(m.InitUserClaimDomain(), nil),
+				)
+				suite.sqlMock.ExpectCommit()
+
+				result, err := suite.couponService.ClaimBundle(suite.ctx, &request.ClaimBundle{
+					Username:    user.Username,
+					CouponNames: []string{"COUPON_B", "COUPON_A"},
+				})
+				if err == nil && !result.Claimed {
+					return sharedErrs.New(sharedErrs.ErrKindUnknown, "bundle is blocked by %s", result.BlockedBy)
+				}
+
+				return err
+			},
+		},
+		{
+			name:         "grant falls back without a lock initialised",
+			lockFallback: true,
+			withoutLock:  true,
+			act: func() error {
+				coupon := m.InitCouponDomain()
+				coupon.ClaimStrategy = enums.ClaimStrategyRedisLock
+
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq("COUPON_TEST"), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUsersByUsernames(suite.ctx, gomock.Eq([]string{user.Username})).
+					Return([]*domain.User{user}, nil).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().FindCouponByIDForUpdate(gomock.Any(), gomock.Eq(coupon.ID)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindClaimedUserIDsByCouponIDAndUserIDs(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq([]uint64{user.ID})).
+					Return(nil, nil).
+					Times(1)
+				suite.repo.EXPECT().GrantCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq(uint64(1)), gomock.Eq(uint64(0)), gomock.Eq(int64(0))).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaims(gomock.Any(), gomock.Len(1)).
+					Return(nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+
+				_, err := suite.couponService.Grant(suite.ctx, &request.GrantCoupon{
+					CouponName: "COUPON_TEST",
+					Usernames:  []string{user.Username},
+				})
+
+				return err
+			},
+		},
+		{
+			name:         "reconciler falls back",
+			lockFallback: true,
+			act: func() error {
+				suite.redisLock.EXPECT().WithLock(suite.ctx, gomock.Eq(stockReconcilerLockKey), gomock.Any()).
+					Return(redis.ErrLockUnavailable).
+					Times(1)
+				suite.repo.EXPECT().FindCouponStocks(suite.ctx).Return(nil, nil).Times(1)
+				suite.repo.EXPECT().CreateCouponStockDrifts(suite.ctx, gomock.Len(0)).Return(nil).Times(1)
+
+				_, err := suite.couponService.ReconcileRemainingAmounts(suite.ctx, false)
+
+				return err
+			},
+		},
+		{
+			name:        "reconciler fails closed without a lock initialised",
+			withoutLock: true,
+			act: func() error {
+				suite.repo.EXPECT().FindCouponStocks(gomock.Any()).Times(0)
+
+				_, err := suite.couponService.ReconcileRemainingAmounts(suite.ctx, false)
+
+				return err
+			},
+			wantErr:       true,
+			expectedError: errLockNotInitialised,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			suite.couponService.(*base).lockFallback = tc.lockFallback
+			if tc.withoutLock {
+				suite.couponService.(*base).redisLock = nil
+			}
+
+			// Act
+			err := tc.act()
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, tc.expectedError)
 			}
+			assert.NoError(t, suite.sqlMock.ExpectationsWereMet())
 		})
 	}
 }
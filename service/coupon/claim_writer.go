@@ -0,0 +1,239 @@
+package coupon
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/database"
+	"coupon_be/shared/external/redis"
+	"coupon_be/util/logger"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	couponStockKeyFormat = "coupon:%d"
+	claimWriterLockKey   = "claim:writer"
+)
+
+// errStockDrift is returned when postgres has no stock left for a claim which is already taken in redis.
+var errStockDrift = sharedErrs.New(sharedErrs.ErrKindConflict, "Coupon stock in redis is drifted from postgres")
+
+func couponStockKey(couponID uint64) string {
+	return fmt.Sprintf(couponStockKeyFormat, couponID)
+}
+
+func (b *base) RunClaimWriter(ctx context.Context) {
+	if b.stock == nil || b.redisLock == nil {
+		logger.Info(ctx, "claim writer is disabled, stock counter or redis lock is not initialised")
+		return
+	}
+
+	logger.Info(ctx, "reconciling coupon stocks in redis on startup ...")
+	if err := b.reconcileStocks(ctx); err != nil {
+		logger.Error(ctx, "failed to reconcile coupon stocks on startup: %v", err)
+	}
+
+	ticker := time.NewTicker(b.claimWriterInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info(ctx, "claim writer is stopped")
+			return
+		case <-ticker.C:
+			// keep draining without waiting for the next tick while the batches are full
+			for {
+				persisted, err := b.persistPendingClaims(ctx)
+				if err != nil {
+					logger.Error(ctx, "failed to persist pending claims: %v", err)
+				}
+				if err != nil || persisted < b.claimWriterBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// persistPendingClaims writes one batch of pending takes into user_claims, and returns the number of persisted takes.
+// Only one instance persists at a time, guarded by the claim writer lock.
+func (b *base) persistPendingClaims(ctx context.Context) (int, error) {
+	var processed int
+
+	err := b.redisLock.WithLock(ctx, claimWriterLockKey, func(ctx context.Context, _ int64) error {
+		takes, err := b.stock.Pending(ctx, b.claimWriterBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(takes) == 0 {
+			return nil
+		}
+
+		var persistErr error
+		drifted := make(map[uint64]struct{})
+		for _, take := range takes {
+			if err = b.persistTake(ctx, take); errors.Is(err, errStockDrift) {
+				var couponID uint64
+				_, _ = fmt.Sscanf(take.Key, couponStockKeyFormat, &couponID)
+				drifted[couponID] = struct{}{}
+			} else if err != nil {
+				persistErr = err
+				break
+			}
+
+			processed++
+		}
+
+		if ackErr := b.stock.Ack(ctx, processed); ackErr != nil {
+			return ackErr
+		}
+
+		logger.Info(ctx, "%d pending claims are persisted", processed)
+
+		for couponID := range drifted {
+			coupon, findErr := b.repository.FindCouponByID(ctx, couponID)
+			if findErr != nil {
+				logger.Error(ctx, "failed to find drifted coupon %d: %v", couponID, findErr)
+				continue
+			}
+
+			if rebuildErr := b.rebuildStockLocked(ctx, coupon); rebuildErr != nil {
+				logger.Error(ctx, "failed to rebuild drifted coupon %d: %v", couponID, rebuildErr)
+			}
+		}
+
+		return persistErr
+	})
+
+	return processed, err
+}
+
+// persistTake creates the user claim of the take and decrements the remaining amount of the coupon in postgres.
+// A take which has been persisted before is skipped, so it is safe to persist the same take more than once.
+func (b *base) persistTake(ctx context.Context, take *redis.PendingTake) (err error) {
+	var couponID uint64
+	if _, err = fmt.Sscanf(take.Key, couponStockKeyFormat, &couponID); err != nil {
+		logger.Error(ctx, "skipping pending take with invalid key %s: %v", take.Key, err)
+		return nil
+	}
+
+	userID, err := strconv.ParseUint(take.Member, 10, 64)
+	if err != nil {
+		logger.Error(ctx, "skipping pending take with invalid member %s: %v", take.Member, err)
+		return nil
+	}
+
+	tCtx, tx := database.InitTx(ctx, b.writeDB)
+	defer func() {
+		if err := tx.Rollback().Error; err != nil && !errors.Is(err, sql.ErrTxDone) {
+			logger.Error(ctx, "Repository Error on executing b.persistTake: ROLLBACK TXN: %v", err)
+		}
+	}()
+
+	takenAt := time.UnixMilli(take.TakenAt)
+	_, err = b.repository.CreateUserClaim(tCtx, &domain.UserClaim{
+		BaseModel: domain.BaseModel{
+			CreatedAt: takenAt,
+			UpdatedAt: time.Now(),
+		},
+		UserID:   userID,
+		CouponID: couponID,
+	})
+	if errors.Is(err, sharedErrs.ConflictErr) {
+		logger.Debug(ctx, "claim of coupon id %d by user id %d is already persisted", couponID, userID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// unfenced, the tokens of the claim writer lock are not comparable with the ones of the coupon claim lock
+	var drift bool
+	err = b.repository.DecrementCouponRemainingAmount(tCtx, couponID, 0)
+	if errors.Is(err, sharedErrs.NotFoundErr) {
+		// the claim has been granted to the user already, so keep it and rebuild the redis stock afterwards
+		logger.Error(ctx, "coupon id %d has no remaining amount in postgres for the claim of user id %d", couponID, userID)
+		drift = true
+	} else if err != nil {
+		return err
+	}
+
+	if err = database.Commit(tCtx, tx); err != nil {
+		logger.Error(ctx, "Repository Error on executing b.persistTake: COMMIT TXN: %v", err)
+
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindDatabase, "Failed to persist claim", err)
+	}
+
+	if drift {
+		return errStockDrift
+	}
+
+	return nil
+}
+
+// rebuildStock rebuilds the redis stock of the coupon from postgres while holding the claim writer lock, so no pending
+// claim is persisted in the middle of the rebuild.
+func (b *base) rebuildStock(ctx context.Context, coupon *domain.Coupon) error {
+	if b.redisLock == nil {
+		return sharedErrs.New(sharedErrs.ErrKindRedis, "Redis lock is not initialised")
+	}
+
+	return b.redisLock.WithLock(ctx, claimWriterLockKey, func(ctx context.Context, _ int64) error {
+		return b.rebuildStockLocked(ctx, coupon)
+	})
+}
+
+func (b *base) rebuildStockLocked(ctx context.Context, coupon *domain.Coupon) error {
+	userIDs, err := b.repository.FindClaimedUserIDsByCouponID(ctx, coupon.ID)
+	if err != nil {
+		return err
+	}
+
+	members := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		members[i] = strconv.FormatUint(userID, 10)
+	}
+
+	remaining, err := b.stock.Rebuild(ctx, couponStockKey(coupon.ID), int64(coupon.Amount), members)
+	if err != nil {
+		return err
+	}
+
+	logger.Info(ctx, "stock of coupon %s is rebuilt: amount %d | persisted claims %d | remaining %d",
+		coupon.Name, coupon.Amount, len(userIDs), remaining)
+
+	if remaining > 0 {
+		b.clearSoldOut(ctx, coupon.Name)
+	}
+
+	return nil
+}
+
+// reconcileStocks rebuilds the redis stock of every coupon claimed with the redis_atomic strategy.
+func (b *base) reconcileStocks(ctx context.Context) error {
+	strategies := []enums.ClaimStrategy{enums.ClaimStrategyRedisAtomic}
+	if b.defaultStrategy == enums.ClaimStrategyRedisAtomic {
+		strategies = append(strategies, "")
+	}
+
+	return b.redisLock.WithLock(ctx, claimWriterLockKey, func(ctx context.Context, _ int64) error {
+		coupons, err := b.repository.FindCouponsByClaimStrategy(ctx, strategies)
+		if err != nil {
+			return err
+		}
+
+		for _, coupon := range coupons {
+			if err = b.rebuildStockLocked(ctx, coupon); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,135 @@
+package coupon
+
+import (
+	m "coupon_be/mock"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func (suite *CouponServiceTestSuite) Test_PersistPendingClaims() {
+	coupon := m.InitCouponDomain()
+	takes := []*redis.PendingTake{
+		{Key: "coupon:1", Member: "1", TakenAt: 1760000000000},
+		{Key: "coupon:1", Member: "2", TakenAt: 1760000000001},
+	}
+
+	testCases := []struct {
+		name              string
+		prepareMock       func()
+		expectedPersisted int
+		wantErr           bool
+	}{
+		{
+			name: "success",
+			prepareMock: func() {
+				suite.expectWithLock("claim:writer")
+				suite.stock.EXPECT().Pending(suite.ctx, gomock.Eq(defaultClaimWriterBatchSize)).
+					Return(takes, nil).
+					Times(1)
+				for range takes {
+					suite.sqlMock.ExpectBegin()
+					suite.sqlMock.ExpectCommit()
+				}
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(m.InitUserClaimDomain(), nil).
+					Times(2)
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Any()).
+					Return(nil).
+					Times(2)
+				suite.stock.EXPECT().Ack(suite.ctx, gomock.Eq(2)).
+					Return(nil).
+					Times(1)
+			},
+			expectedPersisted: 2,
+		},
+		{
+			name: "skip already persisted claim",
+			prepareMock: func() {
+				suite.expectWithLock("claim:writer")
+				suite.stock.EXPECT().Pending(suite.ctx, gomock.Eq(defaultClaimWriterBatchSize)).
+					Return(takes[:1], nil).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.sqlMock.ExpectRollback()
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(nil, sharedErrs.ConflictErr).
+					Times(1)
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				suite.stock.EXPECT().Ack(suite.ctx, gomock.Eq(1)).
+					Return(nil).
+					Times(1)
+			},
+			expectedPersisted: 1,
+		},
+		{
+			name: "rebuild drifted stock",
+			prepareMock: func() {
+				suite.expectWithLock("claim:writer")
+				suite.stock.EXPECT().Pending(suite.ctx, gomock.Eq(defaultClaimWriterBatchSize)).
+					Return(takes[:1], nil).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.sqlMock.ExpectCommit()
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(m.InitUserClaimDomain(), nil).
+					Times(1)
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Any()).
+					Return(sharedErrs.NotFoundErr).
+					Times(1)
+				suite.stock.EXPECT().Ack(suite.ctx, gomock.Eq(1)).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().FindCouponByID(suite.ctx, gomock.Eq(coupon.ID)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindClaimedUserIDsByCouponID(suite.ctx, gomock.Eq(coupon.ID)).
+					Return([]uint64{1}, nil).
+					Times(1)
+				suite.stock.EXPECT().Rebuild(suite.ctx, gomock.Eq("coupon:1"), gomock.Eq(int64(coupon.Amount)), gomock.Eq([]string{"1"})).
+					Return(int64(coupon.Amount-1), nil).
+					Times(1)
+			},
+			expectedPersisted: 1,
+		},
+		{
+			name: "stop on database error",
+			prepareMock: func() {
+				suite.expectWithLock("claim:writer")
+				suite.stock.EXPECT().Pending(suite.ctx, gomock.Eq(defaultClaimWriterBatchSize)).
+					Return(takes, nil).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.sqlMock.ExpectRollback()
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRepository, "connection refused", nil)).
+					Times(1)
+				suite.stock.EXPECT().Ack(suite.ctx, gomock.Eq(0)).
+					Return(nil).
+					Times(1)
+			},
+			expectedPersisted: 0,
+			wantErr:           true,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			tc.prepareMock()
+
+			// Act
+			persisted, err := suite.couponService.(*base).persistPendingClaims(suite.ctx)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			assert.Equal(t, tc.expectedPersisted, persisted)
+			assert.NoError(t, suite.sqlMock.ExpectationsWereMet())
+		})
+	}
+}
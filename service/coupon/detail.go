@@ -15,5 +15,11 @@ func (b *base) Detail(ctx context.Context, name string) (*response.Coupon, error
 		return nil, err
 	}
 
+	if coupon.IsSharded() {
+		if coupon.RemainingAmount, err = b.repository.FindCouponShardRemainingAmount(ctx, coupon.ID); err != nil {
+			return nil, err
+		}
+	}
+
 	return response.NewCouponFromDomain(coupon), nil
 }
@@ -36,6 +36,23 @@ func (suite *CouponServiceTestSuite) Test_Detail() {
 					Times(1)
 			},
 		},
+		{
+			name: "sharded coupon",
+			prepareMock: func() {
+				coupon = m.InitCouponDomain()
+				coupon.ShardCount = 4
+
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(commentName), gomock.Eq(true)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindCouponShardRemainingAmount(suite.ctx, gomock.Eq(coupon.ID)).
+					Return(uint64(7), nil).
+					Times(1)
+
+				expected = response.NewCouponFromDomain(coupon)
+				expected.RemainingAmount = 7
+			},
+		},
 		{
 			name: "data not found",
 			prepareMock: func() {
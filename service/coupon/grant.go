@@ -0,0 +1,264 @@
+package coupon
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	"coupon_be/request"
+	"coupon_be/response"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/database"
+	"coupon_be/util"
+	"coupon_be/util/logger"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	grantBatchSize = 100
+	maxGrantUsers  = 5000
+)
+
+// grantTarget is a user the coupon is granted to, next to its entry in the grant report.
+type grantTarget struct {
+	user   *domain.User
+	result *response.CouponGrantResult
+}
+
+// Grant grants the coupon to every listed user who does not hold it yet. The users are granted in batches, each
+// consuming its quota in a single transaction under the claim lock of the coupon, so a grant of thousands of users
+// does not contend with the claims once per user.
+//
+// Users beyond the remaining amount are not granted, unless ExceedQuota is set, in which case the amount of the coupon
+// is raised by the grants over its quota.
+func (b *base) Grant(ctx context.Context, input *request.GrantCoupon) (*response.CouponGrant, error) {
+	logger.Info(ctx, "Grant Coupon with req: %v", input)
+
+	total := len(input.Usernames) + len(input.UserIDs)
+	if total == 0 {
+		return nil, sharedErrs.NewBusinessValidationErr("Please provide the usernames or user_ids to grant the coupon to")
+	}
+	if total > maxGrantUsers {
+		return nil, sharedErrs.NewBusinessValidationErr("Coupon can be granted to at most %d users at once", maxGrantUsers)
+	}
+
+	coupon, err := b.repository.FindCouponByName(ctx, util.SanitizeString(input.CouponName), false)
+	if err != nil {
+		return nil, err
+	}
+
+	if coupon.IsSharded() || b.claimStrategy(coupon).Name() == enums.ClaimStrategyRedisAtomic {
+		return nil, sharedErrs.NewBusinessValidationErr("Coupon %s can not be granted, its stock is not kept in a single row", coupon.Name)
+	}
+
+	result := &response.CouponGrant{CouponName: coupon.Name}
+
+	targets, err := b.findGrantTargets(ctx, input, result)
+	if err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(targets); start += grantBatchSize {
+		batch := targets[start:min(start+grantBatchSize, len(targets))]
+
+		err := b.withClaimLock(ctx, fmt.Sprintf(couponClaimLockKey, coupon.Name), func(ctx context.Context, fence int64) error {
+			return b.grantInTx(ctx, coupon, batch, input.ExceedQuota, fence)
+		})
+		if err != nil {
+			logger.Error(ctx, "failed to grant coupon %s to a batch of %d users: %v", coupon.Name, len(batch), err)
+
+			for _, target := range batch {
+				target.result.Status = enums.GrantStatusFailed.String()
+				target.result.Reason = errorReason(err)
+			}
+		}
+	}
+
+	for _, r := range result.Results {
+		switch enums.GrantStatus(r.Status) {
+		case enums.GrantStatusGranted:
+			result.Granted++
+			if r.OverQuota {
+				result.OverQuota++
+			}
+		case enums.GrantStatusFailed:
+			result.Failed++
+		default:
+			result.Skipped++
+		}
+	}
+
+	logger.Info(ctx, "coupon %s is granted to %d users, %d over quota, %d skipped, %d failed",
+		coupon.Name, result.Granted, result.OverQuota, result.Skipped, result.Failed)
+
+	return result, nil
+}
+
+// findGrantTargets resolves the listed users in the order they are listed. Every listed entry gets a result in the
+// report, the unknown and duplicate ones are reported without being granted.
+func (b *base) findGrantTargets(ctx context.Context, input *request.GrantCoupon, result *response.CouponGrant) ([]*grantTarget, error) {
+	byUsername := make(map[string]*domain.User)
+	if len(input.Usernames) > 0 {
+		users, err := b.repository.FindUsersByUsernames(ctx, input.Usernames)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, user := range users {
+			byUsername[user.Username] = user
+		}
+	}
+
+	byID := make(map[uint64]*domain.User)
+	if len(input.UserIDs) > 0 {
+		users, err := b.repository.FindUsersByIDs(ctx, input.UserIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, user := range users {
+			byID[user.ID] = user
+		}
+	}
+
+	var (
+		targets []*grantTarget
+		seen    = make(map[uint64]bool)
+	)
+
+	add := func(user *domain.User, r *response.CouponGrantResult) {
+		result.Results = append(result.Results, r)
+
+		switch {
+		case user == nil:
+			r.Status = enums.GrantStatusUserNotFound.String()
+		case seen[user.ID]:
+			r.Status = enums.GrantStatusDuplicate.String()
+		default:
+			seen[user.ID] = true
+			targets = append(targets, &grantTarget{user: user, result: r})
+		}
+	}
+
+	for _, username := range input.Usernames {
+		user := byUsername[username]
+
+		r := &response.CouponGrantResult{Username: username}
+		if user != nil {
+			r.UserID = user.ID
+		}
+
+		add(user, r)
+	}
+
+	for _, id := range input.UserIDs {
+		user := byID[id]
+
+		r := &response.CouponGrantResult{UserID: id}
+		if user != nil {
+			r.Username = user.Username
+		}
+
+		add(user, r)
+	}
+
+	return targets, nil
+}
+
+// grantInTx grants the coupon to a batch of users in a single transaction. The coupon row is locked first, so claims
+// not serialised by the claim lock can not change the stock or the claims of the batch until the grant is committed.
+func (b *base) grantInTx(ctx context.Context, coupon *domain.Coupon, batch []*grantTarget, exceedQuota bool, fence int64) (err error) {
+	tCtx, tx := database.InitTx(ctx, b.writeDB)
+	defer func() {
+		if err := tx.Rollback().Error; err != nil && !errors.Is(err, sql.ErrTxDone) {
+			logger.Error(ctx, "Repository Error on executing b.Grant: ROLLBACK TXN: %v", err)
+		}
+	}()
+
+	current, err := b.repository.FindCouponByIDForUpdate(tCtx, coupon.ID)
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]uint64, len(batch))
+	for i, target := range batch {
+		userIDs[i] = target.user.ID
+	}
+
+	claimedUserIDs, err := b.repository.FindClaimedUserIDsByCouponIDAndUserIDs(tCtx, coupon.ID, userIDs)
+	if err != nil {
+		return err
+	}
+
+	holders := make(map[uint64]bool, len(claimedUserIDs))
+	for _, id := range claimedUserIDs {
+		holders[id] = true
+	}
+
+	var (
+		now      = time.Now()
+		claims   []*domain.UserClaim
+		consumed uint64
+		over     uint64
+		statuses = make([]enums.GrantStatus, len(batch))
+		overs    = make([]bool, len(batch))
+	)
+
+	for i, target := range batch {
+		switch {
+		case holders[target.user.ID]:
+			statuses[i] = enums.GrantStatusAlreadyClaimed
+			continue
+		case consumed < current.RemainingAmount:
+			consumed++
+		case exceedQuota:
+			over++
+			overs[i] = true
+		default:
+			statuses[i] = enums.GrantStatusQuotaExceeded
+			continue
+		}
+
+		statuses[i] = enums.GrantStatusGranted
+		claims = append(claims, &domain.UserClaim{
+			BaseModel: domain.BaseModel{
+				CreatedAt: now,
+				UpdatedAt: now,
+			},
+			UserID:   target.user.ID,
+			CouponID: coupon.ID,
+		})
+	}
+
+	if len(claims) > 0 {
+		if err = b.repository.GrantCouponRemainingAmount(tCtx, coupon.ID, consumed, over, fence); err != nil {
+			return err
+		}
+
+		if err = b.repository.CreateUserClaims(tCtx, claims); err != nil {
+			return err
+		}
+	}
+
+	if err = database.Commit(tCtx, tx); err != nil {
+		logger.Error(ctx, "Repository Error on executing b.Grant: COMMIT TXN: %v", err)
+
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindDatabase, "Failed to grant coupon", err)
+	}
+
+	// a mark left behind by a claim racing with a restore is removed while stock remains
+	if consumed == current.RemainingAmount {
+		b.markSoldOut(ctx, coupon.Name)
+	} else {
+		b.clearSoldOut(ctx, coupon.Name)
+	}
+
+	for i, target := range batch {
+		target.result.Status = statuses[i].String()
+		target.result.OverQuota = overs[i]
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package coupon
+
+import (
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	m "coupon_be/mock"
+	"coupon_be/request"
+	"coupon_be/response"
+	sharedErrs "coupon_be/shared/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func (suite *CouponServiceTestSuite) Test_Grant() {
+	newUser := func(id uint64, username string) *domain.User {
+		user := m.InitUserDomain()
+		user.ID, user.Username = id, username
+
+		return user
+	}
+
+	alice, bob, carol := newUser(1, "alice"), newUser(2, "bob"), newUser(3, "carol")
+
+	input := &request.GrantCoupon{
+		CouponName: "COUPON_TEST",
+		Usernames:  []string{"alice", "bob", "carol", "ghost"},
+		UserIDs:    []uint64{1},
+	}
+
+	var coupon *domain.Coupon
+
+	expectBatch := func() {
+		suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+			Return(coupon, nil).
+			Times(1)
+		suite.repo.EXPECT().FindUsersByUsernames(suite.ctx, gomock.Eq(input.Usernames)).
+			Return([]*domain.User{alice, bob, carol}, nil).
+			Times(1)
+		suite.repo.EXPECT().FindUsersByIDs(suite.ctx, gomock.Eq(input.UserIDs)).
+			Return([]*domain.User{alice}, nil).
+			Times(1)
+		suite.expectWithLock("claim:coupon:COUPON_TEST")
+		suite.sqlMock.ExpectBegin()
+		suite.repo.EXPECT().FindCouponByIDForUpdate(gomock.Any(), gomock.Eq(coupon.ID)).
+			Return(coupon, nil).
+			Times(1)
+		suite.repo.EXPECT().FindClaimedUserIDsByCouponIDAndUserIDs(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq([]uint64{1, 2, 3})).
+			Return([]uint64{bob.ID}, nil).
+			Times(1)
+	}
+
+	expectedResults := func(carolStatus enums.GrantStatus, carolOverQuota bool, aliceStatus enums.GrantStatus) []*response.CouponGrantResult {
+		return []*response.CouponGrantResult{
+			{Username: "alice", UserID: 1, Status: aliceStatus.String()},
+			{Username: "bob", UserID: 2, Status: enums.GrantStatusAlreadyClaimed.String()},
+			{Username: "carol", UserID: 3, Status: carolStatus.String(), OverQuota: carolOverQuota},
+			{Username: "ghost", Status: enums.GrantStatusUserNotFound.String()},
+			{Username: "alice", UserID: 1, Status: enums.GrantStatusDuplicate.String()},
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		exceedQuota bool
+		prepareMock func()
+		wantErr     bool
+		expected    *response.CouponGrant
+	}{
+		{
+			name: "success within the quota",
+			prepareMock: func() {
+				coupon = m.InitCouponDomain()
+				coupon.RemainingAmount = 1
+
+				expectBatch()
+				suite.repo.EXPECT().GrantCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq(uint64(1)), gomock.Eq(uint64(0)), gomock.Eq(int64(1))).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaims(gomock.Any(), gomock.Len(1)).
+					Return(nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+			},
+			expected: &response.CouponGrant{
+				CouponName: "COUPON_TEST",
+				Granted:    1,
+				Skipped:    4,
+				Results:    expectedResults(enums.GrantStatusQuotaExceeded, false, enums.GrantStatusGranted),
+			},
+		},
+		{
+			name:        "success exceeding the quota",
+			exceedQuota: true,
+			prepareMock: func() {
+				coupon = m.InitCouponDomain()
+				coupon.RemainingAmount = 1
+
+				expectBatch()
+				suite.repo.EXPECT().GrantCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq(uint64(1)), gomock.Eq(uint64(1)), gomock.Eq(int64(1))).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaims(gomock.Any(), gomock.Len(2)).
+					Return(nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+			},
+			expected: &response.CouponGrant{
+				CouponName: "COUPON_TEST",
+				Granted:    2,
+				OverQuota:  1,
+				Skipped:    3,
+				Results:    expectedResults(enums.GrantStatusGranted, true, enums.GrantStatusGranted),
+			},
+		},
+		{
+			name: "batch failed on a stale fencing token",
+			prepareMock: func() {
+				coupon = m.InitCouponDomain()
+
+				expectBatch()
+				suite.repo.EXPECT().GrantCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq(uint64(2)), gomock.Eq(uint64(0)), gomock.Any()).
+					Return(sharedErrs.StaleFenceErr).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaims(gomock.Any(), gomock.Any()).Times(0)
+				suite.sqlMock.ExpectRollback()
+			},
+			expected: &response.CouponGrant{
+				CouponName: "COUPON_TEST",
+				Skipped:    2,
+				Failed:     3,
+			},
+		},
+		{
+			name: "sharded coupon",
+			prepareMock: func() {
+				coupon = m.InitCouponDomain()
+				coupon.ShardCount = 4
+
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.redisLock.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			tc.prepareMock()
+
+			grant := *input
+			grant.ExceedQuota = tc.exceedQuota
+
+			// Act
+			result, err := suite.couponService.Grant(suite.ctx, &grant)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.Empty(t, result)
+				return
+			}
+
+			assert.Equal(t, tc.expected.Granted, result.Granted)
+			assert.Equal(t, tc.expected.OverQuota, result.OverQuota)
+			assert.Equal(t, tc.expected.Skipped, result.Skipped)
+			assert.Equal(t, tc.expected.Failed, result.Failed)
+			if tc.expected.Results != nil {
+				assert.Equal(t, tc.expected.Results, result.Results)
+			}
+			assert.NoError(t, suite.sqlMock.ExpectationsWereMet())
+		})
+	}
+}
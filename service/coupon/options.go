@@ -0,0 +1,61 @@
+package coupon
+
+import (
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/queue"
+	"coupon_be/shared/external/redis"
+)
+
+type Option func(*base) error
+
+// WithRedisLock sets the distributed lock used by the redis_lock strategy and the claim writer.
+func WithRedisLock(lock redis.ILock) Option {
+	return func(b *base) error {
+		if lock == nil {
+			return sharedErrs.New(sharedErrs.ErrKindCodeInjection, "Redis lock is nil")
+		}
+
+		b.redisLock = lock
+
+		return nil
+	}
+}
+
+// WithStockCounter sets the atomic stock counter used by the redis_atomic strategy.
+func WithStockCounter(stock redis.IStockCounter) Option {
+	return func(b *base) error {
+		if stock == nil {
+			return sharedErrs.New(sharedErrs.ErrKindCodeInjection, "Stock counter is nil")
+		}
+
+		b.stock = stock
+
+		return nil
+	}
+}
+
+// WithSoldOutMarker sets the marker the sold-out coupons are short-circuited with.
+func WithSoldOutMarker(soldOut redis.ISoldOutMarker) Option {
+	return func(b *base) error {
+		if soldOut == nil {
+			return sharedErrs.New(sharedErrs.ErrKindCodeInjection, "Sold out marker is nil")
+		}
+
+		b.soldOut = soldOut
+
+		return nil
+	}
+}
+
+// WithClaimQueue sets the queue the asynchronous claims are published to and consumed from.
+func WithClaimQueue(claimQueue queue.IQueue) Option {
+	return func(b *base) error {
+		if claimQueue == nil {
+			return sharedErrs.New(sharedErrs.ErrKindCodeInjection, "Claim queue is nil")
+		}
+
+		b.claimQueue = claimQueue
+
+		return nil
+	}
+}
@@ -0,0 +1,132 @@
+package coupon
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/response"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/logger"
+	"errors"
+	"time"
+)
+
+const stockReconcilerLockKey = "reconcile:remaining_amount"
+
+func (b *base) RunStockReconciler(ctx context.Context) {
+	if b.redisLock == nil && !b.lockFallback {
+		logger.Info(ctx, "stock reconciler is disabled, redis lock is not initialised")
+		return
+	}
+
+	ticker := time.NewTicker(b.reconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info(ctx, "stock reconciler is stopped")
+			return
+		case <-ticker.C:
+			if _, err := b.ReconcileRemainingAmounts(ctx, b.reconcileAutoCorrect); err != nil {
+				logger.Error(ctx, "failed to reconcile coupon remaining amounts: %v", err)
+			}
+		}
+	}
+}
+
+// ReconcileRemainingAmounts compares the remaining amount of every coupon with amount - count(user_claims), records the
+// drifts, and corrects them when autoCorrect is set. Only one instance reconciles at a time, unless the redis lock is
+// unavailable and the claims fall back, the same drift may be recorded by several instances then.
+func (b *base) ReconcileRemainingAmounts(ctx context.Context, autoCorrect bool) (*response.StockReconciliation, error) {
+	logger.Info(ctx, "Reconcile Coupon Remaining Amounts with auto correct: %t", autoCorrect)
+
+	var result *response.StockReconciliation
+
+	err := b.withClaimLock(ctx, stockReconcilerLockKey, func(ctx context.Context, _ int64) error {
+		stocks, err := b.repository.FindCouponStocks(ctx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		result = &response.StockReconciliation{
+			CheckedCoupons: len(stocks),
+			AutoCorrect:    autoCorrect,
+			Drifts:         make([]*response.StockDrift, 0),
+			ReconciledAt:   now,
+		}
+
+		var drifts []*domain.CouponStockDrift
+		for _, stock := range stocks {
+			if !stock.IsDrifted() {
+				continue
+			}
+
+			logger.Warn(ctx, "coupon %d remaining amount drifted: amount %d | claimed %d | remaining %d | expected %d",
+				stock.CouponID, stock.Amount, stock.ClaimedCount, stock.RemainingAmount, stock.ExpectedRemainingAmount())
+
+			corrected := autoCorrect && b.correctRemainingAmount(ctx, stock)
+
+			result.Drifts = append(result.Drifts, response.NewStockDrift(stock, corrected))
+			drifts = append(drifts, &domain.CouponStockDrift{
+				BaseModel: domain.BaseModel{
+					CreatedAt: now,
+					UpdatedAt: now,
+				},
+				CouponID:                stock.CouponID,
+				Amount:                  stock.Amount,
+				ClaimedCount:            stock.ClaimedCount,
+				RemainingAmount:         stock.RemainingAmount,
+				ExpectedRemainingAmount: stock.ExpectedRemainingAmount(),
+				Corrected:               corrected,
+			})
+		}
+
+		if err = b.repository.CreateCouponStockDrifts(ctx, drifts); err != nil {
+			return err
+		}
+
+		logger.Info(ctx, "reconciled %d coupons, %d drifted", len(stocks), len(drifts))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// correctRemainingAmount overwrites the remaining amount with the one derived from the claims, and reports whether
+// the drift is corrected.
+func (b *base) correctRemainingAmount(ctx context.Context, stock *domain.CouponStock) bool {
+	if stock.ShardCount > 1 {
+		logger.Error(ctx, "coupon %d is sharded, the drift can not be attributed to a shard and has to be corrected manually",
+			stock.CouponID)
+		return false
+	}
+
+	expected := stock.ExpectedRemainingAmount()
+	if expected < 0 {
+		logger.Error(ctx, "coupon %d is over-claimed by %d, it has to be corrected manually", stock.CouponID, -expected)
+		return false
+	}
+
+	err := b.repository.CorrectCouponRemainingAmount(ctx, stock.CouponID, stock.RemainingAmount, uint64(expected))
+	if errors.Is(err, sharedErrs.NotFoundErr) {
+		logger.Info(ctx, "coupon %d is claimed while reconciling, skipping the correction", stock.CouponID)
+		return false
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to correct coupon %d remaining amount: %v", stock.CouponID, err)
+		return false
+	}
+
+	logger.Info(ctx, "coupon %d remaining amount is corrected to %d", stock.CouponID, expected)
+
+	if expected > 0 {
+		b.clearSoldOut(ctx, stock.CouponName)
+	}
+
+	return true
+}
@@ -0,0 +1,101 @@
+package coupon
+
+import (
+	"coupon_be/domain"
+	sharedErrs "coupon_be/shared/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func (suite *CouponServiceTestSuite) Test_ReconcileRemainingAmounts() {
+	stocks := func() []*domain.CouponStock {
+		return []*domain.CouponStock{
+			{CouponID: 1, CouponName: "COUPON_OK", Amount: 10, RemainingAmount: 7, ClaimedCount: 3},
+			{CouponID: 2, CouponName: "COUPON_DRIFT", Amount: 10, RemainingAmount: 5, ClaimedCount: 3},
+			{CouponID: 3, CouponName: "COUPON_OVER", Amount: 2, RemainingAmount: 0, ClaimedCount: 3},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		autoCorrect       bool
+		prepareMock       func()
+		expectedDrifts    int
+		expectedCorrected []bool
+		wantErr           bool
+	}{
+		{
+			name: "report only",
+			prepareMock: func() {
+				suite.expectWithLock(stockReconcilerLockKey)
+				suite.repo.EXPECT().FindCouponStocks(suite.ctx).Return(stocks(), nil).Times(1)
+				suite.repo.EXPECT().CorrectCouponRemainingAmount(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+				suite.repo.EXPECT().CreateCouponStockDrifts(suite.ctx, gomock.Len(2)).Return(nil).Times(1)
+			},
+			expectedDrifts:    2,
+			expectedCorrected: []bool{false, false},
+		},
+		{
+			name:        "auto correct",
+			autoCorrect: true,
+			prepareMock: func() {
+				suite.expectWithLock(stockReconcilerLockKey)
+				suite.repo.EXPECT().FindCouponStocks(suite.ctx).Return(stocks(), nil).Times(1)
+				suite.repo.EXPECT().CorrectCouponRemainingAmount(suite.ctx, gomock.Eq(uint64(2)), gomock.Eq(uint64(5)), gomock.Eq(uint64(7))).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateCouponStockDrifts(suite.ctx, gomock.Len(2)).Return(nil).Times(1)
+			},
+			expectedDrifts:    2,
+			expectedCorrected: []bool{true, false},
+		},
+		{
+			name:        "claimed while reconciling",
+			autoCorrect: true,
+			prepareMock: func() {
+				suite.expectWithLock(stockReconcilerLockKey)
+				suite.repo.EXPECT().FindCouponStocks(suite.ctx).Return(stocks()[:2], nil).Times(1)
+				suite.repo.EXPECT().CorrectCouponRemainingAmount(suite.ctx, gomock.Eq(uint64(2)), gomock.Eq(uint64(5)), gomock.Eq(uint64(7))).
+					Return(sharedErrs.NotFoundErr).
+					Times(1)
+				suite.repo.EXPECT().CreateCouponStockDrifts(suite.ctx, gomock.Len(1)).Return(nil).Times(1)
+			},
+			expectedDrifts:    1,
+			expectedCorrected: []bool{false},
+		},
+		{
+			name: "failed to find stocks",
+			prepareMock: func() {
+				suite.expectWithLock(stockReconcilerLockKey)
+				suite.repo.EXPECT().FindCouponStocks(suite.ctx).
+					Return(nil, sharedErrs.New(sharedErrs.ErrKindDatabase, "Connection refused")).
+					Times(1)
+				suite.repo.EXPECT().CreateCouponStockDrifts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			suite.Before(t)
+			tc.prepareMock()
+
+			result, err := suite.couponService.ReconcileRemainingAmounts(suite.ctx, tc.autoCorrect)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, result.Drifts, tc.expectedDrifts)
+			for i, corrected := range tc.expectedCorrected {
+				assert.Equal(t, corrected, result.Drifts[i].Corrected)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package coupon
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/request"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/logger"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+const couponShardLockKey = "claim:coupon:%s:shard:%d"
+
+// claimSharded claims one stock from the shard picked by the user hash, falling back to the other shards in turn
+// when it is empty. Every shard has its own claim lock and counter row, so claims on different shards run in parallel.
+func (b *base) claimSharded(ctx context.Context, coupon *domain.Coupon, input *request.ClaimCoupon) error {
+	user, err := b.findClaimant(ctx, coupon, input.Username)
+	if err != nil {
+		return err
+	}
+
+	shards, err := b.repository.FindCouponShards(ctx, coupon.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, shard := range shardOrder(shards, input.Username) {
+		if shard.RemainingAmount == 0 {
+			continue
+		}
+
+		logger.Info(ctx, "claiming coupon %s from shard %d ...", coupon.Name, shard.ShardIndex)
+
+		claim := func(ctx context.Context, fence int64) error {
+			return b.claimInTx(ctx, coupon, user, func(tCtx context.Context) error {
+				return b.repository.DecrementCouponShardRemainingAmount(tCtx, shard.ID, fence)
+			})
+		}
+
+		err = b.withClaimLock(ctx, fmt.Sprintf(couponShardLockKey, coupon.Name, shard.ShardIndex), claim)
+		if errors.Is(err, sharedErrs.NotFoundErr) {
+			logger.Info(ctx, "shard %d of coupon %s is sold out while claiming, trying the next shard", shard.ShardIndex, coupon.Name)
+			continue
+		}
+
+		return err
+	}
+
+	logger.Warn(ctx, "coupon %s is not usable, every shard is sold out", coupon.Name)
+
+	return b.soldOutErr(ctx, coupon)
+}
+
+// shardOrder returns the shards starting from the one the username hashes to.
+func shardOrder(shards []*domain.CouponShard, username string) []*domain.CouponShard {
+	if len(shards) == 0 {
+		return shards
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(username))
+	start := int(hash.Sum32() % uint32(len(shards)))
+
+	return append(shards[start:len(shards):len(shards)], shards[:start]...)
+}
+
+// newCouponShards splits the amount of the coupon evenly into count shards, the first shards take the remainder.
+func newCouponShards(coupon *domain.Coupon, count int) []*domain.CouponShard {
+	now := time.Now()
+
+	shards := make([]*domain.CouponShard, count)
+	for i := range shards {
+		amount := coupon.Amount / uint64(count)
+		if uint64(i) < coupon.Amount%uint64(count) {
+			amount++
+		}
+
+		shards[i] = &domain.CouponShard{
+			BaseModel: domain.BaseModel{
+				CreatedAt: now,
+				UpdatedAt: now,
+			},
+			CouponID:        coupon.ID,
+			ShardIndex:      i,
+			Amount:          amount,
+			RemainingAmount: amount,
+		}
+	}
+
+	return shards
+}
@@ -0,0 +1,176 @@
+package coupon
+
+import (
+	"coupon_be/domain"
+	m "coupon_be/mock"
+	"coupon_be/request"
+	sharedErrs "coupon_be/shared/errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func initCouponShards(remaining ...uint64) []*domain.CouponShard {
+	shards := make([]*domain.CouponShard, len(remaining))
+	for i, amount := range remaining {
+		shards[i] = &domain.CouponShard{
+			BaseModel:       domain.BaseModel{ID: uint64(i + 1)},
+			CouponID:        1,
+			ShardIndex:      i,
+			Amount:          5,
+			RemainingAmount: amount,
+		}
+	}
+
+	return shards
+}
+
+func (suite *CouponServiceTestSuite) Test_Claim_Sharded() {
+	user := m.InitUserDomain()
+	coupon := m.InitCouponDomain()
+	coupon.ShardCount = 3
+	input := &request.ClaimCoupon{
+		CouponName: "COUPON_TEST",
+		Username:   "user_123",
+	}
+
+	// the shard the user hashes to, and the one after it
+	home := shardOrder(initCouponShards(1, 1, 1), input.Username)[0].ShardIndex
+	next := (home + 1) % 3
+
+	shardsWith := func(remaining map[int]uint64) []*domain.CouponShard {
+		shards := initCouponShards(0, 0, 0)
+		for index, amount := range remaining {
+			shards[index].RemainingAmount = amount
+		}
+
+		return shards
+	}
+
+	expectFindClaimant := func() {
+		suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+			Return(coupon, nil).
+			Times(1)
+		suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+			Return(user, nil).
+			Times(1)
+		suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(coupon.ID), gomock.Eq(user.ID)).
+			Return(nil, nil).
+			Times(1)
+	}
+
+	testCases := []struct {
+		name          string
+		prepareMock   func()
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name: "claim from the shard of the user",
+			prepareMock: func() {
+				expectFindClaimant()
+				suite.repo.EXPECT().FindCouponShards(suite.ctx, gomock.Eq(coupon.ID)).
+					Return(shardsWith(map[int]uint64{home: 1, next: 1}), nil).
+					Times(1)
+				suite.expectWithLock(fmt.Sprintf("claim:coupon:COUPON_TEST:shard:%d", home))
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponShardRemainingAmount(gomock.Any(), gomock.Eq(uint64(home+1)), gomock.Eq(int64(1))).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(m.InitUserClaimDomain(), nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "fall back when the shard of the user is empty",
+			prepareMock: func() {
+				expectFindClaimant()
+				suite.repo.EXPECT().FindCouponShards(suite.ctx, gomock.Eq(coupon.ID)).
+					Return(shardsWith(map[int]uint64{next: 1}), nil).
+					Times(1)
+				suite.expectWithLock(fmt.Sprintf("claim:coupon:COUPON_TEST:shard:%d", next))
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponShardRemainingAmount(gomock.Any(), gomock.Eq(uint64(next+1)), gomock.Eq(int64(1))).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(m.InitUserClaimDomain(), nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "fall back when the shard of the user is sold out while claiming",
+			prepareMock: func() {
+				expectFindClaimant()
+				suite.repo.EXPECT().FindCouponShards(suite.ctx, gomock.Eq(coupon.ID)).
+					Return(shardsWith(map[int]uint64{home: 1, next: 1}), nil).
+					Times(1)
+				suite.expectWithLock(fmt.Sprintf("claim:coupon:COUPON_TEST:shard:%d", home))
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponShardRemainingAmount(gomock.Any(), gomock.Eq(uint64(home+1)), gomock.Any()).
+					Return(sharedErrs.NotFoundErr).
+					Times(1)
+				suite.sqlMock.ExpectRollback()
+				suite.expectWithLock(fmt.Sprintf("claim:coupon:COUPON_TEST:shard:%d", next))
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponShardRemainingAmount(gomock.Any(), gomock.Eq(uint64(next+1)), gomock.Any()).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(m.InitUserClaimDomain(), nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "every shard sold out",
+			prepareMock: func() {
+				expectFindClaimant()
+				suite.repo.EXPECT().FindCouponShards(suite.ctx, gomock.Eq(coupon.ID)).
+					Return(shardsWith(nil), nil).
+					Times(1)
+				suite.redisLock.EXPECT().WithLock(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr: true,
+			expectedError: sharedErrs.NewBusinessValidationErr(
+				"Coupon %s is not usable because no stock remaining", coupon.Name),
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			suite.Before(t)
+			tc.prepareMock()
+
+			err := suite.couponService.Claim(suite.ctx, input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tc.expectedError, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, suite.sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestNewCouponShards(t *testing.T) {
+	coupon := &domain.Coupon{BaseModel: domain.BaseModel{ID: 1}, Amount: 11}
+
+	shards := newCouponShards(coupon, 4)
+
+	var total uint64
+	for i, shard := range shards {
+		assert.Equal(t, i, shard.ShardIndex)
+		assert.Equal(t, shard.Amount, shard.RemainingAmount)
+		total += shard.Amount
+	}
+	assert.Equal(t, []uint64{3, 3, 3, 2}, []uint64{shards[0].Amount, shards[1].Amount, shards[2].Amount, shards[3].Amount})
+	assert.Equal(t, coupon.Amount, total)
+}
@@ -0,0 +1,60 @@
+package coupon
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/util"
+	"coupon_be/util/logger"
+)
+
+// CheckSoldOut rejects the claims of a coupon marked as sold out, so they are answered without taking the claim lock
+// or reaching postgres. The claims go through when the marker is unavailable, the claim itself being authoritative.
+func (b *base) CheckSoldOut(ctx context.Context, couponName string) error {
+	if b.soldOut == nil {
+		return nil
+	}
+
+	name := util.SanitizeString(couponName)
+
+	marked, err := b.soldOut.IsMarked(ctx, name)
+	if err != nil {
+		logger.Error(ctx, "failed to check whether coupon %s is sold out, claiming it anyway: %v", name, err)
+		return nil
+	}
+	if marked {
+		logger.Debug(ctx, "coupon %s is marked as sold out", name)
+		return notUsableErr(&domain.Coupon{Name: name})
+	}
+
+	return nil
+}
+
+// soldOutErr marks the coupon as sold out and returns the error of claiming it.
+func (b *base) soldOutErr(ctx context.Context, coupon *domain.Coupon) error {
+	b.markSoldOut(ctx, coupon.Name)
+
+	return notUsableErr(coupon)
+}
+
+// markSoldOut marks the coupon as sold out until its stock is restored, or until the mark expires. The mark expiring
+// bounds a mark left behind by a claim racing with a restore.
+func (b *base) markSoldOut(ctx context.Context, couponName string) {
+	if b.soldOut == nil {
+		return
+	}
+
+	if err := b.soldOut.Mark(ctx, couponName, b.soldOutTTL); err != nil {
+		logger.Error(ctx, "failed to mark coupon %s as sold out: %v", couponName, err)
+	}
+}
+
+// clearSoldOut removes the sold out mark of a coupon whose stock is restored.
+func (b *base) clearSoldOut(ctx context.Context, couponName string) {
+	if b.soldOut == nil {
+		return
+	}
+
+	if err := b.soldOut.Clear(ctx, couponName); err != nil {
+		logger.Error(ctx, "failed to clear the sold out mark of coupon %s: %v", couponName, err)
+	}
+}
@@ -0,0 +1,300 @@
+package coupon
+
+import (
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	m "coupon_be/mock"
+	"coupon_be/request"
+	sharedErrs "coupon_be/shared/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func (suite *CouponServiceTestSuite) Test_CheckSoldOut() {
+	var soldOut *m.MockISoldOutMarker
+
+	testCases := []struct {
+		name          string
+		prepareMock   func()
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name: "not marked",
+			prepareMock: func() {
+				soldOut.EXPECT().IsMarked(suite.ctx, gomock.Eq("COUPON_TEST")).
+					Return(false, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "marked as sold out",
+			prepareMock: func() {
+				soldOut.EXPECT().IsMarked(suite.ctx, gomock.Eq("COUPON_TEST")).
+					Return(true, nil).
+					Times(1)
+			},
+			wantErr: true,
+			expectedError: sharedErrs.NewBusinessValidationErr(
+				"Coupon %s is not usable because no stock remaining", "COUPON_TEST"),
+		},
+		{
+			name: "marker unavailable",
+			prepareMock: func() {
+				soldOut.EXPECT().IsMarked(suite.ctx, gomock.Eq("COUPON_TEST")).
+					Return(false, sharedErrs.New(sharedErrs.ErrKindRedis, "redis is down")).
+					Times(1)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			soldOut = m.NewMockISoldOutMarker(gomock.NewController(t))
+			suite.couponService.(*base).soldOut = soldOut
+			tc.prepareMock()
+
+			// Act
+			err := suite.couponService.CheckSoldOut(suite.ctx, " COUPON_TEST ")
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.EqualError(t, err, tc.expectedError.Error())
+			}
+		})
+	}
+}
+
+func (suite *CouponServiceTestSuite) Test_Claim_MarkSoldOut() {
+	user := m.InitUserDomain()
+	input := &request.ClaimCoupon{
+		CouponName: "COUPON_TEST",
+		Username:   "user_123",
+	}
+
+	var soldOut *m.MockISoldOutMarker
+
+	testCases := []struct {
+		name        string
+		prepareMock func()
+		wantErr     bool
+	}{
+		{
+			name: "last stock is claimed",
+			prepareMock: func() {
+				coupon := m.InitCouponDomain()
+				coupon.RemainingAmount = 1
+
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(2)
+				suite.expectWithLock("claim:coupon:COUPON_TEST")
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Any(), gomock.Any()).
+					Return(nil, nil).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq(int64(1))).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(nil, nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+				soldOut.EXPECT().Mark(suite.ctx, gomock.Eq("COUPON_TEST"), gomock.Eq(time.Minute)).
+					Return(nil).
+					Times(1)
+			},
+		},
+		{
+			name: "stock remains after the claim",
+			prepareMock: func() {
+				coupon := m.InitCouponDomain()
+
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(2)
+				suite.expectWithLock("claim:coupon:COUPON_TEST")
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Any(), gomock.Any()).
+					Return(nil, nil).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().DecrementCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq(int64(1))).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaim(gomock.Any(), gomock.Any()).
+					Return(nil, nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+				soldOut.EXPECT().Mark(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "coupon is sold out",
+			prepareMock: func() {
+				coupon := m.InitCouponDomain()
+				coupon.RemainingAmount = 0
+
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(2)
+				suite.expectWithLock("claim:coupon:COUPON_TEST")
+				suite.repo.EXPECT().FindUserByUsername(gomock.Any(), gomock.Any()).Times(0)
+				soldOut.EXPECT().Mark(suite.ctx, gomock.Eq("COUPON_TEST"), gomock.Eq(time.Minute)).
+					Return(nil).
+					Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "marker failure does not fail the claim",
+			prepareMock: func() {
+				coupon := m.InitCouponDomain()
+				coupon.RemainingAmount = 0
+				coupon.ClaimStrategy = enums.ClaimStrategyRowLock
+
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(input.CouponName), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				soldOut.EXPECT().Mark(suite.ctx, gomock.Eq("COUPON_TEST"), gomock.Eq(time.Minute)).
+					Return(sharedErrs.New(sharedErrs.ErrKindRedis, "redis is down")).
+					Times(1)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			soldOut = m.NewMockISoldOutMarker(gomock.NewController(t))
+			suite.couponService.(*base).soldOut = soldOut
+			tc.prepareMock()
+
+			// Act
+			err := suite.couponService.Claim(suite.ctx, input)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.EqualError(t, err, notUsableErr(&domain.Coupon{Name: "COUPON_TEST"}).Error())
+			}
+		})
+	}
+}
+
+func (suite *CouponServiceTestSuite) Test_ClearSoldOut() {
+	user := m.InitUserDomain()
+
+	var soldOut *m.MockISoldOutMarker
+
+	testCases := []struct {
+		name string
+		act  func() error
+	}{
+		{
+			name: "stock is rebuilt with stock remaining",
+			act: func() error {
+				coupon := m.InitCouponDomain()
+
+				suite.repo.EXPECT().FindClaimedUserIDsByCouponID(suite.ctx, gomock.Eq(coupon.ID)).
+					Return([]uint64{user.ID}, nil).
+					Times(1)
+				suite.stock.EXPECT().Rebuild(suite.ctx, gomock.Eq(couponStockKey(coupon.ID)), gomock.Eq(int64(coupon.Amount)), gomock.Len(1)).
+					Return(int64(1), nil).
+					Times(1)
+				soldOut.EXPECT().Clear(suite.ctx, gomock.Eq("COUPON_TEST")).
+					Return(nil).
+					Times(1)
+
+				return suite.couponService.(*base).rebuildStockLocked(suite.ctx, coupon)
+			},
+		},
+		{
+			name: "stock is rebuilt with no stock remaining",
+			act: func() error {
+				coupon := m.InitCouponDomain()
+
+				suite.repo.EXPECT().FindClaimedUserIDsByCouponID(suite.ctx, gomock.Eq(coupon.ID)).
+					Return([]uint64{user.ID}, nil).
+					Times(1)
+				suite.stock.EXPECT().Rebuild(suite.ctx, gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(int64(0), nil).
+					Times(1)
+				soldOut.EXPECT().Clear(gomock.Any(), gomock.Any()).Times(0)
+
+				return suite.couponService.(*base).rebuildStockLocked(suite.ctx, coupon)
+			},
+		},
+		{
+			name: "grant leaves stock remaining",
+			act: func() error {
+				coupon := m.InitCouponDomain()
+				coupon.RemainingAmount = 2
+
+				suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq("COUPON_TEST"), gomock.Eq(false)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUsersByUsernames(suite.ctx, gomock.Eq([]string{user.Username})).
+					Return([]*domain.User{user}, nil).
+					Times(1)
+				suite.expectWithLock("claim:coupon:COUPON_TEST")
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().FindCouponByIDForUpdate(gomock.Any(), gomock.Eq(coupon.ID)).
+					Return(coupon, nil).
+					Times(1)
+				suite.repo.EXPECT().FindClaimedUserIDsByCouponIDAndUserIDs(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq([]uint64{user.ID})).
+					Return(nil, nil).
+					Times(1)
+				suite.repo.EXPECT().GrantCouponRemainingAmount(gomock.Any(), gomock.Eq(coupon.ID), gomock.Eq(uint64(1)), gomock.Eq(uint64(0)), gomock.Any()).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUserClaims(gomock.Any(), gomock.Len(1)).
+					Return(nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+				soldOut.EXPECT().Mark(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				soldOut.EXPECT().Clear(suite.ctx, gomock.Eq("COUPON_TEST")).
+					Return(nil).
+					Times(1)
+
+				_, err := suite.couponService.Grant(suite.ctx, &request.GrantCoupon{
+					CouponName: "COUPON_TEST",
+					Usernames:  []string{user.Username},
+				})
+
+				return err
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			soldOut = m.NewMockISoldOutMarker(gomock.NewController(t))
+			suite.couponService.(*base).soldOut = soldOut
+
+			// Act
+			err := tc.act()
+
+			// Assert
+			assert.NoError(t, err)
+		})
+	}
+}
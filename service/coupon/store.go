@@ -3,10 +3,13 @@ package coupon
 import (
 	"context"
 	"coupon_be/domain"
+	"coupon_be/domain/enums"
 	"coupon_be/request"
 	"coupon_be/response"
 	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/database"
 	"coupon_be/util/logger"
+	"database/sql"
 	"errors"
 	"regexp"
 	"strings"
@@ -27,6 +30,10 @@ func (b *base) Store(ctx context.Context, input *request.UpsertCoupon) (*respons
 			"Create Failed. Coupon with name '%s' already exists.", input.Name)
 	}
 
+	if input.Shards > 1 {
+		return b.storeSharded(ctx, input)
+	}
+
 	now := time.Now()
 	coupon, err := b.repository.CreateCoupon(ctx, &domain.Coupon{
 		BaseModel: domain.BaseModel{
@@ -36,11 +43,64 @@ func (b *base) Store(ctx context.Context, input *request.UpsertCoupon) (*respons
 		Name:            input.Name,
 		Amount:          input.Amount,
 		RemainingAmount: input.Amount,
+		ClaimStrategy:   enums.ClaimStrategy(input.ClaimStrategy),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the mark of a deleted coupon of the same name is removed
+	b.clearSoldOut(ctx, coupon.Name)
+
+	return response.NewCouponFromDomain(coupon), nil
+}
+
+// storeSharded creates the coupon together with its shards in a single transaction.
+func (b *base) storeSharded(ctx context.Context, input *request.UpsertCoupon) (result *response.Coupon, err error) {
+	if input.ClaimStrategy != "" && enums.ClaimStrategy(input.ClaimStrategy) != enums.ClaimStrategyRedisLock {
+		return nil, sharedErrs.NewBusinessValidationErr(
+			"Create Failed. Sharded coupons are claimed with the %s strategy only.", enums.ClaimStrategyRedisLock)
+	}
+	if uint64(input.Shards) > input.Amount {
+		return nil, sharedErrs.NewBusinessValidationErr(
+			"Create Failed. Coupon with amount %d can not be split into %d shards.", input.Amount, input.Shards)
+	}
+
+	tCtx, tx := database.InitTx(ctx, b.writeDB)
+	defer func() {
+		if err := tx.Rollback().Error; err != nil && !errors.Is(err, sql.ErrTxDone) {
+			logger.Error(ctx, "Repository Error on executing b.Store: ROLLBACK TXN: %v", err)
+		}
+	}()
+
+	now := time.Now()
+	coupon, err := b.repository.CreateCoupon(tCtx, &domain.Coupon{
+		BaseModel: domain.BaseModel{
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		Name:            input.Name,
+		Amount:          input.Amount,
+		RemainingAmount: input.Amount,
+		ClaimStrategy:   enums.ClaimStrategy(input.ClaimStrategy),
+		ShardCount:      input.Shards,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	if _, err = b.repository.CreateCouponShards(tCtx, newCouponShards(coupon, input.Shards)); err != nil {
+		return nil, err
+	}
+
+	if err = database.Commit(tCtx, tx); err != nil {
+		logger.Error(ctx, "Repository Error on executing b.Store: COMMIT TXN: %v", err)
+
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindDatabase, "Failed to create coupon", err)
+	}
+
+	b.clearSoldOut(ctx, coupon.Name)
+
 	return response.NewCouponFromDomain(coupon), nil
 }
 
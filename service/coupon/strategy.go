@@ -0,0 +1,243 @@
+package coupon
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	"coupon_be/request"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
+	"coupon_be/util/logger"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const couponClaimLockKey = "claim:coupon:%s"
+
+// ClaimStrategy protects the stock of a coupon from being over-claimed by concurrent claims.
+type ClaimStrategy interface {
+	Name() enums.ClaimStrategy
+
+	Claim(ctx context.Context, coupon *domain.Coupon, input *request.ClaimCoupon) error
+}
+
+// redisLockStrategy serialises every claim of a coupon with a distributed lock,
+// then runs the read-check-write claim process inside the lock.
+type redisLockStrategy struct {
+	*base
+}
+
+func (s *redisLockStrategy) Name() enums.ClaimStrategy {
+	return enums.ClaimStrategyRedisLock
+}
+
+func (s *redisLockStrategy) Claim(ctx context.Context, coupon *domain.Coupon, input *request.ClaimCoupon) error {
+	err := s.withRedisLock(ctx, fmt.Sprintf(couponClaimLockKey, coupon.Name), func(ctx context.Context, fence int64) error {
+		// reload the coupon, as the stock might have changed while waiting for the lock
+		coupon, err := s.repository.FindCouponByName(ctx, coupon.Name, false)
+		if err != nil {
+			return err
+		}
+
+		logger.Info(ctx, "coupon %s usable amount: %d remaining", coupon.Name, coupon.RemainingAmount)
+		if valid := coupon.IsUsable(); !valid {
+			logger.Warn(ctx, "coupon %s is not usable", coupon.Name)
+			return s.soldOutErr(ctx, coupon)
+		}
+
+		user, err := s.findClaimant(ctx, coupon, input.Username)
+		if err != nil {
+			return err
+		}
+
+		err = s.claimInTx(ctx, coupon, user, func(tCtx context.Context) error {
+			return s.repository.DecrementCouponRemainingAmount(tCtx, coupon.ID, fence)
+		})
+		if err == nil && coupon.RemainingAmount == 1 {
+			// the stock read inside the lock is exact, so this claim took the last one
+			s.markSoldOut(ctx, coupon.Name)
+		}
+
+		return err
+	})
+	if s.fallsBack(ctx, err) {
+		logger.Warn(ctx, "claiming coupon %s using %s strategy while the redis lock is unavailable ...",
+			coupon.Name, enums.ClaimStrategyRowLock)
+
+		return s.strategies[enums.ClaimStrategyRowLock].Claim(ctx, coupon, input)
+	}
+
+	return err
+}
+
+// errLockNotInitialised is returned in place of the redis lock which failed to initialise at startup, it is
+// unavailable in the same way as a lock whose nodes can not be reached.
+var errLockNotInitialised = sharedErrs.New(sharedErrs.ErrKindRedis, "Redis lock is not initialised")
+
+// withRedisLock runs fn inside the redis lock of key, or returns errLockNotInitialised without the lock.
+func (b *base) withRedisLock(ctx context.Context, key string, fn func(ctx context.Context, fence int64) error) error {
+	if b.redisLock == nil {
+		return errLockNotInitialised
+	}
+
+	return b.redisLock.WithLock(ctx, key, fn)
+}
+
+// withClaimLock runs fn inside the redis lock of key, or without the lock and its fencing token when the lock is
+// unavailable and the claims fall back. fn takes the row locks of the coupons it writes with FOR UPDATE then.
+func (b *base) withClaimLock(ctx context.Context, key string, fn func(ctx context.Context, fence int64) error) error {
+	err := b.withRedisLock(ctx, key, fn)
+	if b.fallsBack(ctx, err) {
+		logger.Warn(ctx, "running %s without the unavailable redis lock ...", key)
+		return fn(ctx, 0)
+	}
+
+	return err
+}
+
+// fallsBack reports whether a claim failed on an unavailable redis lock is to be made without the lock. The fencing
+// tokens are not needed then, as the unfenced conditional decrements take the row lock of the stock.
+func (b *base) fallsBack(ctx context.Context, err error) bool {
+	if !errors.Is(err, redis.ErrLockUnavailable) && !errors.Is(err, errLockNotInitialised) {
+		return false
+	}
+
+	if !b.lockFallback {
+		logger.Error(ctx, "redis lock is unavailable, failing the claim")
+		return false
+	}
+
+	return true
+}
+
+// rowLockStrategy relies on postgres only. The conditional decrement takes the row lock of the coupon until the
+// transaction ends, so concurrent claims wait for each other and never consume stock that does not exist.
+type rowLockStrategy struct {
+	*base
+}
+
+func (s *rowLockStrategy) Name() enums.ClaimStrategy {
+	return enums.ClaimStrategyRowLock
+}
+
+func (s *rowLockStrategy) Claim(ctx context.Context, coupon *domain.Coupon, input *request.ClaimCoupon) error {
+	// fail fast on a sold-out coupon, the conditional decrement below is the authoritative check
+	if valid := coupon.IsUsable(); !valid {
+		logger.Warn(ctx, "coupon %s is not usable", coupon.Name)
+		return s.soldOutErr(ctx, coupon)
+	}
+
+	user, err := s.findClaimant(ctx, coupon, input.Username)
+	if err != nil {
+		return err
+	}
+
+	return s.claimInTx(ctx, coupon, user, func(tCtx context.Context) error {
+		err := s.repository.DecrementCouponRemainingAmount(tCtx, coupon.ID, 0)
+		if errors.Is(err, sharedErrs.NotFoundErr) {
+			logger.Warn(ctx, "coupon %s is sold out while claiming", coupon.Name)
+			return s.soldOutErr(ctx, coupon)
+		}
+
+		return err
+	})
+}
+
+// optimisticStrategy decrements the stock only if the coupon version is unchanged since it was read.
+// On a version conflict, the coupon is reloaded and the claim is retried up to maxRetries times.
+type optimisticStrategy struct {
+	*base
+	maxRetries int
+}
+
+func (s *optimisticStrategy) Name() enums.ClaimStrategy {
+	return enums.ClaimStrategyOptimistic
+}
+
+func (s *optimisticStrategy) Claim(ctx context.Context, coupon *domain.Coupon, input *request.ClaimCoupon) error {
+	user, err := s.findClaimant(ctx, coupon, input.Username)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; attempt <= s.maxRetries; attempt++ {
+		if valid := coupon.IsUsable(); !valid {
+			logger.Warn(ctx, "coupon %s is not usable", coupon.Name)
+			return s.soldOutErr(ctx, coupon)
+		}
+
+		err = s.claimInTx(ctx, coupon, user, func(tCtx context.Context) error {
+			return s.repository.DecrementCouponRemainingAmountByVersion(tCtx, coupon.ID, coupon.Version)
+		})
+		if err == nil && coupon.RemainingAmount == 1 {
+			// the version is unchanged since the stock is read, so this claim took the last one
+			s.markSoldOut(ctx, coupon.Name)
+		}
+		if !errors.Is(err, sharedErrs.NotFoundErr) {
+			return err
+		}
+
+		logger.Warn(ctx, "coupon %s version %d is outdated on attempt %d, reloading ...", coupon.Name, coupon.Version, attempt)
+		if coupon, err = s.repository.FindCouponByID(ctx, coupon.ID); err != nil {
+			return err
+		}
+	}
+
+	return sharedErrs.New(sharedErrs.ErrKindConflict,
+		"Coupon %s is being claimed by too many users, please try again", coupon.Name)
+}
+
+// redisAtomicStrategy takes the stock from an atomic redis counter without any lock. The claim is recorded in redis
+// only, and persisted to postgres later by the claim writer.
+type redisAtomicStrategy struct {
+	*base
+}
+
+func (s *redisAtomicStrategy) Name() enums.ClaimStrategy {
+	return enums.ClaimStrategyRedisAtomic
+}
+
+func (s *redisAtomicStrategy) Claim(ctx context.Context, coupon *domain.Coupon, input *request.ClaimCoupon) error {
+	if s.stock == nil {
+		return sharedErrs.New(sharedErrs.ErrKindRedis, "Redis stock counter is not initialised")
+	}
+
+	user, err := s.repository.FindUserByUsername(ctx, input.Username)
+	if err != nil {
+		return err
+	}
+
+	key := couponStockKey(coupon.ID)
+	member := strconv.FormatUint(user.ID, 10)
+
+	result, err := s.stock.Take(ctx, key, member)
+	if err != nil {
+		return err
+	}
+
+	if result == redis.TakeNotLoaded {
+		logger.Info(ctx, "stock of coupon %s is not loaded, rebuilding ...", coupon.Name)
+		if err = s.rebuildStock(ctx, coupon); err != nil {
+			return err
+		}
+
+		if result, err = s.stock.Take(ctx, key, member); err != nil {
+			return err
+		}
+	}
+
+	switch result {
+	case redis.TakeSuccess:
+		logger.Info(ctx, "coupon %s is claimed by user id %d, waiting to be persisted", coupon.Name, user.ID)
+		return nil
+	case redis.TakeDuplicate:
+		logger.Warn(ctx, "coupon %s is already claimed by user id %d", coupon.Name, user.ID)
+		return alreadyClaimedErr(coupon, user)
+	case redis.TakeSoldOut:
+		logger.Warn(ctx, "coupon %s is not usable", coupon.Name)
+		return s.soldOutErr(ctx, coupon)
+	default:
+		return sharedErrs.New(sharedErrs.ErrKindRedis, "Stock of coupon %s is not loaded", coupon.Name)
+	}
+}
@@ -0,0 +1,134 @@
+package coupon
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	"coupon_be/request"
+	"coupon_be/response"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/database"
+	"coupon_be/util"
+	"coupon_be/util/logger"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Transfer moves the claim of the coupon from its holder to the recipient. The claim row itself is moved, so the
+// stock of the coupon is untouched, and the recipient's unique claim of the coupon is enforced by the user_claims
+// constraint. Redeemed claims, and claims transferred maxTransfers times already, can not be transferred.
+func (b *base) Transfer(ctx context.Context, input *request.TransferCoupon) (*response.CouponTransfer, error) {
+	logger.Info(ctx, "Transfer Coupon with req: %v", input)
+
+	coupon, err := b.repository.FindCouponByName(ctx, util.SanitizeString(input.CouponName), false)
+	if err != nil {
+		return nil, err
+	}
+
+	// the claimants of redis_atomic coupons are kept in redis, which a moved row would disagree with
+	if b.claimStrategy(coupon).Name() == enums.ClaimStrategyRedisAtomic {
+		return nil, sharedErrs.NewBusinessValidationErr("Coupon %s can not be transferred", coupon.Name)
+	}
+
+	sender, err := b.repository.FindUserByUsername(ctx, input.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := b.repository.FindUserByUsername(ctx, input.RecipientUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	claim, err := b.repository.FindUserClaimByUserIDAndCouponID(ctx, sender.ID, coupon.ID)
+	if errors.Is(err, sharedErrs.NotFoundErr) {
+		return nil, sharedErrs.NewBusinessValidationErr("Coupon %s is not claimed by user %s", coupon.Name, sender.Username)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = b.checkTransferable(coupon, claim); err != nil {
+		return nil, err
+	}
+
+	recipientClaim, err := b.repository.FindUserClaimByUserIDAndCouponID(ctx, recipient.ID, coupon.ID)
+	if err != nil && !errors.Is(err, sharedErrs.NotFoundErr) {
+		return nil, err
+	}
+	if recipientClaim != nil {
+		return nil, alreadyClaimedErr(coupon, recipient)
+	}
+
+	if err = b.transferInTx(ctx, coupon, claim, sender, recipient); err != nil {
+		return nil, err
+	}
+
+	logger.Info(ctx, "coupon %s is transferred from user %s to user %s", coupon.Name, sender.Username, recipient.Username)
+
+	return &response.CouponTransfer{
+		CouponName:    coupon.Name,
+		FromUsername:  sender.Username,
+		ToUsername:    recipient.Username,
+		TransferCount: claim.TransferCount + 1,
+		TransferredAt: time.Now(),
+	}, nil
+}
+
+func (b *base) checkTransferable(coupon *domain.Coupon, claim *domain.UserClaim) error {
+	if claim.IsRedeemed() {
+		return sharedErrs.NewBusinessValidationErr("Coupon %s is redeemed already and can not be transferred", coupon.Name)
+	}
+
+	if claim.TransferCount >= b.maxTransfers {
+		return sharedErrs.NewBusinessValidationErr("Coupon %s can not be transferred more than %d times", coupon.Name, b.maxTransfers)
+	}
+
+	return nil
+}
+
+// transferInTx moves the user claim and records the transfer in a single transaction.
+func (b *base) transferInTx(ctx context.Context, coupon *domain.Coupon, claim *domain.UserClaim, sender, recipient *domain.User) (err error) {
+	tCtx, tx := database.InitTx(ctx, b.writeDB)
+	defer func() {
+		if err := tx.Rollback().Error; err != nil && !errors.Is(err, sql.ErrTxDone) {
+			logger.Error(ctx, "Repository Error on executing b.Transfer: ROLLBACK TXN: %v", err)
+		}
+	}()
+
+	err = b.repository.TransferUserClaim(tCtx, claim.ID, sender.ID, recipient.ID, b.maxTransfers)
+	if errors.Is(err, sharedErrs.NotFoundErr) {
+		// the claim has been transferred or redeemed in the meantime
+		return sharedErrs.NewBusinessValidationErr("Coupon %s can not be transferred by user %s anymore", coupon.Name, sender.Username)
+	}
+	if errors.Is(err, sharedErrs.ConflictErr) {
+		return alreadyClaimedErr(coupon, recipient)
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = b.repository.CreateCouponTransfer(tCtx, &domain.CouponTransfer{
+		BaseModel: domain.BaseModel{
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		UserClaimID: claim.ID,
+		CouponID:    coupon.ID,
+		FromUserID:  sender.ID,
+		ToUserID:    recipient.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = database.Commit(tCtx, tx); err != nil {
+		logger.Error(ctx, "Repository Error on executing b.Transfer: COMMIT TXN: %v", err)
+
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindDatabase, "Failed to transfer coupon", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package coupon
+
+import (
+	"coupon_be/domain"
+	m "coupon_be/mock"
+	"coupon_be/request"
+	sharedErrs "coupon_be/shared/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func (suite *CouponServiceTestSuite) Test_Transfer() {
+	coupon := m.InitCouponDomain()
+	sender := m.InitUserDomain()
+	recipient := m.InitUserDomain()
+	recipient.ID, recipient.Username = 2, "friend"
+
+	input := &request.TransferCoupon{
+		Username:          sender.Username,
+		RecipientUsername: recipient.Username,
+		CouponName:        coupon.Name,
+	}
+
+	var claim *domain.UserClaim
+
+	expectClaim := func() {
+		suite.repo.EXPECT().FindCouponByName(suite.ctx, gomock.Eq(coupon.Name), gomock.Eq(false)).
+			Return(coupon, nil).
+			Times(1)
+		suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(sender.Username)).
+			Return(sender, nil).
+			Times(1)
+		suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(recipient.Username)).
+			Return(recipient, nil).
+			Times(1)
+		suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(sender.ID), gomock.Eq(coupon.ID)).
+			Return(claim, nil).
+			Times(1)
+	}
+
+	testCases := []struct {
+		name        string
+		prepareMock func()
+		wantErr     bool
+	}{
+		{
+			name: "success",
+			prepareMock: func() {
+				claim = m.InitUserClaimDomain()
+
+				expectClaim()
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(recipient.ID), gomock.Eq(coupon.ID)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().TransferUserClaim(gomock.Any(), gomock.Eq(claim.ID), gomock.Eq(sender.ID), gomock.Eq(recipient.ID), gomock.Eq(defaultMaxTransfers)).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateCouponTransfer(gomock.Any(), gomock.Any()).
+					Return(&domain.CouponTransfer{}, nil).
+					Times(1)
+				suite.sqlMock.ExpectCommit()
+			},
+		},
+		{
+			name: "redeemed claim",
+			prepareMock: func() {
+				redeemedAt := time.Now()
+				claim = m.InitUserClaimDomain()
+				claim.RedeemedAt = &redeemedAt
+
+				expectClaim()
+				suite.repo.EXPECT().TransferUserClaim(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr: true,
+		},
+		{
+			name: "transfer limit reached",
+			prepareMock: func() {
+				claim = m.InitUserClaimDomain()
+				claim.TransferCount = defaultMaxTransfers
+
+				expectClaim()
+				suite.repo.EXPECT().TransferUserClaim(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr: true,
+		},
+		{
+			name: "recipient already holds the coupon",
+			prepareMock: func() {
+				claim = m.InitUserClaimDomain()
+
+				expectClaim()
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(recipient.ID), gomock.Eq(coupon.ID)).
+					Return(m.InitUserClaimDomain(), nil).
+					Times(1)
+				suite.repo.EXPECT().TransferUserClaim(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			wantErr: true,
+		},
+		{
+			name: "claim transferred in the meantime",
+			prepareMock: func() {
+				claim = m.InitUserClaimDomain()
+
+				expectClaim()
+				suite.repo.EXPECT().FindUserClaimByUserIDAndCouponID(suite.ctx, gomock.Eq(recipient.ID), gomock.Eq(coupon.ID)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.sqlMock.ExpectBegin()
+				suite.repo.EXPECT().TransferUserClaim(gomock.Any(), gomock.Eq(claim.ID), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(sharedErrs.NotFoundErr).
+					Times(1)
+				suite.repo.EXPECT().CreateCouponTransfer(gomock.Any(), gomock.Any()).Times(0)
+				suite.sqlMock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			tc.prepareMock()
+
+			// Act
+			result, err := suite.couponService.Transfer(suite.ctx, input)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.Empty(t, result)
+			} else {
+				assert.Equal(t, recipient.Username, result.ToUsername)
+				assert.Equal(t, 1, result.TransferCount)
+			}
+			assert.NoError(t, suite.sqlMock.ExpectationsWereMet())
+		})
+	}
+}
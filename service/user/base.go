@@ -3,22 +3,51 @@ package user
 import (
 	"context"
 	"coupon_be/repository"
+	"coupon_be/request"
+	"coupon_be/response"
+	"coupon_be/shared/auth"
 
 	"gorm.io/gorm"
 )
 
 type Service interface {
-	Register(ctx context.Context) error
+	// Register creates a user with the given credentials.
+	Register(ctx context.Context, input *request.Register) (*response.User, error)
+
+	// Login verifies the credentials of the user, and issues its access and refresh tokens.
+	Login(ctx context.Context, input *request.Login) (*response.Login, error)
+
+	// Refresh exchanges a valid refresh token for a new access and refresh token, carrying the current role of the user.
+	Refresh(ctx context.Context, input *request.Refresh) (*response.Login, error)
+
+	// AssignRole sets the role of the user, which applies to the tokens issued on its next login or refresh.
+	AssignRole(ctx context.Context, input *request.AssignRole) (*response.User, error)
+
+	// BootstrapAdmin makes the given user the first admin when there is no admin yet, creating the user when it does
+	// not exist.
+	BootstrapAdmin(ctx context.Context, input *request.Register) error
 }
 
+// minAdminPasswordLength is the minimum length of the password of a bootstrapped admin, as of a registered user.
+const minAdminPasswordLength = 8
+
 type base struct {
 	repository repository.Repository
 	writeDB    *gorm.DB
+	tokens     auth.ITokenManager
 }
 
-func NewService(repository repository.Repository, writerDB *gorm.DB) (Service, error) {
-	return &base{
+func NewService(repository repository.Repository, writerDB *gorm.DB, options ...Option) (Service, error) {
+	b := &base{
 		repository: repository,
 		writeDB:    writerDB,
-	}, nil
+	}
+
+	for _, option := range options {
+		if err := option(b); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
 }
@@ -0,0 +1,80 @@
+package user
+
+import (
+	"context"
+	m "coupon_be/mock"
+	"coupon_be/util/logger"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestNewService_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repoMock := m.NewMockRepository(ctrl)
+	tokensMock := m.NewMockITokenManager(ctrl)
+	writeDB, _, err := m.NewMockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userService, err := NewService(repoMock, writeDB, WithTokenManager(tokensMock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotNil(t, userService)
+	assert.Implements(t, (*Service)(nil), userService)
+}
+
+func TestNewService_NilTokenManager(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, err := NewService(m.NewMockRepository(ctrl), nil, WithTokenManager(nil))
+
+	assert.Error(t, err)
+}
+
+type UserServiceTestSuite struct {
+	suite.Suite
+	repo    *m.MockRepository
+	tokens  *m.MockITokenManager
+	writeDB *gorm.DB
+	ctx     context.Context
+
+	userService Service
+}
+
+func (suite *UserServiceTestSuite) Before(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var err error
+
+	logger.Initialise()
+
+	suite.ctx = context.Background()
+	suite.repo = m.NewMockRepository(ctrl)
+	suite.tokens = m.NewMockITokenManager(ctrl)
+	suite.writeDB, _, err = m.NewMockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suite.userService, err = NewService(suite.repo, suite.writeDB, WithTokenManager(suite.tokens))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (suite *UserServiceTestSuite) After(t *testing.T) {}
+
+func TestSuiteRunUserService(t *testing.T) {
+	suite.Run(t, new(UserServiceTestSuite))
+}
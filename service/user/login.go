@@ -0,0 +1,89 @@
+package user
+
+import (
+	"context"
+	"coupon_be/request"
+	"coupon_be/response"
+	"coupon_be/shared/auth"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/logger"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const tokenTypeBearer = "Bearer"
+
+func (b *base) Login(ctx context.Context, input *request.Login) (*response.Login, error) {
+	if b.tokens == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindCodeInjection, "Token manager is nil")
+	}
+
+	user, err := b.repository.FindUserByUsername(ctx, input.Username)
+	if errors.Is(err, sharedErrs.NotFoundErr) {
+		logger.Warn(ctx, "Login failed, user %s is not found", input.Username)
+		return nil, sharedErrs.IncorrectCredentialErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		logger.Warn(ctx, "Login failed, incorrect password of user %s", input.Username)
+		return nil, sharedErrs.IncorrectCredentialErr
+	}
+
+	tokens, err := b.tokens.Issue(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(ctx, "User %s logged in", user.Username)
+
+	return loginResponse(tokens), nil
+}
+
+func (b *base) Refresh(ctx context.Context, input *request.Refresh) (*response.Login, error) {
+	if b.tokens == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindCodeInjection, "Token manager is nil")
+	}
+
+	claims, err := b.tokens.Parse(ctx, input.RefreshToken, auth.TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, sharedErrs.InvalidTokenErr
+	}
+
+	// the tokens carry the current role of the user, rather than the role of the refresh token
+	user, err := b.repository.FindUserByID(ctx, userID)
+	if errors.Is(err, sharedErrs.NotFoundErr) {
+		logger.Warn(ctx, "Refresh failed, user %d of the refresh token is not found", userID)
+		return nil, sharedErrs.InvalidTokenErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := b.tokens.Issue(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(ctx, "User %s refreshed its tokens", user.Username)
+
+	return loginResponse(tokens), nil
+}
+
+func loginResponse(tokens *auth.TokenPair) *response.Login {
+	return &response.Login{
+		TokenType:             tokenTypeBearer,
+		AccessToken:           tokens.AccessToken,
+		AccessTokenExpiresAt:  tokens.AccessTokenExpiresAt,
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt,
+	}
+}
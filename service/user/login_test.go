@@ -0,0 +1,212 @@
+package user
+
+import (
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	m "coupon_be/mock"
+	"coupon_be/request"
+	"coupon_be/shared/auth"
+	sharedErrs "coupon_be/shared/errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (suite *UserServiceTestSuite) Test_Login() {
+	user := m.InitUserDomain()
+	password, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	user.Password = string(password)
+
+	tokens := &auth.TokenPair{
+		AccessToken:           "access",
+		AccessTokenExpiresAt:  time.Now().Add(15 * time.Minute),
+		RefreshToken:          "refresh",
+		RefreshTokenExpiresAt: time.Now().Add(24 * time.Hour),
+	}
+
+	testCases := []struct {
+		name          string
+		input         *request.Login
+		prepareMock   func()
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name:  "success",
+			input: &request.Login{Username: user.Username, Password: "password123"},
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(user.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.tokens.EXPECT().Issue(suite.ctx, gomock.Eq(user)).
+					Return(tokens, nil).
+					Times(1)
+			},
+		},
+		{
+			name:  "incorrect password",
+			input: &request.Login{Username: user.Username, Password: "password124"},
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(user.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.tokens.EXPECT().Issue(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			wantErr:       true,
+			expectedError: sharedErrs.IncorrectCredentialErr,
+		},
+		{
+			name:  "unknown user",
+			input: &request.Login{Username: "unknown", Password: "password123"},
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq("unknown")).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.tokens.EXPECT().Issue(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			wantErr:       true,
+			expectedError: sharedErrs.IncorrectCredentialErr,
+		},
+		{
+			name:  "unexpected error",
+			input: &request.Login{Username: user.Username, Password: "password123"},
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(user.Username)).
+					Return(nil, sharedErrs.InternalServerErr).
+					Times(1)
+			},
+			wantErr:       true,
+			expectedError: sharedErrs.InternalServerErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			tc.prepareMock()
+
+			// Act
+			result, err := suite.userService.Login(suite.ctx, tc.input)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.Empty(t, result)
+				assert.Equal(t, tc.expectedError, err)
+			} else {
+				assert.Equal(t, "Bearer", result.TokenType)
+				assert.Equal(t, tokens.AccessToken, result.AccessToken)
+				assert.Equal(t, tokens.RefreshToken, result.RefreshToken)
+			}
+		})
+	}
+}
+
+func (suite *UserServiceTestSuite) Test_Refresh() {
+	user := m.InitUserDomain()
+	user.Role = enums.RoleOperator
+	input := &request.Refresh{RefreshToken: "refresh"}
+
+	// the refresh token is issued before the user is made an operator
+	claims := &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: strconv.FormatUint(user.ID, 10)},
+		Username:         user.Username,
+		Role:             enums.RoleCustomer,
+		Type:             auth.TokenTypeRefresh,
+	}
+
+	tokens := &auth.TokenPair{
+		AccessToken:           "access-2",
+		AccessTokenExpiresAt:  time.Now().Add(15 * time.Minute),
+		RefreshToken:          "refresh-2",
+		RefreshTokenExpiresAt: time.Now().Add(24 * time.Hour),
+	}
+
+	testCases := []struct {
+		name          string
+		prepareMock   func()
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name: "success",
+			prepareMock: func() {
+				suite.tokens.EXPECT().Parse(suite.ctx, gomock.Eq(input.RefreshToken), gomock.Eq(auth.TokenTypeRefresh)).
+					Return(claims, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByID(suite.ctx, gomock.Eq(user.ID)).
+					Return(user, nil).
+					Times(1)
+				suite.tokens.EXPECT().Issue(suite.ctx, gomock.Any()).
+					DoAndReturn(func(_ any, issued *domain.User) (*auth.TokenPair, error) {
+						// with the current role of the user
+						assert.Equal(suite.T(), enums.RoleOperator, issued.Role)
+						return tokens, nil
+					}).
+					Times(1)
+			},
+		},
+		{
+			name: "expired refresh token",
+			prepareMock: func() {
+				suite.tokens.EXPECT().Parse(suite.ctx, gomock.Eq(input.RefreshToken), gomock.Eq(auth.TokenTypeRefresh)).
+					Return(nil, sharedErrs.ExpiredTokenErr).
+					Times(1)
+				suite.tokens.EXPECT().Issue(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			wantErr:       true,
+			expectedError: sharedErrs.ExpiredTokenErr,
+		},
+		{
+			name: "user is gone",
+			prepareMock: func() {
+				suite.tokens.EXPECT().Parse(suite.ctx, gomock.Eq(input.RefreshToken), gomock.Eq(auth.TokenTypeRefresh)).
+					Return(claims, nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByID(suite.ctx, gomock.Eq(user.ID)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.tokens.EXPECT().Issue(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			wantErr:       true,
+			expectedError: sharedErrs.InvalidTokenErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			tc.prepareMock()
+
+			// Act
+			result, err := suite.userService.Refresh(suite.ctx, input)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.Empty(t, result)
+				assert.Equal(t, tc.expectedError, err)
+			} else {
+				assert.Equal(t, "Bearer", result.TokenType)
+				assert.Equal(t, tokens.AccessToken, result.AccessToken)
+				assert.Equal(t, tokens.RefreshToken, result.RefreshToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package user
+
+import (
+	"coupon_be/shared/auth"
+	sharedErrs "coupon_be/shared/errors"
+)
+
+type Option func(*base) error
+
+// WithTokenManager sets the token manager the tokens are issued with on login.
+func WithTokenManager(tokens auth.ITokenManager) Option {
+	return func(b *base) error {
+		if tokens == nil {
+			return sharedErrs.New(sharedErrs.ErrKindCodeInjection, "Token manager is nil")
+		}
+
+		b.tokens = tokens
+
+		return nil
+	}
+}
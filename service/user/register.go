@@ -3,34 +3,64 @@ package user
 import (
 	"context"
 	"coupon_be/domain"
-	"fmt"
+	"coupon_be/domain/enums"
+	"coupon_be/request"
+	"coupon_be/response"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/logger"
+	"errors"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-func (b *base) Register(ctx context.Context) error {
-	now := time.Now()
+func (b *base) Register(ctx context.Context, input *request.Register) (*response.User, error) {
+	logger.Info(ctx, "Register user with username: %s", input.Username)
+
+	userExists, err := b.repository.FindUserByUsername(ctx, input.Username)
+	if err != nil && !errors.Is(err, sharedErrs.NotFoundErr) {
+		return nil, err
+	}
+	if userExists != nil {
+		return nil, sharedErrs.NewBusinessValidationErr(
+			"Register Failed. Username '%s' is already taken.", input.Username)
+	}
 
-	for i := 0; i <= 100; i++ {
-		password, err := bcrypt.GenerateFromPassword([]byte("user123"), bcrypt.DefaultCost)
-		if err != nil {
-			return err
-		}
-
-		_, err = b.repository.CreateUser(ctx, &domain.User{
-			BaseModel: domain.BaseModel{
-				CreatedAt: now,
-				UpdatedAt: now,
-			},
-			Username: fmt.Sprintf("user_%d", i),
-			FullName: fmt.Sprintf("User %d", i),
-			Password: string(password),
-		})
-		if err != nil {
-			return err
-		}
+	user, err := b.createUser(ctx, input, enums.RoleCustomer)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return userResponse(user), nil
+}
+
+// createUser stores the user of the role with its password hashed.
+func (b *base) createUser(ctx context.Context, input *request.Register, role enums.Role) (*domain.User, error) {
+	password, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplication, "failed to hash password", err)
+	}
+
+	now := time.Now()
+
+	return b.repository.CreateUser(ctx, &domain.User{
+		BaseModel: domain.BaseModel{
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		Username: input.Username,
+		FullName: input.FullName,
+		Password: string(password),
+		Role:     role,
+	})
+}
+
+func userResponse(user *domain.User) *response.User {
+	return &response.User{
+		ID:        user.ID,
+		Username:  user.Username,
+		FullName:  user.FullName,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+	}
 }
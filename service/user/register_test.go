@@ -0,0 +1,95 @@
+package user
+
+import (
+	"coupon_be/domain"
+	m "coupon_be/mock"
+	"coupon_be/request"
+	sharedErrs "coupon_be/shared/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (suite *UserServiceTestSuite) Test_Register() {
+	user := m.InitUserDomain()
+	input := &request.Register{
+		Username: "username",
+		FullName: "full_name",
+		Password: "password123",
+	}
+
+	testCases := []struct {
+		name          string
+		prepareMock   func()
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name: "success",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.repo.EXPECT().CreateUser(suite.ctx, gomock.Any()).
+					DoAndReturn(func(_ any, data *domain.User) (*domain.User, error) {
+						// the password is stored as its bcrypt hash
+						assert.NoError(suite.T(), bcrypt.CompareHashAndPassword([]byte(data.Password), []byte(input.Password)))
+						assert.Equal(suite.T(), input.FullName, data.FullName)
+
+						return user, nil
+					}).
+					Times(1)
+			},
+		},
+		{
+			name: "username is taken",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(user, nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUser(suite.ctx, gomock.Any()).
+					Times(0)
+			},
+			wantErr: true,
+			expectedError: sharedErrs.NewBusinessValidationErr(
+				"Register Failed. Username '%s' is already taken.", input.Username),
+		},
+		{
+			name: "unexpected error",
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(input.Username)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.repo.EXPECT().CreateUser(suite.ctx, gomock.Any()).
+					Return(nil, sharedErrs.InternalServerErr).
+					Times(1)
+			},
+			wantErr:       true,
+			expectedError: sharedErrs.InternalServerErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			tc.prepareMock()
+
+			// Act
+			result, err := suite.userService.Register(suite.ctx, input)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.Empty(t, result)
+				assert.Equal(t, tc.expectedError, err)
+			} else {
+				assert.Equal(t, user.ID, result.ID)
+				assert.Equal(t, user.Username, result.Username)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package user
+
+import (
+	"context"
+	"coupon_be/domain/enums"
+	"coupon_be/request"
+	"coupon_be/response"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (b *base) AssignRole(ctx context.Context, input *request.AssignRole) (*response.User, error) {
+	user, err := b.repository.FindUserByUsername(ctx, input.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	// keeps an admin from locking out every admin, including themselves
+	if user.ID == constant.UserIDFromCtx(ctx) {
+		return nil, sharedErrs.NewBusinessValidationErr("Assign Role Failed. Users can not change their own role.")
+	}
+
+	if user.Role == input.Role {
+		return userResponse(user), nil
+	}
+
+	if err = b.repository.UpdateUserRole(ctx, user.ID, input.Role); err != nil {
+		return nil, err
+	}
+
+	logger.Warn(ctx, "AUDIT role of user %s is changed from %s to %s by user %s",
+		user.Username, user.Role, input.Role, constant.UsernameFromCtx(ctx))
+
+	user.Role = input.Role
+
+	return userResponse(user), nil
+}
+
+func (b *base) BootstrapAdmin(ctx context.Context, input *request.Register) error {
+	admins, err := b.repository.FindUserCountByRole(ctx, enums.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if admins > 0 {
+		return nil
+	}
+
+	if input.Username == "" {
+		logger.Warn(ctx, "There is no admin, and no admin to bootstrap is configured")
+		return nil
+	}
+
+	user, err := b.repository.FindUserByUsername(ctx, input.Username)
+	if err != nil && !errors.Is(err, sharedErrs.NotFoundErr) {
+		return err
+	}
+
+	if user != nil {
+		// anyone may have registered the username first, so only its owner knowing the password is promoted
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)) != nil {
+			return sharedErrs.New(sharedErrs.ErrKindApplicationPermanent,
+				"User %s already exists with another password than the configured admin password", user.Username)
+		}
+
+		if err = b.repository.UpdateUserRole(ctx, user.ID, enums.RoleAdmin); err != nil {
+			return err
+		}
+
+		logger.Warn(ctx, "AUDIT existing user %s is bootstrapped as the first admin", user.Username)
+
+		return nil
+	}
+
+	if len(input.Password) < minAdminPasswordLength {
+		return sharedErrs.New(sharedErrs.ErrKindApplicationPermanent,
+			"Password of the admin %s must be at least %d characters", input.Username, minAdminPasswordLength)
+	}
+
+	if _, err = b.createUser(ctx, input, enums.RoleAdmin); err != nil {
+		return err
+	}
+
+	logger.Warn(ctx, "AUDIT user %s is created as the first admin", input.Username)
+
+	return nil
+}
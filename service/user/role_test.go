@@ -0,0 +1,237 @@
+package user
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	m "coupon_be/mock"
+	"coupon_be/request"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/constant"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (suite *UserServiceTestSuite) Test_AssignRole() {
+	user := m.InitUserDomain()
+	input := &request.AssignRole{Username: user.Username, Role: enums.RoleOperator}
+
+	testCases := []struct {
+		name          string
+		adminID       uint64
+		prepareMock   func()
+		wantErr       bool
+		expectedRole  enums.Role
+		expectedError error
+	}{
+		{
+			name:    "success",
+			adminID: 2,
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Return(m.InitUserDomain(), nil).
+					Times(1)
+				suite.repo.EXPECT().UpdateUserRole(gomock.Any(), gomock.Eq(user.ID), gomock.Eq(enums.RoleOperator)).
+					Return(nil).
+					Times(1)
+			},
+			expectedRole: enums.RoleOperator,
+		},
+		{
+			name:    "role is unchanged",
+			adminID: 2,
+			prepareMock: func() {
+				operator := m.InitUserDomain()
+				operator.Role = enums.RoleOperator
+				suite.repo.EXPECT().FindUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Return(operator, nil).
+					Times(1)
+				suite.repo.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			expectedRole: enums.RoleOperator,
+		},
+		{
+			name:    "own role",
+			adminID: user.ID,
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Return(m.InitUserDomain(), nil).
+					Times(1)
+				suite.repo.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			wantErr:       true,
+			expectedError: sharedErrs.NewBusinessValidationErr("Assign Role Failed. Users can not change their own role."),
+		},
+		{
+			name:    "user not found",
+			adminID: 2,
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+			},
+			wantErr:       true,
+			expectedError: sharedErrs.NotFoundErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			tc.prepareMock()
+			ctx := context.WithValue(suite.ctx, constant.XUserIDKey, tc.adminID)
+
+			// Act
+			result, err := suite.userService.AssignRole(ctx, input)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.Empty(t, result)
+				assert.Equal(t, tc.expectedError, err)
+			} else {
+				assert.Equal(t, tc.expectedRole, result.Role)
+			}
+		})
+	}
+}
+
+func (suite *UserServiceTestSuite) Test_BootstrapAdmin() {
+	user := m.InitUserDomain()
+	userWithPassword := func(password string) *domain.User {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		assert.NoError(suite.T(), err)
+
+		existing := m.InitUserDomain()
+		existing.Password = string(hash)
+
+		return existing
+	}
+	input := &request.Register{Username: user.Username, FullName: "Admin", Password: "admin1234"}
+
+	testCases := []struct {
+		name          string
+		input         *request.Register
+		prepareMock   func()
+		wantErr       bool
+		expectedError error
+	}{
+		{
+			name:  "admin exists",
+			input: input,
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserCountByRole(suite.ctx, gomock.Eq(enums.RoleAdmin)).
+					Return(int64(1), nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+		{
+			name:  "no admin is configured",
+			input: &request.Register{},
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserCountByRole(suite.ctx, gomock.Eq(enums.RoleAdmin)).
+					Return(int64(0), nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+		{
+			name:  "existing user with the configured password is promoted",
+			input: input,
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserCountByRole(suite.ctx, gomock.Eq(enums.RoleAdmin)).
+					Return(int64(0), nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(user.Username)).
+					Return(userWithPassword(input.Password), nil).
+					Times(1)
+				suite.repo.EXPECT().UpdateUserRole(suite.ctx, gomock.Eq(user.ID), gomock.Eq(enums.RoleAdmin)).
+					Return(nil).
+					Times(1)
+				suite.repo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+		{
+			name:  "existing user with another password is refused",
+			input: input,
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserCountByRole(suite.ctx, gomock.Eq(enums.RoleAdmin)).
+					Return(int64(0), nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(user.Username)).
+					Return(userWithPassword("registered first"), nil).
+					Times(1)
+				suite.repo.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			wantErr: true,
+			expectedError: sharedErrs.New(sharedErrs.ErrKindApplicationPermanent,
+				"User %s already exists with another password than the configured admin password", user.Username),
+		},
+		{
+			name:  "admin is created",
+			input: input,
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserCountByRole(suite.ctx, gomock.Eq(enums.RoleAdmin)).
+					Return(int64(0), nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(user.Username)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.repo.EXPECT().CreateUser(suite.ctx, gomock.Any()).
+					DoAndReturn(func(_ any, data *domain.User) (*domain.User, error) {
+						assert.Equal(suite.T(), enums.RoleAdmin, data.Role)
+
+						return data, nil
+					}).
+					Times(1)
+			},
+		},
+		{
+			name:  "password is too short",
+			input: &request.Register{Username: user.Username, Password: "admin"},
+			prepareMock: func() {
+				suite.repo.EXPECT().FindUserCountByRole(suite.ctx, gomock.Eq(enums.RoleAdmin)).
+					Return(int64(0), nil).
+					Times(1)
+				suite.repo.EXPECT().FindUserByUsername(suite.ctx, gomock.Eq(user.Username)).
+					Return(nil, sharedErrs.NotFoundErr).
+					Times(1)
+				suite.repo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			wantErr: true,
+			expectedError: sharedErrs.New(sharedErrs.ErrKindApplicationPermanent,
+				"Password of the admin %s must be at least %d characters", user.Username, minAdminPasswordLength),
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			// Arrange
+			suite.Before(t)
+			defer suite.After(t)
+			tc.prepareMock()
+
+			// Act
+			err := suite.userService.BootstrapAdmin(suite.ctx, tc.input)
+
+			// Assert
+			assert.Equal(t, tc.wantErr, err != nil, "error expected %v, but actual: %v", tc.wantErr, err)
+			if tc.wantErr {
+				assert.Equal(t, tc.expectedError, err)
+			}
+		})
+	}
+}
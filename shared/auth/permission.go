@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"coupon_be/domain/enums"
+	"coupon_be/util/constant"
+)
+
+// Permission is what a route requires of the role of the authenticated user.
+type Permission string
+
+const (
+	// PermissionClaimCoupons is claiming and transferring coupons.
+	PermissionClaimCoupons Permission = "coupons:claim"
+	// PermissionManageCoupons is creating, granting and reconciling coupons, and handling the claim queue.
+	PermissionManageCoupons Permission = "coupons:manage"
+	// PermissionActAsUser is claiming and transferring coupons on behalf of another user.
+	PermissionActAsUser Permission = "users:act_as"
+	// PermissionManageRoles is assigning the roles of the users.
+	PermissionManageRoles Permission = "users:manage_roles"
+	// PermissionManageLocks is inspecting and force-releasing the locks.
+	PermissionManageLocks Permission = "locks:manage"
+	// PermissionBypassCache is reading around the cache with the "Cache-Bypass" header.
+	PermissionBypassCache Permission = "cache:bypass"
+)
+
+// rolePermissions are the permissions granted to every role.
+var rolePermissions = map[enums.Role]map[Permission]bool{
+	enums.RoleAdmin: {
+		PermissionClaimCoupons:  true,
+		PermissionManageCoupons: true,
+		PermissionActAsUser:     true,
+		PermissionManageRoles:   true,
+		PermissionManageLocks:   true,
+		PermissionBypassCache:   true,
+	},
+	enums.RoleOperator: {
+		PermissionClaimCoupons:  true,
+		PermissionManageCoupons: true,
+	},
+	enums.RoleCustomer: {
+		PermissionClaimCoupons: true,
+	},
+}
+
+// HasPermission reports whether the role is granted the permission.
+func HasPermission(role enums.Role, permission Permission) bool {
+	return rolePermissions[role][permission]
+}
+
+// Can reports whether the authenticated user of ctx is granted the permission, by the role of its access token.
+func Can(ctx context.Context, permission Permission) bool {
+	return HasPermission(enums.Role(constant.RoleFromCtx(ctx)), permission)
+}
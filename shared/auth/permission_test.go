@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"coupon_be/domain/enums"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasPermission(t *testing.T) {
+	testCases := []struct {
+		role     enums.Role
+		expected map[Permission]bool
+	}{
+		{
+			role: enums.RoleAdmin,
+			expected: map[Permission]bool{
+				PermissionClaimCoupons:  true,
+				PermissionManageCoupons: true,
+				PermissionActAsUser:     true,
+				PermissionManageRoles:   true,
+				PermissionManageLocks:   true,
+			},
+		},
+		{
+			role: enums.RoleOperator,
+			expected: map[Permission]bool{
+				PermissionClaimCoupons:  true,
+				PermissionManageCoupons: true,
+			},
+		},
+		{
+			role: enums.RoleCustomer,
+			expected: map[Permission]bool{
+				PermissionClaimCoupons: true,
+			},
+		},
+		{
+			role:     "",
+			expected: map[Permission]bool{},
+		},
+	}
+
+	permissions := []Permission{
+		PermissionClaimCoupons,
+		PermissionManageCoupons,
+		PermissionActAsUser,
+		PermissionManageRoles,
+		PermissionManageLocks,
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.role.String(), func(t *testing.T) {
+			for _, permission := range permissions {
+				assert.Equal(t, tc.expected[permission], HasPermission(tc.role, permission), permission)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/config"
+	"time"
+)
+
+const (
+	defaultAccessTokenExpiration  = 15 * time.Minute
+	defaultRefreshTokenExpiration = 24 * time.Hour
+)
+
+// GetTokenManager - Returns the token manager of the configured secret and expirations
+func GetTokenManager() (ITokenManager, error) {
+	authConfig := config.Env().Auth
+
+	accessTTL, err := expiration(authConfig.AccessTokenExpiration, defaultAccessTokenExpiration)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTTL, err := expiration(authConfig.RefreshTokenExpiration, defaultRefreshTokenExpiration)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTokenManager(config.Env().JWTSecret, accessTTL, refreshTTL)
+}
+
+func expiration(data string, fallback time.Duration) (time.Duration, error) {
+	if data == "" {
+		return fallback, nil
+	}
+
+	ttl, err := time.ParseDuration(data)
+	if err != nil {
+		return 0, sharedErrs.NewWithCause(sharedErrs.ErrKindApplicationPermanent, "Invalid token expiration", err)
+	}
+
+	return ttl, nil
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/logger"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -package mock -source=token.go -destination=../../mock/auth_token.go *
+
+// TokenType tells the access tokens, which authenticate the requests, apart from the refresh tokens.
+type TokenType string
+
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
+)
+
+// Claims are the claims of the tokens, with the id of the user as the subject.
+type Claims struct {
+	jwt.RegisteredClaims
+
+	Username string `json:"username"`
+	// Role is the role of the user when the token is issued. The permissions are checked against it, so a role change
+	// applies to the tokens issued afterwards, and the access tokens issued before keep the former role until they
+	// expire.
+	Role enums.Role `json:"role"`
+	Type TokenType  `json:"token_type"`
+}
+
+// UserID returns the id of the user the token is issued to.
+func (c *Claims) UserID() (uint64, error) {
+	return strconv.ParseUint(c.Subject, 10, 64)
+}
+
+// TokenPair is the access and refresh token issued on a login.
+type TokenPair struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+// ITokenManager - interface for issuing and verifying the JWTs of the users
+type ITokenManager interface {
+	// Issue returns a new access and refresh token of the user, carrying its current role.
+	Issue(ctx context.Context, user *domain.User) (*TokenPair, error)
+	// Parse verifies the token is a valid, unexpired token of the given type, and returns its claims.
+	Parse(ctx context.Context, token string, tokenType TokenType) (*Claims, error)
+}
+
+// TokenManager - HMAC-SHA256 implementation of ITokenManager
+type TokenManager struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+// NewTokenManager returns a token manager signing with secret, issuing tokens which expire after the given ttls.
+func NewTokenManager(secret string, accessTTL, refreshTTL time.Duration) (*TokenManager, error) {
+	if secret == "" {
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "JWT secret is empty")
+	}
+	if accessTTL <= 0 || refreshTTL <= 0 {
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "JWT expirations must be positive")
+	}
+
+	return &TokenManager{
+		secret:     []byte(secret),
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		now:        time.Now,
+	}, nil
+}
+
+func (m *TokenManager) Issue(ctx context.Context, user *domain.User) (*TokenPair, error) {
+	now := m.now()
+
+	accessToken, accessExpiresAt, err := m.sign(user, TokenTypeAccess, now, m.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshExpiresAt, err := m.sign(user, TokenTypeRefresh, now, m.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug(ctx, "issued tokens of user %d, the access token expires at %v", user.ID, accessExpiresAt)
+
+	return &TokenPair{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+func (m *TokenManager) Parse(ctx context.Context, token string, tokenType TokenType) (*Claims, error) {
+	claims := &Claims{}
+
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return m.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(m.now),
+	)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, sharedErrs.ExpiredTokenErr
+	}
+	if err != nil {
+		logger.Debug(ctx, "invalid token: %v", err)
+		return nil, sharedErrs.InvalidTokenErr
+	}
+
+	if claims.Type != tokenType {
+		logger.Debug(ctx, "invalid token: %s token is given instead of %s token", claims.Type, tokenType)
+		return nil, sharedErrs.InvalidTokenErr
+	}
+
+	if _, err = claims.UserID(); err != nil {
+		logger.Debug(ctx, "invalid token subject %q: %v", claims.Subject, err)
+		return nil, sharedErrs.InvalidTokenErr
+	}
+
+	return claims, nil
+}
+
+func (m *TokenManager) sign(user *domain.User, tokenType TokenType, now time.Time, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := now.Add(ttl)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   strconv.FormatUint(user.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Username: user.Username,
+		Role:     user.Role,
+		Type:     tokenType,
+	}).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, sharedErrs.NewWithCause(sharedErrs.ErrKindApplication, "failed to sign token", err)
+	}
+
+	return token, expiresAt, nil
+}
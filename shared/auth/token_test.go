@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenManager_IssueAndParse(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+	user := &domain.User{BaseModel: domain.BaseModel{ID: 42}, Username: "user_42", Role: enums.RoleOperator}
+
+	tokens, err := NewTokenManager("secret", 15*time.Minute, 24*time.Hour)
+	assert.NoError(t, err)
+
+	pair, err := tokens.Issue(ctx, user)
+	assert.NoError(t, err)
+	assert.True(t, pair.RefreshTokenExpiresAt.After(pair.AccessTokenExpiresAt))
+
+	claims, err := tokens.Parse(ctx, pair.AccessToken, TokenTypeAccess)
+	assert.NoError(t, err)
+	userID, err := claims.UserID()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), userID)
+	assert.Equal(t, "user_42", claims.Username)
+	assert.Equal(t, enums.RoleOperator, claims.Role)
+
+	claims, err = tokens.Parse(ctx, pair.RefreshToken, TokenTypeRefresh)
+	assert.NoError(t, err)
+	assert.Equal(t, TokenTypeRefresh, claims.Type)
+}
+
+func TestTokenManager_Parse(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+	user := &domain.User{BaseModel: domain.BaseModel{ID: 42}, Username: "user_42", Role: enums.RoleOperator}
+	now := time.Now()
+
+	tokens, err := NewTokenManager("secret", 15*time.Minute, 24*time.Hour)
+	assert.NoError(t, err)
+	tokens.now = func() time.Time { return now }
+
+	pair, err := tokens.Issue(ctx, user)
+	assert.NoError(t, err)
+
+	other, err := NewTokenManager("other secret", 15*time.Minute, 24*time.Hour)
+	assert.NoError(t, err)
+	otherPair, err := other.Issue(ctx, user)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		token         string
+		tokenType     TokenType
+		elapsed       time.Duration
+		expectedError error
+	}{
+		{
+			name:          "refresh token as access token",
+			token:         pair.RefreshToken,
+			tokenType:     TokenTypeAccess,
+			expectedError: sharedErrs.InvalidTokenErr,
+		},
+		{
+			name:          "signed with another secret",
+			token:         otherPair.AccessToken,
+			tokenType:     TokenTypeAccess,
+			expectedError: sharedErrs.InvalidTokenErr,
+		},
+		{
+			name:          "malformed",
+			token:         "not.a.token",
+			tokenType:     TokenTypeAccess,
+			expectedError: sharedErrs.InvalidTokenErr,
+		},
+		{
+			name:          "expired",
+			token:         pair.AccessToken,
+			tokenType:     TokenTypeAccess,
+			elapsed:       16 * time.Minute,
+			expectedError: sharedErrs.ExpiredTokenErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens.now = func() time.Time { return now.Add(tc.elapsed) }
+
+			claims, err := tokens.Parse(ctx, tc.token, tc.tokenType)
+
+			assert.Nil(t, claims)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestNewTokenManager_Invalid(t *testing.T) {
+	_, err := NewTokenManager("", 15*time.Minute, 24*time.Hour)
+	assert.Error(t, err)
+
+	_, err = NewTokenManager("secret", 0, 24*time.Hour)
+	assert.Error(t, err)
+}
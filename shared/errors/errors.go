@@ -19,5 +19,9 @@ func NewRepositoryErr(err error, message string, args ...any) BaseError {
 		return NotFoundErr
 	}
 
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ConflictErr
+	}
+
 	return NewWithCause(ErrKindRepository, fmt.Sprintf(message, args...), err)
 }
@@ -18,6 +18,9 @@ var (
 	// ConflictErr will throw if the current action already exists
 	ConflictErr = New(ErrKindRepository, "Requested data already exist")
 
+	// StaleFenceErr will throw if a write carries an older fencing token than the last accepted write
+	StaleFenceErr = New(ErrKindConflict, "Write is rejected because of a stale fencing token")
+
 	// BadParamInputErr will throw if the given request-body or params is not valid
 	BadParamInputErr = New(ErrKindBusinessValidation, "Requested parameters are not valid")
 
@@ -29,7 +32,7 @@ var (
 var (
 	// UnauthorizedErr will throw if the current request is unauthorized
 	UnauthorizedErr        = New(ErrKindAuthorization, "Unauthorized")
-	IncorrectCredentialErr = New(ErrKindValidation, "Login failed. Email or password is incorrect.")
+	IncorrectCredentialErr = New(ErrKindValidation, "Login failed. Username or password is incorrect.")
 	InvalidTokenErr        = New(ErrKindAuthorization, "Invalid token")
 	ExpiredTokenErr        = New(ErrKindAuthorization, "Expired token")
 )
@@ -66,6 +69,10 @@ func getStatusCodeByErrorKind(k Kind) int {
 		return http.StatusForbidden
 	case ErrKindConflict, ErrKindAcquireRedisLock:
 		return http.StatusConflict
+	case ErrKindRateLimited:
+		return http.StatusTooManyRequests
+	case ErrKindLockCancelled:
+		return http.StatusServiceUnavailable
 	default:
 		return http.StatusInternalServerError
 	}
@@ -13,6 +13,7 @@ const (
 	ErrKindHTTP           Kind = "http_error"
 	ErrKindInvalidRequest Kind = "invalid_request_error"
 	ErrKindValidation     Kind = "validation_error"
+	ErrKindRateLimited    Kind = "rate_limited_error"
 
 	// ErrKindApplication errors are errors that might be resolved by retrying the same request at a later time
 	ErrKindApplication Kind = "application_error"
@@ -39,5 +40,6 @@ const (
 	ErrKindHttpClient       Kind = "http_client_error"
 	ErrKindRedis            Kind = "redis_error"
 	ErrKindAcquireRedisLock Kind = "acquire_redis_lock_error"
+	ErrKindLockCancelled    Kind = "lock_cancelled_error"
 	ErrKindDependency       Kind = "dependency_error"
 )
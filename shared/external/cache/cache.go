@@ -0,0 +1,25 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate mockgen -package mock -source=cache.go -destination=../../../mock/cache.go *
+
+const (
+	keyPrefix = "cache:"
+	tagPrefix = "cache:tag:"
+)
+
+// ICache - interface for a read-through cache whose keys are invalidated by tags
+type ICache interface {
+	// Get decodes the cached value of key into dst, and reports whether the key is cached.
+	Get(ctx context.Context, key string, dst any) (bool, error)
+	// Set caches value under key for ttl, tagged with the tags it is invalidated by.
+	Set(ctx context.Context, key string, value any, ttl time.Duration, tags ...string) error
+	// Invalidate removes every key tagged with any of tags, on every instance.
+	Invalidate(ctx context.Context, tags ...string) error
+	// Listen drops the local copies of the keys invalidated by the other instances until ctx is done.
+	Listen(ctx context.Context)
+}
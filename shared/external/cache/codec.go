@@ -0,0 +1,52 @@
+package cache
+
+import (
+	sharedErrs "coupon_be/shared/errors"
+	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	CodecJSON    = "json"
+	CodecMsgpack = "msgpack"
+)
+
+// Codec encodes the cached values.
+type Codec interface {
+	Marshal(value any) ([]byte, error)
+	Unmarshal(data []byte, dst any) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(value any) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jsonCodec) Unmarshal(data []byte, dst any) error {
+	return json.Unmarshal(data, dst)
+}
+
+// msgpackCodec is more compact and faster to decode than json, at the cost of values unreadable with redis-cli.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(value any) ([]byte, error) {
+	return msgpack.Marshal(value)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, dst any) error {
+	return msgpack.Unmarshal(data, dst)
+}
+
+// NewCodec returns the codec of the given name, json when the name is empty.
+func NewCodec(name string) (Codec, error) {
+	switch name {
+	case CodecJSON, "":
+		return jsonCodec{}, nil
+	case CodecMsgpack:
+		return msgpackCodec{}, nil
+	default:
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "Unknown cache codec %s", name)
+	}
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"coupon_be/domain"
+	"coupon_be/domain/enums"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodec(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	coupon := &domain.Coupon{
+		BaseModel:       domain.BaseModel{ID: 1, CreatedAt: now, UpdatedAt: now},
+		Name:            "COUPON_TEST",
+		Amount:          100,
+		RemainingAmount: 50,
+		ClaimStrategy:   enums.ClaimStrategyRedisLock,
+		ClaimedBy: []*domain.User{
+			{BaseModel: domain.BaseModel{ID: 2, CreatedAt: now, UpdatedAt: now}, Username: "user_123"},
+		},
+	}
+
+	for _, name := range []string{CodecJSON, CodecMsgpack} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := NewCodec(name)
+			assert.NoError(t, err)
+
+			data, err := codec.Marshal(coupon)
+			assert.NoError(t, err)
+
+			var decoded domain.Coupon
+			assert.NoError(t, codec.Unmarshal(data, &decoded))
+			assert.Equal(t, coupon.Name, decoded.Name)
+			assert.Equal(t, coupon.RemainingAmount, decoded.RemainingAmount)
+			assert.Equal(t, coupon.ClaimStrategy, decoded.ClaimStrategy)
+			assert.True(t, coupon.CreatedAt.Equal(decoded.CreatedAt))
+			assert.False(t, decoded.DeletedAt.Valid)
+			assert.Len(t, decoded.ClaimedBy, 1)
+			assert.Equal(t, "user_123", decoded.ClaimedBy[0].Username)
+		})
+	}
+
+	_, err := NewCodec("gob")
+	assert.Error(t, err)
+}
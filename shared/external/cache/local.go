@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+const maxLocalEntries = 10000
+
+type localEntry struct {
+	data      []byte
+	tags      []string
+	expiresAt time.Time
+}
+
+// localCache keeps the encoded values of an instance for a short ttl, so the hottest keys are not read from redis on
+// every call. It is bounded by maxLocalEntries, and cleared when it is full of unexpired entries.
+type localCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*localEntry
+	tagged  map[string]map[string]struct{}
+	now     func() time.Time
+}
+
+func newLocalCache(ttl time.Duration) *localCache {
+	return &localCache{
+		ttl:     ttl,
+		entries: make(map[string]*localEntry),
+		tagged:  make(map[string]map[string]struct{}),
+		now:     time.Now,
+	}
+}
+
+func (c *localCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(key)
+		return nil, false
+	}
+
+	return entry.data, true
+}
+
+// set keeps data for the shorter of ttl and the ttl of the local cache.
+func (c *localCache) set(key string, data []byte, ttl time.Duration, tags []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; ok {
+		c.remove(key)
+	} else if len(c.entries) >= maxLocalEntries {
+		c.evictExpired()
+		if len(c.entries) >= maxLocalEntries {
+			c.reset()
+		}
+	}
+
+	c.entries[key] = &localEntry{data: data, tags: tags, expiresAt: c.now().Add(min(ttl, c.ttl))}
+	for _, tag := range tags {
+		if c.tagged[tag] == nil {
+			c.tagged[tag] = make(map[string]struct{})
+		}
+		c.tagged[tag][key] = struct{}{}
+	}
+}
+
+func (c *localCache) invalidate(tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tagged[tag] {
+			c.remove(key)
+		}
+	}
+}
+
+func (c *localCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reset()
+}
+
+func (c *localCache) evictExpired() {
+	now := c.now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			c.remove(key)
+		}
+	}
+}
+
+func (c *localCache) reset() {
+	c.entries = make(map[string]*localEntry)
+	c.tagged = make(map[string]map[string]struct{})
+}
+
+func (c *localCache) remove(key string) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+
+	delete(c.entries, key)
+	for _, tag := range entry.tags {
+		delete(c.tagged[tag], key)
+		if len(c.tagged[tag]) == 0 {
+			delete(c.tagged, tag)
+		}
+	}
+}
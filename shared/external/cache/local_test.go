@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalCache(t *testing.T) {
+	now := time.Now()
+
+	c := newLocalCache(time.Second)
+	c.now = func() time.Time { return now }
+
+	c.set("coupon:name:A", []byte("a"), time.Minute, []string{"coupon:1", "user_claims"})
+	c.set("coupon:name:B", []byte("b"), time.Minute, []string{"coupon:2", "user_claims"})
+	c.set("coupons:page:1", []byte("list"), 500*time.Millisecond, []string{"coupons"})
+
+	data, ok := c.get("coupon:name:A")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a"), data)
+
+	// the local ttl caps the longer ttl of the key, and the shorter ttl of the key is kept
+	now = now.Add(600 * time.Millisecond)
+	_, ok = c.get("coupons:page:1")
+	assert.False(t, ok)
+	_, ok = c.get("coupon:name:A")
+	assert.True(t, ok)
+
+	c.invalidate("coupon:1")
+	_, ok = c.get("coupon:name:A")
+	assert.False(t, ok)
+	_, ok = c.get("coupon:name:B")
+	assert.True(t, ok)
+
+	c.invalidate("user_claims")
+	_, ok = c.get("coupon:name:B")
+	assert.False(t, ok)
+	assert.Empty(t, c.entries)
+	assert.Empty(t, c.tagged)
+
+	c.set("coupon:name:A", []byte("a"), time.Minute, []string{"coupon:1"})
+	now = now.Add(time.Second)
+	_, ok = c.get("coupon:name:A")
+	assert.False(t, ok)
+}
+
+func TestLocalCache_Full(t *testing.T) {
+	c := newLocalCache(time.Minute)
+	for i := range maxLocalEntries {
+		c.set(string(rune(i)), nil, time.Minute, []string{"coupons"})
+	}
+
+	c.set("coupon:name:A", []byte("a"), time.Minute, []string{"coupon:1"})
+
+	assert.Len(t, c.entries, 1)
+	data, ok := c.get("coupon:name:A")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a"), data)
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
+	uConfig "coupon_be/util/config"
+	"time"
+)
+
+const defaultInvalidationChannel = "cache:invalidations"
+
+// GetCache - Returns the redis cache with the configured codec and local ttl
+func GetCache(ctx context.Context) (ICache, error) {
+	cacheConfig := uConfig.Env().Cache
+
+	codec, err := NewCodec(cacheConfig.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	var localTTL time.Duration
+	if cacheConfig.LocalTTL != "" {
+		if localTTL, err = time.ParseDuration(cacheConfig.LocalTTL); err != nil {
+			return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplicationPermanent, "Invalid cache local ttl", err)
+		}
+	}
+
+	channel := cacheConfig.Channel
+	if channel == "" {
+		channel = defaultInvalidationChannel
+	}
+
+	pool, err := redis.GetConnection(ctx)
+	if err != nil {
+		return nil, sharedErrs.Wrap(err, "get redis conn")
+	}
+
+	return NewRedisCache(pool, codec, localTTL, redis.Key(channel))
+}
@@ -0,0 +1,268 @@
+package cache
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
+	"coupon_be/util/logger"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	valueField = "v"
+	tagsField  = "t"
+	tagsSep    = ","
+
+	listenRetryDelay = time.Second
+)
+
+// setScript stores the value with its tags and adds the key to the set of every tag. A tag set lives as long as the
+// longest lived key in it.
+//
+// KEYS[1] key, KEYS[2...] tag set keys
+// ARGV[1] value, ARGV[2] tags, ARGV[3] ttl in milliseconds
+var setScript = redigo.NewScript(-1, `
+redis.call('HSET', KEYS[1], 'v', ARGV[1], 't', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+for i = 2, #KEYS do
+	redis.call('SADD', KEYS[i], KEYS[1])
+	if redis.call('PTTL', KEYS[i]) < tonumber(ARGV[3]) then
+		redis.call('PEXPIRE', KEYS[i], ARGV[3])
+	end
+end
+return 1
+`)
+
+// invalidateScript removes every key of the tag sets together with the sets.
+//
+// KEYS[1...] tag set keys
+var invalidateScript = redigo.NewScript(-1, `
+local removed = 0
+for i = 1, #KEYS do
+	for _, key in ipairs(redis.call('SMEMBERS', KEYS[i])) do
+		removed = removed + redis.call('DEL', key)
+	end
+	redis.call('DEL', KEYS[i])
+end
+return removed
+`)
+
+// RedisCache - redis implementation of ICache, in front of an optional local cache per instance. The invalidated tags
+// are published on channel, so every instance drops its local copies of them.
+type RedisCache struct {
+	pool    *redis.Pool
+	codec   Codec
+	local   *localCache
+	channel string
+}
+
+// NewRedisCache returns the cache, keeping the values of an instance for localTTL as well when it is above zero.
+func NewRedisCache(pool *redis.Pool, codec Codec, localTTL time.Duration, channel string) (*RedisCache, error) {
+	if pool == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindRedis, "Redis pool is nil")
+	}
+
+	c := &RedisCache{pool: pool, codec: codec, channel: channel}
+	if localTTL > 0 {
+		c.local = newLocalCache(localTTL)
+	}
+
+	return c, nil
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string, dst any) (bool, error) {
+	if c.local != nil {
+		if data, ok := c.local.get(key); ok {
+			return true, c.decode(data, dst)
+		}
+	}
+
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return false, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	fields, err := redigo.ByteSlices(conn.Do("HMGET", redis.Key(keyPrefix+key), valueField, tagsField))
+	if err != nil {
+		return false, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to read cache", err)
+	}
+	if len(fields) != 2 || fields[0] == nil {
+		logger.Debug(ctx, "cache miss on %s", key)
+		return false, nil
+	}
+
+	if err = c.decode(fields[0], dst); err != nil {
+		return false, err
+	}
+
+	if c.local != nil {
+		ttl, err := redigo.Int64(conn.Do("PTTL", redis.Key(keyPrefix+key)))
+		if err == nil && ttl > 0 {
+			c.local.set(key, fields[0], time.Duration(ttl)*time.Millisecond, splitTags(fields[1]))
+		}
+	}
+
+	return true, nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value any, ttl time.Duration, tags ...string) error {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to encode cached value", err)
+	}
+
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	args := []any{len(tags) + 1, redis.Key(keyPrefix + key)}
+	for _, tag := range tags {
+		args = append(args, redis.Key(tagPrefix+tag))
+	}
+	args = append(args, data, strings.Join(tags, tagsSep), ttl.Milliseconds())
+
+	if _, err = setScript.Do(conn, args...); err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to write cache", err)
+	}
+
+	if c.local != nil {
+		c.local.set(key, data, ttl, tags)
+	}
+
+	return nil
+}
+
+func (c *RedisCache) Invalidate(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if c.local != nil {
+		c.local.invalidate(tags...)
+	}
+
+	message, err := json.Marshal(tags)
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to encode invalidated tags", err)
+	}
+
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	args := []any{len(tags)}
+	for _, tag := range tags {
+		args = append(args, redis.Key(tagPrefix+tag))
+	}
+
+	removed, err := redigo.Int(invalidateScript.Do(conn, args...))
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to invalidate cache", err)
+	}
+
+	if _, err = conn.Do("PUBLISH", c.channel, message); err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to publish cache invalidation", err)
+	}
+
+	logger.Debug(ctx, "cache tags %v are invalidated, %d keys removed", tags, removed)
+
+	return nil
+}
+
+// Listen subscribes to the invalidations of the other instances. The local cache is cleared whenever the subscription
+// is lost, as the invalidations published meanwhile are missed.
+func (c *RedisCache) Listen(ctx context.Context) {
+	if c.local == nil {
+		return
+	}
+
+	for {
+		err := c.listen(ctx)
+		c.local.clear()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		logger.Error(ctx, "cache invalidation subscription is lost, resubscribing in %v: %v", listenRetryDelay, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+func (c *RedisCache) listen(ctx context.Context) error {
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	psc := redigo.PubSubConn{Conn: conn}
+	defer psc.Close()
+
+	if err = psc.Subscribe(c.channel); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = psc.Unsubscribe()
+		case <-done:
+		}
+	}()
+
+	logger.Info(ctx, "listening to cache invalidations on %s", c.channel)
+
+	for {
+		// no read timeout, the subscription waits for messages as long as it is alive
+		switch msg := psc.ReceiveWithTimeout(0).(type) {
+		case redigo.Message:
+			var tags []string
+			if err := json.Unmarshal(msg.Data, &tags); err != nil {
+				logger.Error(ctx, "failed to decode cache invalidation %s: %v", msg.Data, err)
+				continue
+			}
+
+			c.local.invalidate(tags...)
+		case redigo.Subscription:
+			if msg.Count == 0 {
+				return errors.New("unsubscribed")
+			}
+		case error:
+			return msg
+		}
+	}
+}
+
+func (c *RedisCache) decode(data []byte, dst any) error {
+	if err := c.codec.Unmarshal(data, dst); err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to decode cached value", err)
+	}
+
+	return nil
+}
+
+func splitTags(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return strings.Split(string(data), tagsSep)
+}
@@ -35,7 +35,8 @@ func OpenGormDB(sqlDB *sql.DB) (*gorm.DB, error) {
 		Conn: sqlDB,
 	}), &gorm.Config{
 		//Logger: logger.Default.LogMode(logger.Silent),
-		PrepareStmt: true,
+		PrepareStmt:    true,
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, err
@@ -2,13 +2,23 @@ package database
 
 import (
 	"context"
+	"sync"
 
 	"gorm.io/gorm"
 )
 
 type keys string
 
-const txKey keys = "DBTRX"
+const (
+	txKey          keys = "DBTRX"
+	afterCommitKey keys = "DBTRX_AFTER_COMMIT"
+)
+
+// afterCommit holds the functions to run once the transaction is committed.
+type afterCommit struct {
+	mu  sync.Mutex
+	fns []func()
+}
 
 // ConnFromCtx retrieves the *gorm.DB transaction from the context or falls back to the provided default if available.
 // This function is used to pass the database transaction and connection between layers.
@@ -28,9 +38,48 @@ func ConnFromCtx(ctx context.Context, defaults ...*gorm.DB) (*gorm.DB, bool) {
 // InitTx initializes a database transaction, then store it to the context.
 func InitTx(ctx context.Context, db *gorm.DB) (context.Context, *gorm.DB) {
 	dbTx := db.WithContext(ctx).Begin()
+	ctx = context.WithValue(ctx, afterCommitKey, &afterCommit{})
 	return context.WithValue(ctx, txKey, dbTx), dbTx
 }
 
+// AfterCommit runs fn once the transaction of ctx is committed by Commit, and never when it is rolled back. It runs fn
+// right away when ctx carries no transaction.
+func AfterCommit(ctx context.Context, fn func()) {
+	hooks, ok := ctx.Value(afterCommitKey).(*afterCommit)
+	if !ok {
+		fn()
+		return
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+
+	hooks.fns = append(hooks.fns, fn)
+}
+
+// Commit commits the transaction tx of ctx, then runs the functions registered by AfterCommit.
+func Commit(ctx context.Context, tx *gorm.DB) error {
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	hooks, ok := ctx.Value(afterCommitKey).(*afterCommit)
+	if !ok {
+		return nil
+	}
+
+	hooks.mu.Lock()
+	fns := hooks.fns
+	hooks.fns = nil
+	hooks.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+
+	return nil
+}
+
 // GetTxFromCtx retrieves a database transaction from the provided context.
 func GetTxFromCtx(ctx context.Context) (*gorm.DB, bool) {
 	tx, ok := ctx.Value(txKey).(*gorm.DB)
@@ -0,0 +1,152 @@
+package queue
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryQueue - in-memory implementation of IQueue for tests and single node deployments.
+// Messages are lost when the process stops.
+type MemoryQueue struct {
+	partitions []chan *Message
+
+	mu          sync.Mutex
+	seq         uint64
+	deadLetters []*DeadLetter
+}
+
+// NewMemoryQueue creates an in-memory queue with the given number of partitions, each buffering up to capacity messages.
+func NewMemoryQueue(partitions, capacity int) (*MemoryQueue, error) {
+	if partitions < 1 {
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "queue partitions is less than 1")
+	}
+	if capacity < 1 {
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "queue capacity is less than 1")
+	}
+
+	q := &MemoryQueue{partitions: make([]chan *Message, partitions)}
+	for i := range q.partitions {
+		q.partitions[i] = make(chan *Message, capacity)
+	}
+
+	return q, nil
+}
+
+func (q *MemoryQueue) Partitions() int {
+	return len(q.partitions)
+}
+
+func (q *MemoryQueue) Publish(_ context.Context, key string, payload []byte) (string, error) {
+	q.mu.Lock()
+	q.seq++
+	id := strconv.FormatUint(q.seq, 10)
+	q.mu.Unlock()
+
+	msg := &Message{
+		ID:          id,
+		Partition:   partitionOf(key, len(q.partitions)),
+		Key:         key,
+		Payload:     payload,
+		PublishedAt: time.Now(),
+	}
+
+	select {
+	case q.partitions[msg.Partition] <- msg:
+		return id, nil
+	default:
+		return "", sharedErrs.New(sharedErrs.ErrKindApplication, "Queue partition %d is full", msg.Partition)
+	}
+}
+
+func (q *MemoryQueue) Receive(ctx context.Context, partition int) (*Message, error) {
+	if partition < 0 || partition >= len(q.partitions) {
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "Unknown queue partition %d", partition)
+	}
+
+	select {
+	case msg := <-q.partitions[partition]:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (q *MemoryQueue) Ack(context.Context, *Message) error {
+	return nil
+}
+
+func (q *MemoryQueue) DeadLetter(_ context.Context, msg *Message, reason string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.deadLetters = append(q.deadLetters, &DeadLetter{
+		ID:       msg.ID,
+		Message:  msg,
+		Reason:   reason,
+		FailedAt: time.Now(),
+	})
+
+	return nil
+}
+
+func (q *MemoryQueue) DeadLetters(_ context.Context, limit int) ([]*DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if limit <= 0 || limit > len(q.deadLetters) {
+		limit = len(q.deadLetters)
+	}
+
+	result := make([]*DeadLetter, limit)
+	copy(result, q.deadLetters[:limit])
+
+	return result, nil
+}
+
+func (q *MemoryQueue) FindDeadLetter(_ context.Context, id string) (*DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, d := range q.deadLetters {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+
+	return nil, sharedErrs.NotFoundErr
+}
+
+func (q *MemoryQueue) Replay(ctx context.Context, id string) (*Message, error) {
+	q.mu.Lock()
+	var deadLetter *DeadLetter
+	for i, d := range q.deadLetters {
+		if d.ID == id {
+			deadLetter = d
+			q.deadLetters = append(q.deadLetters[:i], q.deadLetters[i+1:]...)
+			break
+		}
+	}
+	q.mu.Unlock()
+
+	if deadLetter == nil {
+		return nil, sharedErrs.NotFoundErr
+	}
+
+	newID, err := q.Publish(ctx, deadLetter.Message.Key, deadLetter.Message.Payload)
+	if err != nil {
+		// put it back, so the dead letter is not lost
+		q.mu.Lock()
+		q.deadLetters = append(q.deadLetters, deadLetter)
+		q.mu.Unlock()
+
+		return nil, err
+	}
+
+	replayed := *deadLetter.Message
+	replayed.ID = newID
+
+	return &replayed, nil
+}
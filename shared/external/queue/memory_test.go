@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryQueue_OrderedPerPartition(t *testing.T) {
+	ctx := context.Background()
+
+	q, err := NewMemoryQueue(4, 10)
+	assert.NoError(t, err)
+
+	published := []struct{ key, payload string }{
+		{"COUPON_A", "a1"}, {"COUPON_B", "b1"}, {"COUPON_A", "a2"}, {"COUPON_B", "b2"}, {"COUPON_A", "a3"},
+	}
+	for _, p := range published {
+		_, err = q.Publish(ctx, p.key, []byte(p.payload))
+		assert.NoError(t, err)
+	}
+
+	// the messages of a key are received from its partition in the order they are published
+	counts := make(map[int]int)
+	for _, p := range published {
+		counts[partitionOf(p.key, q.Partitions())]++
+	}
+
+	received := make(map[string][]string)
+	for partition, count := range counts {
+		for range count {
+			msg, err := q.Receive(ctx, partition)
+			assert.NoError(t, err)
+			assert.Equal(t, partitionOf(msg.Key, q.Partitions()), msg.Partition)
+
+			received[msg.Key] = append(received[msg.Key], string(msg.Payload))
+		}
+	}
+
+	assert.Equal(t, map[string][]string{"COUPON_A": {"a1", "a2", "a3"}, "COUPON_B": {"b1", "b2"}}, received)
+}
+
+func TestMemoryQueue_Receive(t *testing.T) {
+	q, err := NewMemoryQueue(1, 1)
+	assert.NoError(t, err)
+
+	_, err = q.Publish(context.Background(), "COUPON_A", []byte("a1"))
+	assert.NoError(t, err)
+
+	_, err = q.Publish(context.Background(), "COUPON_A", []byte("a2"))
+	assert.Error(t, err, "full partition is not rejected")
+
+	_, err = q.Receive(context.Background(), 1)
+	assert.Error(t, err, "unknown partition is not rejected")
+
+	msg, err := q.Receive(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "a1", string(msg.Payload))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = q.Receive(ctx, 0)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMemoryQueue_DeadLetter(t *testing.T) {
+	ctx := context.Background()
+
+	q, err := NewMemoryQueue(2, 10)
+	assert.NoError(t, err)
+
+	_, err = q.Publish(ctx, "COUPON_A", []byte("a1"))
+	assert.NoError(t, err)
+
+	msg, err := q.Receive(ctx, partitionOf("COUPON_A", 2))
+	assert.NoError(t, err)
+	assert.NoError(t, q.DeadLetter(ctx, msg, "coupon is locked"))
+
+	deadLetters, err := q.DeadLetters(ctx, 10)
+	assert.NoError(t, err)
+	assert.Len(t, deadLetters, 1)
+	assert.Equal(t, "coupon is locked", deadLetters[0].Reason)
+	assert.Equal(t, msg, deadLetters[0].Message)
+
+	deadLetter, err := q.FindDeadLetter(ctx, deadLetters[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, deadLetters[0], deadLetter)
+
+	// the replayed message is published again, and the dead letter is removed
+	replayed, err := q.Replay(ctx, deadLetter.ID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, msg.ID, replayed.ID)
+
+	received, err := q.Receive(ctx, msg.Partition)
+	assert.NoError(t, err)
+	assert.Equal(t, replayed.ID, received.ID)
+	assert.Equal(t, "a1", string(received.Payload))
+
+	_, err = q.FindDeadLetter(ctx, deadLetter.ID)
+	assert.Equal(t, sharedErrs.NotFoundErr, err)
+
+	_, err = q.Replay(ctx, deadLetter.ID)
+	assert.Equal(t, sharedErrs.NotFoundErr, err)
+}
@@ -0,0 +1,69 @@
+package queue
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
+	uConfig "coupon_be/util/config"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+
+	defaultPartitions = 4
+	defaultCapacity   = 10000
+	defaultClaimIdle  = time.Minute
+)
+
+// GetQueue - Returns the queue with the given name, backed by the configured queue backend
+func GetQueue(ctx context.Context, name string) (IQueue, error) {
+	queueConfig := uConfig.Env().Queue
+
+	partitions := queueConfig.Partitions
+	if partitions == 0 {
+		partitions = defaultPartitions
+	}
+
+	switch queueConfig.Backend {
+	case BackendMemory, "":
+		capacity := queueConfig.Capacity
+		if capacity == 0 {
+			capacity = defaultCapacity
+		}
+
+		return NewMemoryQueue(partitions, capacity)
+	case BackendRedis:
+		pool, err := redis.GetConnection(ctx)
+		if err != nil {
+			return nil, sharedErrs.Wrap(err, "get redis conn")
+		}
+
+		claimIdle := defaultClaimIdle
+		if queueConfig.ClaimIdle != "" {
+			if claimIdle, err = time.ParseDuration(queueConfig.ClaimIdle); err != nil {
+				return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplicationPermanent, "invalid queue claim idle", err)
+			}
+		}
+
+		return NewStreamQueue(ctx, pool, name, consumerName(), partitions, claimIdle)
+	default:
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "Unknown queue backend %s", queueConfig.Backend)
+	}
+}
+
+// consumerName identifies this instance in the stream consumer groups, and must be stable across restarts so
+// the messages read before a restart are delivered again.
+func consumerName() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return uuid.New().String()
+	}
+
+	return fmt.Sprintf("%s-%s", hostname, uConfig.Env().App.Name)
+}
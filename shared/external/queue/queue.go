@@ -0,0 +1,55 @@
+package queue
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+)
+
+//go:generate mockgen -package mock -source=queue.go -destination=../../../mock/queue.go *
+
+// Message is a single entry of the queue. Messages with the same key are published to the same partition,
+// and every partition is delivered in order.
+type Message struct {
+	ID          string    `json:"id"`
+	Partition   int       `json:"partition"`
+	Key         string    `json:"key"`
+	Payload     []byte    `json:"payload"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// DeadLetter is a message which could not be processed successfully, kept for inspection and replay.
+type DeadLetter struct {
+	ID       string    `json:"id"`
+	Message  *Message  `json:"message"`
+	Reason   string    `json:"reason"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// IQueue - interface for partitioned message queues with a dead-letter queue
+type IQueue interface {
+	// Partitions returns the number of partitions of the queue.
+	Partitions() int
+	// Publish appends the payload to the partition of the key, and returns the message id.
+	Publish(ctx context.Context, key string, payload []byte) (string, error)
+	// Receive blocks until a message of the partition is available or ctx is done.
+	Receive(ctx context.Context, partition int) (*Message, error)
+	// Ack marks the message as processed.
+	Ack(ctx context.Context, msg *Message) error
+	// DeadLetter moves the message to the dead-letter queue with the failure reason.
+	DeadLetter(ctx context.Context, msg *Message, reason string) error
+	// DeadLetters returns up to limit oldest dead letters.
+	DeadLetters(ctx context.Context, limit int) ([]*DeadLetter, error)
+	// FindDeadLetter returns the dead letter of the id, or NotFoundErr.
+	FindDeadLetter(ctx context.Context, id string) (*DeadLetter, error)
+	// Replay publishes the dead letter back to its partition and removes it from the dead-letter queue.
+	Replay(ctx context.Context, id string) (*Message, error)
+}
+
+// partitionOf returns the partition of the key, so messages with the same key always go to the same partition.
+func partitionOf(key string, partitions int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	return int(h.Sum32() % uint32(partitions))
+}
@@ -0,0 +1,489 @@
+package queue
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
+	"coupon_be/util/logger"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	streamGroup        = "workers"
+	streamBlockTimeout = 2 * time.Second
+	// streamReclaimStart is the cursor of XAUTOCLAIM scanning the pending entries from the oldest one.
+	streamReclaimStart = "0-0"
+)
+
+// StreamQueue - redis streams implementation of IQueue. Every partition is a stream read by a consumer group,
+// and the dead letters are kept in a separate stream.
+//
+// Every instance reads every partition as a member of the same consumer group, so a partition is delivered in
+// order within an instance only: the messages of a key may be processed by several instances at the same time.
+type StreamQueue struct {
+	pool       *redis.Pool
+	name       string
+	consumer   string
+	partitions int
+	// minIdle is how long an entry stays pending with another consumer before it is claimed by this one.
+	minIdle time.Duration
+
+	mu sync.Mutex
+	// drained marks the partitions whose pending entries of this consumer have been re-delivered after start.
+	drained map[int]bool
+	// reclaimCursors is the XAUTOCLAIM cursor of every partition, and reclaimAt when the next scan is due.
+	reclaimCursors map[int]string
+	reclaimAt      map[int]time.Time
+}
+
+// NewStreamQueue creates a redis streams queue, and the consumer group of every partition when it does not exist.
+// The entries left pending for minIdle by a consumer, e.g. of an instance which crashed or has been scaled down,
+// are claimed and delivered again by the others.
+func NewStreamQueue(ctx context.Context, pool *redis.Pool, name, consumer string, partitions int,
+	minIdle time.Duration) (*StreamQueue, error) {
+	if pool == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindRedis, "Redis pool is nil")
+	}
+	if partitions < 1 {
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "queue partitions is less than 1")
+	}
+	if minIdle <= 0 {
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "queue claim idle time is not positive")
+	}
+
+	q := &StreamQueue{
+		pool:           pool,
+		name:           name,
+		consumer:       consumer,
+		partitions:     partitions,
+		minIdle:        minIdle,
+		drained:        make(map[int]bool),
+		reclaimCursors: make(map[int]string),
+		reclaimAt:      make(map[int]time.Time),
+	}
+
+	conn, err := pool.GetContext(ctx)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	for p := 0; p < partitions; p++ {
+		_, err = conn.Do("XGROUP", "CREATE", q.streamKey(p), streamGroup, "0", "MKSTREAM")
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to create stream consumer group", err)
+		}
+	}
+
+	logger.Info(ctx, "Redis stream queue %s initialised with %d partitions as consumer %s", name, partitions, consumer)
+
+	return q, nil
+}
+
+func (q *StreamQueue) streamKey(partition int) string {
+	return redis.Key(fmt.Sprintf("%s:%d", q.name, partition))
+}
+
+func (q *StreamQueue) deadLetterKey() string {
+	return redis.Key(q.name + ":dead")
+}
+
+func (q *StreamQueue) Partitions() int {
+	return q.partitions
+}
+
+func (q *StreamQueue) Publish(ctx context.Context, key string, payload []byte) (string, error) {
+	conn, err := q.pool.GetContext(ctx)
+	if err != nil {
+		return "", sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	id, err := redigo.String(conn.Do("XADD", q.streamKey(partitionOf(key, q.partitions)), "*",
+		"key", key,
+		"payload", payload,
+		"published_at", time.Now().UnixMilli(),
+	))
+	if err != nil {
+		return "", sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to publish message", err)
+	}
+
+	return id, nil
+}
+
+func (q *StreamQueue) Receive(ctx context.Context, partition int) (*Message, error) {
+	if partition < 0 || partition >= q.partitions {
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "Unknown queue partition %d", partition)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// re-deliver the entries this consumer read but never acknowledged, e.g. before a crash, then the new ones
+		q.mu.Lock()
+		start := ">"
+		if !q.drained[partition] {
+			start = "0"
+		}
+		q.mu.Unlock()
+
+		msg, err := q.read(ctx, partition, start)
+		if err != nil {
+			return nil, err
+		}
+
+		if msg != nil {
+			return msg, nil
+		}
+
+		if start == "0" {
+			q.mu.Lock()
+			q.drained[partition] = true
+			q.mu.Unlock()
+			continue
+		}
+
+		// nothing new, take over the entries other consumers have left pending for too long
+		msg, err = q.reclaim(ctx, partition)
+		if err != nil {
+			return nil, err
+		}
+
+		if msg != nil {
+			return msg, nil
+		}
+	}
+}
+
+// reclaim claims the oldest entry of the partition pending with another consumer for at least minIdle, and returns
+// it, or nil when there is none. The pending entries are scanned at most every minIdle, from where the last scan
+// stopped.
+func (q *StreamQueue) reclaim(ctx context.Context, partition int) (*Message, error) {
+	q.mu.Lock()
+	if time.Now().Before(q.reclaimAt[partition]) {
+		q.mu.Unlock()
+		return nil, nil
+	}
+	cursor, ok := q.reclaimCursors[partition]
+	if !ok {
+		cursor = streamReclaimStart
+	}
+	q.mu.Unlock()
+
+	conn, err := q.pool.GetContext(ctx)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	// [next cursor, [[id, [field, value, ...]], ...], [deleted id, ...]], the last one since redis 7
+	reply, err := redigo.Values(conn.Do("XAUTOCLAIM", q.streamKey(partition), streamGroup, q.consumer,
+		q.minIdle.Milliseconds(), cursor, "COUNT", 1))
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to claim idle messages", err)
+	}
+	if len(reply) < 2 {
+		return nil, sharedErrs.New(sharedErrs.ErrKindRedis, "invalid claim reply")
+	}
+
+	next, err := redigo.String(reply[0], nil)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "invalid claim cursor", err)
+	}
+
+	rawEntries, err := redigo.Values(reply[1], nil)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "invalid claimed entries", err)
+	}
+
+	entries, err := parseStreamEntries(rawEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	q.reclaimCursors[partition] = next
+	if next == streamReclaimStart {
+		// the whole pending list has been scanned, wait for the entries pending now to become idle
+		q.reclaimAt[partition] = time.Now().Add(q.minIdle)
+	}
+	q.mu.Unlock()
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	if entries[0].fields == nil {
+		// the entry has been deleted after it was read, nothing left to process
+		return nil, q.Ack(ctx, &Message{ID: entries[0].id, Partition: partition})
+	}
+
+	msg, err := entries[0].message()
+	if err != nil {
+		return nil, err
+	}
+	msg.Partition = partition
+
+	logger.Warn(ctx, "message %s of partition %d was idle for over %s with another consumer, claimed by %s",
+		msg.ID, partition, q.minIdle, q.consumer)
+
+	return msg, nil
+}
+
+func (q *StreamQueue) read(ctx context.Context, partition int, start string) (*Message, error) {
+	conn, err := q.pool.GetContext(ctx)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	reply, err := redigo.Values(redigo.DoWithTimeout(conn, streamBlockTimeout+time.Second,
+		"XREADGROUP", "GROUP", streamGroup, q.consumer,
+		"COUNT", 1,
+		"BLOCK", streamBlockTimeout.Milliseconds(),
+		"STREAMS", q.streamKey(partition), start,
+	))
+	if err == redigo.ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to receive message", err)
+	}
+
+	entries, err := parseStreamReply(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	if entries[0].fields == nil {
+		// the entry has been deleted after it was read, nothing left to process
+		if err = q.Ack(ctx, &Message{ID: entries[0].id, Partition: partition}); err != nil {
+			return nil, err
+		}
+
+		return q.read(ctx, partition, start)
+	}
+
+	msg, err := entries[0].message()
+	if err != nil {
+		return nil, err
+	}
+	msg.Partition = partition
+
+	return msg, nil
+}
+
+func (q *StreamQueue) Ack(ctx context.Context, msg *Message) error {
+	conn, err := q.pool.GetContext(ctx)
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.Do("XACK", q.streamKey(msg.Partition), streamGroup, msg.ID); err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to acknowledge message", err)
+	}
+
+	if _, err = conn.Do("XDEL", q.streamKey(msg.Partition), msg.ID); err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to delete acknowledged message", err)
+	}
+
+	return nil
+}
+
+func (q *StreamQueue) DeadLetter(ctx context.Context, msg *Message, reason string) error {
+	conn, err := q.pool.GetContext(ctx)
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Do("XADD", q.deadLetterKey(), "*",
+		"message_id", msg.ID,
+		"key", msg.Key,
+		"payload", msg.Payload,
+		"published_at", msg.PublishedAt.UnixMilli(),
+		"reason", reason,
+		"failed_at", time.Now().UnixMilli(),
+	)
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to dead letter message", err)
+	}
+
+	return q.Ack(ctx, msg)
+}
+
+func (q *StreamQueue) DeadLetters(ctx context.Context, limit int) ([]*DeadLetter, error) {
+	conn, err := q.pool.GetContext(ctx)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	reply, err := redigo.Values(conn.Do("XRANGE", q.deadLetterKey(), "-", "+", "COUNT", limit))
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to read dead letters", err)
+	}
+
+	entries, err := parseStreamEntries(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*DeadLetter, len(entries))
+	for i, entry := range entries {
+		if result[i], err = entry.deadLetter(q.partitions); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (q *StreamQueue) FindDeadLetter(ctx context.Context, id string) (*DeadLetter, error) {
+	conn, err := q.pool.GetContext(ctx)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	reply, err := redigo.Values(conn.Do("XRANGE", q.deadLetterKey(), id, id))
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to read dead letter", err)
+	}
+
+	entries, err := parseStreamEntries(reply)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, sharedErrs.NotFoundErr
+	}
+
+	return entries[0].deadLetter(q.partitions)
+}
+
+func (q *StreamQueue) Replay(ctx context.Context, id string) (*Message, error) {
+	deadLetter, err := q.FindDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := q.pool.GetContext(ctx)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	newID, err := q.Publish(ctx, deadLetter.Message.Key, deadLetter.Message.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = conn.Do("XDEL", q.deadLetterKey(), id); err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to delete replayed dead letter", err)
+	}
+
+	replayed := *deadLetter.Message
+	replayed.ID = newID
+
+	return &replayed, nil
+}
+
+// streamEntry is a single entry of a stream, with its fields as key value pairs.
+type streamEntry struct {
+	id     string
+	fields map[string]string
+}
+
+func (e *streamEntry) message() (*Message, error) {
+	publishedAt, err := strconv.ParseInt(e.fields["published_at"], 10, 64)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "invalid published_at of stream entry", err)
+	}
+
+	return &Message{
+		ID:          e.id,
+		Key:         e.fields["key"],
+		Payload:     []byte(e.fields["payload"]),
+		PublishedAt: time.UnixMilli(publishedAt),
+	}, nil
+}
+
+func (e *streamEntry) deadLetter(partitions int) (*DeadLetter, error) {
+	msg, err := e.message()
+	if err != nil {
+		return nil, err
+	}
+	msg.ID = e.fields["message_id"]
+	msg.Partition = partitionOf(msg.Key, partitions)
+
+	failedAt, err := strconv.ParseInt(e.fields["failed_at"], 10, 64)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "invalid failed_at of dead letter", err)
+	}
+
+	return &DeadLetter{
+		ID:       e.id,
+		Message:  msg,
+		Reason:   e.fields["reason"],
+		FailedAt: time.UnixMilli(failedAt),
+	}, nil
+}
+
+// parseStreamReply parses the reply of XREADGROUP for a single stream: [[stream, [[id, [field, value, ...]], ...]]]
+func parseStreamReply(reply []any) ([]*streamEntry, error) {
+	if len(reply) == 0 {
+		return nil, nil
+	}
+
+	stream, err := redigo.Values(reply[0], nil)
+	if err != nil || len(stream) != 2 {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "invalid stream reply", err)
+	}
+
+	entries, err := redigo.Values(stream[1], nil)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "invalid stream entries", err)
+	}
+
+	return parseStreamEntries(entries)
+}
+
+// parseStreamEntries parses a list of stream entries: [[id, [field, value, ...]], ...]
+func parseStreamEntries(entries []any) ([]*streamEntry, error) {
+	result := make([]*streamEntry, 0, len(entries))
+	for _, raw := range entries {
+		entry, err := redigo.Values(raw, nil)
+		if err != nil || len(entry) != 2 {
+			return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "invalid stream entry", err)
+		}
+
+		id, err := redigo.String(entry[0], nil)
+		if err != nil {
+			return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "invalid stream entry id", err)
+		}
+
+		// entries deleted after being read are returned with nil fields
+		fields, err := redigo.StringMap(entry[1], nil)
+		if err != nil && entry[1] != nil {
+			return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "invalid stream entry fields", err)
+		}
+
+		result = append(result, &streamEntry{id: id, fields: fields})
+	}
+
+	return result, nil
+}
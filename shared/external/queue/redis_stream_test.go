@@ -0,0 +1,322 @@
+package queue
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
+	"coupon_be/shared/external/redis/redistest"
+	"coupon_be/util/logger"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+const testClaimIdle = 100 * time.Millisecond
+
+func TestStreamQueue_OrderedPerPartition(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+	pool := newStreamsPool(t)
+
+	q, err := NewStreamQueue(ctx, pool, "claim:queue", "worker-1", 4, testClaimIdle)
+	assert.NoError(t, err)
+
+	published := []struct{ key, payload string }{
+		{"COUPON_A", "a1"}, {"COUPON_B", "b1"}, {"COUPON_A", "a2"}, {"COUPON_B", "b2"}, {"COUPON_A", "a3"},
+	}
+	counts := make(map[int]int)
+	for _, p := range published {
+		_, err = q.Publish(ctx, p.key, []byte(p.payload))
+		assert.NoError(t, err)
+
+		counts[partitionOf(p.key, q.Partitions())]++
+	}
+
+	// the messages of a key are received from its partition in the order they are published
+	received := make(map[string][]string)
+	for partition, count := range counts {
+		for range count {
+			msg, err := q.Receive(ctx, partition)
+			assert.NoError(t, err)
+			assert.Equal(t, partition, msg.Partition)
+			assert.NoError(t, q.Ack(ctx, msg))
+
+			received[msg.Key] = append(received[msg.Key], string(msg.Payload))
+		}
+	}
+
+	assert.Equal(t, map[string][]string{"COUPON_A": {"a1", "a2", "a3"}, "COUPON_B": {"b1", "b2"}}, received)
+}
+
+func TestStreamQueue_Redelivery(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+	pool := newStreamsPool(t)
+
+	q, err := NewStreamQueue(ctx, pool, "claim:queue", "worker-1", 1, testClaimIdle)
+	assert.NoError(t, err)
+
+	_, err = q.Publish(ctx, "COUPON_A", []byte("a1"))
+	assert.NoError(t, err)
+
+	msg, err := q.Receive(ctx, 0)
+	assert.NoError(t, err)
+
+	// the consumer restarting before the ack receives the message again
+	restarted, err := NewStreamQueue(ctx, pool, "claim:queue", "worker-1", 1, testClaimIdle)
+	assert.NoError(t, err)
+
+	redelivered, err := restarted.Receive(ctx, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, redelivered)
+
+	// another consumer claims the message once it is left idle by the first one, e.g. scaled down
+	other, err := NewStreamQueue(ctx, pool, "claim:queue", "worker-2", 1, testClaimIdle)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	claimed, err := other.Receive(ctx, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, claimed)
+	assert.GreaterOrEqual(t, time.Since(start), testClaimIdle/2)
+
+	// nothing is delivered once the message is acknowledged
+	assert.NoError(t, other.Ack(ctx, claimed))
+
+	receiveCtx, cancel := context.WithTimeout(ctx, 3*testClaimIdle)
+	defer cancel()
+
+	_, err = restarted.Receive(receiveCtx, 0)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestStreamQueue_DeadLetter(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+	pool := newStreamsPool(t)
+
+	q, err := NewStreamQueue(ctx, pool, "claim:queue", "worker-1", 2, testClaimIdle)
+	assert.NoError(t, err)
+
+	partition := partitionOf("COUPON_A", 2)
+
+	_, err = q.Publish(ctx, "COUPON_A", []byte("a1"))
+	assert.NoError(t, err)
+
+	msg, err := q.Receive(ctx, partition)
+	assert.NoError(t, err)
+	assert.NoError(t, q.DeadLetter(ctx, msg, "coupon is locked"))
+
+	deadLetters, err := q.DeadLetters(ctx, 10)
+	assert.NoError(t, err)
+	assert.Len(t, deadLetters, 1)
+	assert.Equal(t, "coupon is locked", deadLetters[0].Reason)
+	assert.Equal(t, msg, deadLetters[0].Message)
+
+	deadLetter, err := q.FindDeadLetter(ctx, deadLetters[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, deadLetters[0], deadLetter)
+
+	// the dead-lettered message is acknowledged, so it is not delivered again
+	restarted, err := NewStreamQueue(ctx, pool, "claim:queue", "worker-1", 2, testClaimIdle)
+	assert.NoError(t, err)
+
+	receiveCtx, cancel := context.WithTimeout(ctx, 3*testClaimIdle)
+	defer cancel()
+
+	_, err = restarted.Receive(receiveCtx, partition)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the replayed message is published again to its partition, and the dead letter is removed
+	replayed, err := q.Replay(ctx, deadLetter.ID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, msg.ID, replayed.ID)
+
+	received, err := q.Receive(ctx, partition)
+	assert.NoError(t, err)
+	assert.Equal(t, replayed.ID, received.ID)
+	assert.Equal(t, "a1", string(received.Payload))
+
+	_, err = q.FindDeadLetter(ctx, deadLetter.ID)
+	assert.Equal(t, sharedErrs.NotFoundErr, err)
+
+	_, err = q.Replay(ctx, deadLetter.ID)
+	assert.Equal(t, sharedErrs.NotFoundErr, err)
+}
+
+// newStreamsPool returns a pool of connections to a RESP stand-in serving new streams.
+func newStreamsPool(t *testing.T) *redis.Pool {
+	server := redistest.NewServer(t, newStreams().handle)
+
+	return &redis.Pool{Pool: &redigo.Pool{
+		MaxIdle: 2,
+		Dial: func() (redigo.Conn, error) {
+			return redigo.Dial("tcp", server.Address())
+		},
+	}}
+}
+
+// streams is the state behind a RESP stand-in of the redis streams the queue keeps, with a single consumer group of
+// every stream. BLOCK is not waited for, a read finding nothing new answers nil after a short pause.
+type streams struct {
+	mu      sync.Mutex
+	seq     int64
+	entries map[string][]*standInEntry
+	groups  map[string]bool
+}
+
+type standInEntry struct {
+	seq    int64
+	fields []any
+	// delivered marks the entries read by the group, and consumer and deliveredAt the last delivery of the pending.
+	delivered   bool
+	pending     bool
+	deleted     bool
+	consumer    string
+	deliveredAt time.Time
+}
+
+func newStreams() *streams {
+	return &streams{entries: make(map[string][]*standInEntry), groups: make(map[string]bool)}
+}
+
+func (e *standInEntry) id() string {
+	return fmt.Sprintf("%d-0", e.seq)
+}
+
+// reply returns the entry as [id, [field, value, ...]], with nil fields once it is deleted.
+func (e *standInEntry) reply() []any {
+	if e.deleted {
+		return []any{e.id(), nil}
+	}
+
+	return []any{e.id(), e.fields}
+}
+
+func seqOf(id string) int64 {
+	seq, _ := strconv.ParseInt(strings.TrimSuffix(id, "-0"), 10, 64)
+	return seq
+}
+
+func (s *streams) handle(args []string) any {
+	// nothing new, as a blocked read would answer after its timeout
+	if reply := s.do(args); reply != nil || strings.ToUpper(args[0]) != "XREADGROUP" {
+		return reply
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	return nil
+}
+
+func (s *streams) do(args []string) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "XGROUP":
+		// XGROUP CREATE key group 0 MKSTREAM
+		if s.groups[args[2]] {
+			return redistest.Error("BUSYGROUP Consumer Group name already exists")
+		}
+		s.groups[args[2]] = true
+		return redistest.Status("OK")
+	case "XADD":
+		// XADD key * field value ...
+		s.seq++
+		fields := make([]any, 0, len(args)-3)
+		for _, arg := range args[3:] {
+			fields = append(fields, arg)
+		}
+		entry := &standInEntry{seq: s.seq, fields: fields}
+		s.entries[args[1]] = append(s.entries[args[1]], entry)
+		return entry.id()
+	case "XREADGROUP":
+		// XREADGROUP GROUP group consumer COUNT 1 BLOCK ms STREAMS key start
+		consumer, key, start := args[3], args[9], args[10]
+		for _, entry := range s.entries[key] {
+			if start == ">" && !entry.delivered && !entry.deleted {
+				entry.delivered, entry.pending, entry.consumer, entry.deliveredAt = true, true, consumer, time.Now()
+				return []any{[]any{key, []any{entry.reply()}}}
+			}
+			if start != ">" && entry.pending && entry.consumer == consumer {
+				return []any{[]any{key, []any{entry.reply()}}}
+			}
+		}
+		if start == ">" {
+			return nil
+		}
+		return []any{[]any{key, []any{}}}
+	case "XAUTOCLAIM":
+		// XAUTOCLAIM key group consumer min-idle cursor COUNT 1
+		key, consumer, cursor := args[1], args[3], seqOf(args[5])
+		minIdle, _ := strconv.ParseInt(args[4], 10, 64)
+		var claimed []any
+		next := "0-0"
+		for _, entry := range s.entries[key] {
+			if !entry.pending || entry.seq < cursor {
+				continue
+			}
+			if claimed != nil {
+				next = entry.id()
+				break
+			}
+			if time.Since(entry.deliveredAt) >= time.Duration(minIdle)*time.Millisecond {
+				entry.consumer, entry.deliveredAt = consumer, time.Now()
+				claimed = []any{entry.reply()}
+			}
+		}
+		if claimed == nil {
+			claimed = []any{}
+		}
+		return []any{next, claimed, []any{}}
+	case "XACK":
+		acked := int64(0)
+		for _, entry := range s.entries[args[1]] {
+			if entry.id() == args[3] && entry.pending {
+				entry.pending = false
+				acked++
+			}
+		}
+		return acked
+	case "XDEL":
+		deleted := int64(0)
+		for _, entry := range s.entries[args[1]] {
+			if entry.id() == args[2] && !entry.deleted {
+				entry.deleted = true
+				deleted++
+			}
+		}
+		return deleted
+	case "XRANGE":
+		// XRANGE key - + [COUNT n], or XRANGE key id id
+		count := -1
+		if len(args) == 6 {
+			count, _ = strconv.Atoi(args[5])
+		}
+		result := []any{}
+		for _, entry := range s.entries[args[1]] {
+			if entry.deleted || (args[2] != "-" && entry.id() != args[2]) {
+				continue
+			}
+			if len(result) == count {
+				break
+			}
+			result = append(result, entry.reply())
+		}
+		return result
+	case "PING":
+		return redistest.Status("PONG")
+	default:
+		return redistest.Error(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+}
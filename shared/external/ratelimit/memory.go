@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval is how often the keys idle for their whole window are dropped.
+const memorySweepInterval = time.Minute
+
+type memoryEntry struct {
+	requests []time.Time
+	// expiresAt is when the latest request leaves the window, after which the key counts nothing.
+	expiresAt time.Time
+}
+
+// MemoryLimiter - in-memory implementation of ILimiter for tests and single node deployments.
+// Every instance counts its own requests only. The keys idle for their whole window are dropped every
+// memorySweepInterval, so the keys of the clients gone do not pile up.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	sweepAt time.Time
+	now     func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		entries: make(map[string]*memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, limit int, window time.Duration) (*Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	// drop the requests which left the window
+	var requests []time.Time
+	if entry, ok := l.entries[key]; ok {
+		requests = entry.requests
+	}
+	start := 0
+	for start < len(requests) && !requests[start].After(now.Add(-window)) {
+		start++
+	}
+	requests = requests[start:]
+
+	result := &Result{Limit: limit}
+	if len(requests) < limit {
+		requests = append(requests, now)
+		result.Allowed = true
+	}
+
+	if len(requests) == 0 {
+		delete(l.entries, key)
+	} else {
+		l.entries[key] = &memoryEntry{requests: requests, expiresAt: requests[len(requests)-1].Add(window)}
+		result.Reset = requests[0].Add(window).Sub(now)
+	}
+
+	result.Remaining = limit - len(requests)
+
+	return result, nil
+}
+
+// sweep drops the keys whose requests all left their window, once every memorySweepInterval.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Before(l.sweepAt) {
+		return
+	}
+
+	for key, entry := range l.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(l.entries, key)
+		}
+	}
+
+	l.sweepAt = now.Add(memorySweepInterval)
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+
+	result, _ := limiter.Allow(ctx, "key", 2, time.Minute)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+
+	now = now.Add(30 * time.Second)
+	result, _ = limiter.Allow(ctx, "key", 2, time.Minute)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	result, _ = limiter.Allow(ctx, "key", 2, time.Minute)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 30*time.Second, result.Reset)
+
+	// the window slides past the first request only
+	now = now.Add(30 * time.Second)
+	result, _ = limiter.Allow(ctx, "key", 2, time.Minute)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	result, _ = limiter.Allow(ctx, "other", 2, time.Minute)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryLimiter_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+
+	for _, key := range []string{"ip:10.0.0.1", "ip:10.0.0.2", "ip:10.0.0.3"} {
+		_, _ = limiter.Allow(ctx, key, 2, time.Second)
+	}
+	_, _ = limiter.Allow(ctx, "user:1", 2, 2*memorySweepInterval)
+	assert.Len(t, limiter.entries, 4)
+
+	// the keys idle for their window are kept until the next sweep
+	now = now.Add(time.Second)
+	_, _ = limiter.Allow(ctx, "ip:10.0.0.4", 2, time.Second)
+	assert.Len(t, limiter.entries, 5)
+
+	// the sweep drops the idle keys only, and keeps the counts of those still in their window
+	now = now.Add(memorySweepInterval)
+	_, _ = limiter.Allow(ctx, "ip:10.0.0.5", 2, time.Second)
+	assert.Len(t, limiter.entries, 2)
+
+	result, _ := limiter.Allow(ctx, "user:1", 2, 2*memorySweepInterval)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
@@ -0,0 +1,35 @@
+package ratelimit
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
+	uConfig "coupon_be/util/config"
+	"coupon_be/util/logger"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// GetLimiter - Returns the rate limiter backed by the configured backend. An unreachable redis falls back to the
+// in-memory limiter, so every instance limits on its own rather than not at all.
+func GetLimiter(ctx context.Context) (ILimiter, error) {
+	backend := uConfig.Env().RateLimit.Backend
+
+	switch backend {
+	case BackendMemory, "":
+		return NewMemoryLimiter(), nil
+	case BackendRedis:
+		pool, err := redis.GetConnection(ctx)
+		if err != nil {
+			logger.Error(ctx, "Redis is unreachable, falling back to the in-memory rate limiter: %v", err)
+			return NewMemoryLimiter(), nil
+		}
+
+		return NewRedisLimiter(pool)
+	default:
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "Unknown rate limit backend %s", backend)
+	}
+}
@@ -0,0 +1,26 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate mockgen -package mock -source=ratelimit.go -destination=../../../mock/ratelimit.go *
+
+const keyPrefix = "rate_limit:"
+
+// Result is the outcome of a request against a sliding window limit.
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Remaining is the number of requests still allowed in the current window.
+	Remaining int
+	// Reset is the time until the oldest request of the window expires, freeing up a request.
+	Reset time.Duration
+}
+
+// ILimiter - interface for sliding window rate limiters
+type ILimiter interface {
+	// Allow counts a request of key, unless limit requests of key have been counted in the last window already.
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (*Result, error)
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+)
+
+// slidingWindowScript drops the requests which left the window, then counts the request unless the limit is reached.
+// It returns whether the request is allowed, the number of requests in the window, and the milliseconds until the
+// oldest request leaves the window.
+//
+// KEYS[1] rate limit key
+// ARGV[1] now in milliseconds, ARGV[2] window in milliseconds, ARGV[3] limit, ARGV[4] unique request member
+var slidingWindowScript = redigo.NewScript(1, `
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local reset = 0
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+
+return {allowed, count, reset}
+`)
+
+// RedisLimiter - redis implementation of ILimiter, the requests of a key are shared by every instance.
+type RedisLimiter struct {
+	pool *redis.Pool
+	seq  atomic.Uint64
+}
+
+func NewRedisLimiter(pool *redis.Pool) (*RedisLimiter, error) {
+	if pool == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindRedis, "Redis pool is nil")
+	}
+
+	return &RedisLimiter{pool: pool}, nil
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (*Result, error) {
+	conn, err := l.pool.GetContext(ctx)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	now := time.Now()
+	member := fmt.Sprintf("%d-%d", now.UnixNano(), l.seq.Add(1))
+
+	values, err := redigo.Int64s(slidingWindowScript.Do(conn, redis.Key(keyPrefix+key), now.UnixMilli(), window.Milliseconds(), limit, member))
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to count rate limited request", err)
+	}
+
+	return &Result{
+		Allowed:   values[0] == 1,
+		Limit:     limit,
+		Remaining: limit - int(values[1]),
+		Reset:     time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
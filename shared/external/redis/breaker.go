@@ -0,0 +1,156 @@
+package redis
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/logger"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 10 * time.Second
+)
+
+// ErrLockUnavailable is returned by WithLock, without running fn, when the lock nodes can not be reached or the
+// circuit breaker of the lock is open.
+var ErrLockUnavailable = sharedErrs.New(sharedErrs.ErrKindRedis, "Redis lock is unavailable")
+
+type BreakerState string
+
+const (
+	// BreakerClosed lets every lock acquisition through.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen rejects every lock acquisition until the cooldown has passed.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a single trial acquisition through, which closes the breaker again when it succeeds.
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// BreakerStatus is the state of the circuit breaker of the redis lock.
+type BreakerStatus struct {
+	State    BreakerState `json:"state"`
+	Failures int          `json:"failures"`
+	OpenedAt *time.Time   `json:"opened_at,omitempty"`
+}
+
+// circuitBreaker opens after threshold consecutive failures, and lets a trial through once cooldown has passed.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	trial     bool
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+
+	return &circuitBreaker{
+		state:     BreakerClosed,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow reports whether a call may go through.
+func (b *circuitBreaker) allow(ctx context.Context) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+
+		b.setState(ctx, BreakerHalfOpen)
+		b.trial = true
+
+		return true
+	case BreakerHalfOpen:
+		if b.trial {
+			return false
+		}
+
+		b.trial = true
+
+		return true
+	default:
+		return true
+	}
+}
+
+// record reports the outcome of a call let through by allow.
+func (b *circuitBreaker) record(ctx context.Context, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+
+	if !failed {
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.setState(ctx, BreakerClosed)
+		}
+
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		b.setState(ctx, BreakerOpen)
+	}
+}
+
+func (b *circuitBreaker) status() *BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result := &BreakerStatus{State: b.state, Failures: b.failures}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		result.OpenedAt = &openedAt
+	}
+
+	return result
+}
+
+func (b *circuitBreaker) setState(ctx context.Context, state BreakerState) {
+	if state == BreakerClosed {
+		logger.Info(ctx, "Redis lock circuit breaker is %s, from %s", state, b.state)
+	} else {
+		logger.Warn(ctx, "Redis lock circuit breaker is %s, from %s, after %d consecutive failures", state, b.state, b.failures)
+	}
+
+	b.state = state
+}
+
+// breakerLock guards a lock with a circuit breaker, so the unreachable lock nodes are not waited for on every call.
+type breakerLock struct {
+	lock    ILock
+	breaker *circuitBreaker
+}
+
+func (l *breakerLock) WithLock(ctx context.Context, key string, fn func(ctx context.Context, fence int64) error, options ...LockOption) error {
+	if !l.breaker.allow(ctx) {
+		return ErrLockUnavailable
+	}
+
+	err := l.lock.WithLock(ctx, key, fn, options...)
+	l.breaker.record(ctx, errors.Is(err, ErrLockUnavailable))
+
+	return err
+}
@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"coupon_be/util/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	breaker := newCircuitBreaker(2, time.Second)
+	breaker.now = func() time.Time { return now }
+
+	// opens after the threshold of consecutive failures
+	assert.True(t, breaker.allow(ctx))
+	breaker.record(ctx, true)
+	assert.Equal(t, BreakerClosed, breaker.status().State)
+	assert.True(t, breaker.allow(ctx))
+	breaker.record(ctx, true)
+	assert.Equal(t, BreakerOpen, breaker.status().State)
+	assert.False(t, breaker.allow(ctx))
+
+	// lets a single trial through after the cooldown, which opens it again on failure
+	now = now.Add(time.Second)
+	assert.True(t, breaker.allow(ctx))
+	assert.Equal(t, BreakerHalfOpen, breaker.status().State)
+	assert.False(t, breaker.allow(ctx))
+	breaker.record(ctx, true)
+	assert.Equal(t, BreakerOpen, breaker.status().State)
+
+	// closes on a successful trial
+	now = now.Add(time.Second)
+	assert.True(t, breaker.allow(ctx))
+	breaker.record(ctx, false)
+
+	status := breaker.status()
+	assert.Equal(t, BreakerClosed, status.State)
+	assert.Zero(t, status.Failures)
+	assert.Nil(t, status.OpenedAt)
+}
+
+func TestBreakerLock(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+	lock := &breakerLock{
+		lock: lockFunc(func(ctx context.Context, fn func(ctx context.Context, fence int64) error) error {
+			return ErrLockUnavailable
+		}),
+		breaker: newCircuitBreaker(1, time.Minute),
+	}
+
+	called := false
+	fn := func(ctx context.Context, fence int64) error {
+		called = true
+		return nil
+	}
+
+	assert.ErrorIs(t, lock.WithLock(ctx, "key", fn), ErrLockUnavailable)
+	assert.Equal(t, BreakerOpen, lock.breaker.status().State)
+
+	// rejected by the open breaker without reaching the lock
+	lock.lock = lockFunc(func(ctx context.Context, fn func(ctx context.Context, fence int64) error) error {
+		return fn(ctx, 1)
+	})
+	assert.ErrorIs(t, lock.WithLock(ctx, "key", fn), ErrLockUnavailable)
+	assert.False(t, called)
+}
+
+// lockFunc is an ILock running the given function on every WithLock.
+type lockFunc func(ctx context.Context, fn func(ctx context.Context, fence int64) error) error
+
+func (f lockFunc) WithLock(ctx context.Context, _ string, fn func(ctx context.Context, fence int64) error, _ ...LockOption) error {
+	return f(ctx, fn)
+}
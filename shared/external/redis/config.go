@@ -1,5 +1,10 @@
 package redis
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Config - Config struct for redis
 type config struct {
 	Host                  string
@@ -13,4 +18,16 @@ type config struct {
 	WriteTimeoutSeconds   int
 	UseTLS                *bool
 	TLSSkipVerify         *bool
+
+	// Sentinel discovers the master instead of Host and Port, when it is set.
+	Sentinel *sentinelConfig
+}
+
+// address is where redis is reached, for logging.
+func (c *config) address() string {
+	if c.Sentinel != nil {
+		return fmt.Sprintf("master %s of sentinels %s", c.Sentinel.MasterName, strings.Join(c.Sentinel.Addresses, ","))
+	}
+
+	return fmt.Sprintf("%v:%v", c.Host, c.Port)
 }
@@ -5,6 +5,7 @@ import (
 	sharedErrs "coupon_be/shared/errors"
 	"coupon_be/util/logger"
 	"fmt"
+	"net"
 	"time"
 
 	"github.com/gomodule/redigo/redis"
@@ -24,10 +25,25 @@ var (
 
 type Pool struct {
 	*redis.Pool
+
+	// sentinel follows the failovers of the master, nil when redis is dialled directly
+	sentinel *sentinel
 }
 
 // ConnectRedis creates and returns a new Redis connection with the given configuration.
 func connectRedis(ctx context.Context, config *config) (*Pool, error) {
+	conn := newPool(config)
+	if _, err := conn.ping(); err != nil {
+		return nil, sharedErrs.Wrap(err, "redis ping")
+	}
+
+	logger.Info(ctx, "Redis connected successfully at %v", config.address())
+
+	return conn, nil
+}
+
+// newPool creates a Redis connection pool with the given configuration, without connecting to Redis yet.
+func newPool(config *config) *Pool {
 	if config.ConnectTimeoutSeconds == 0 {
 		config.ConnectTimeoutSeconds = defaultConnectTimeoutSeconds
 	}
@@ -44,35 +60,64 @@ func connectRedis(ctx context.Context, config *config) (*Pool, error) {
 		config.TLSSkipVerify = &defaultTLSSkipVerify
 	}
 
+	options := []redis.DialOption{
+		redis.DialPassword(config.Password),
+		redis.DialUseTLS(*config.UseTLS),
+		redis.DialTLSSkipVerify(*config.TLSSkipVerify),
+		redis.DialConnectTimeout(time.Duration(config.ConnectTimeoutSeconds) * time.Second),
+		redis.DialReadTimeout(time.Duration(config.ReadTimeoutSeconds) * time.Second),
+		redis.DialWriteTimeout(time.Duration(config.WriteTimeoutSeconds) * time.Second),
+	}
+
+	dial := func(address string) (redis.Conn, error) {
+		c, err := redis.Dial(connectionType, address, options...)
+		if err != nil {
+			msg := fmt.Sprintf("Error connecting to redis at %v", address)
+			return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, msg, err)
+		}
+		return c, err
+	}
+
 	pool := &redis.Pool{
 		MaxIdle:     config.MaxIdleConnections,
 		MaxActive:   config.MaxActiveConnections,
 		IdleTimeout: time.Duration(config.IdleTimeout) * time.Second,
 		Dial: func() (redis.Conn, error) {
-			c, err := redis.Dial(connectionType, fmt.Sprintf("%v:%v", config.Host, config.Port),
-				redis.DialPassword(config.Password),
-				redis.DialUseTLS(*config.UseTLS),
-				redis.DialTLSSkipVerify(*config.TLSSkipVerify),
-				redis.DialConnectTimeout(time.Duration(config.ConnectTimeoutSeconds)*time.Second),
-				redis.DialReadTimeout(time.Duration(config.ReadTimeoutSeconds)*time.Second),
-				redis.DialWriteTimeout(time.Duration(config.WriteTimeoutSeconds)*time.Second),
-			)
-			if err != nil {
-				msg := fmt.Sprintf("Error connecting to redis at %v:%v", config.Host, config.Port)
-				return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, msg, err)
-			}
-			return c, err
+			return dial(net.JoinHostPort(config.Host, config.Port))
 		},
 	}
 
-	conn := &Pool{pool}
-	if _, err := conn.ping(); err != nil {
-		return nil, sharedErrs.Wrap(err, "redis ping")
+	if config.Sentinel == nil {
+		return &Pool{Pool: pool}
 	}
 
-	logger.Info(ctx, "Redis connected successfully at %v:%v", config.Host, config.Port)
+	// the sentinels are dialled with the timeouts of redis, but without its password and tls
+	s := newSentinel(config.Sentinel,
+		redis.DialConnectTimeout(time.Duration(config.ConnectTimeoutSeconds)*time.Second),
+		redis.DialReadTimeout(time.Duration(config.ReadTimeoutSeconds)*time.Second),
+		redis.DialWriteTimeout(time.Duration(config.WriteTimeoutSeconds)*time.Second),
+	)
+	pool.Dial = func() (redis.Conn, error) {
+		return s.dial(dial)
+	}
+	pool.TestOnBorrow = func(c redis.Conn, _ time.Time) error {
+		if !s.current(c) {
+			return errStaleMaster
+		}
+		return nil
+	}
+	s.watch()
 
-	return conn, nil
+	return &Pool{Pool: pool, sentinel: s}
+}
+
+// Close closes the connections of the pool, and stops following the failovers of the master.
+func (r *Pool) Close() error {
+	if r.sentinel != nil {
+		r.sentinel.close()
+	}
+
+	return r.Pool.Close()
 }
 
 // Ping is a utility to ping a Redis server to verify that the connection is created successfully.
@@ -86,6 +131,8 @@ func (r *Pool) ping() (string, error) {
 		return "", sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to dial to redis", err)
 	}
 
+	defer conn.Close()
+
 	resp, err := conn.Do("ping")
 	if err != nil {
 		return "", sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to ping redis", err)
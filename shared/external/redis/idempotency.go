@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/logger"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+//go:generate mockgen -package mock -source=idempotency.go -destination=../../../mock/redis_idempotency.go *
+
+const idempotencyKeyPrefix = "idempotency:"
+
+// reserveScript stores the in-flight record unless the key exists, and returns the existing record otherwise.
+//
+// KEYS[1] idempotency key
+// ARGV[1] in-flight record, ARGV[2] ttl in milliseconds
+var reserveScript = redis.NewScript(1, `
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return false
+end
+return redis.call('GET', KEYS[1])
+`)
+
+// IdempotentRecord is the state of a request under an idempotency key.
+type IdempotentRecord struct {
+	// Fingerprint identifies the request body the key is first used with.
+	Fingerprint string `json:"fingerprint"`
+	// Completed is false while the first request is still in flight.
+	Completed bool              `json:"completed"`
+	Status    int               `json:"status,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      []byte            `json:"body,omitempty"`
+}
+
+// IIdempotencyStore - interface for storing the first response of a request per idempotency key
+type IIdempotencyStore interface {
+	// Reserve marks the key as in flight and returns nil, or returns the existing record when the key is already used.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotentRecord, error)
+	// Complete stores the response of the request reserving the key.
+	Complete(ctx context.Context, key string, record *IdempotentRecord, ttl time.Duration) error
+	// Release removes the key, so the request can be retried with it.
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyStore - redis implementation of IIdempotencyStore
+type IdempotencyStore struct {
+	pool *Pool
+}
+
+func (s *IdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotentRecord, error) {
+	inFlight, err := json.Marshal(&IdempotentRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to encode idempotent record", err)
+	}
+
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	existing, err := redis.Bytes(reserveScript.Do(conn, Key(idempotencyKeyPrefix+key), inFlight, ttl.Milliseconds()))
+	if errors.Is(err, redis.ErrNil) {
+		logger.Debug(ctx, "idempotency key %s is reserved", key)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to reserve idempotency key", err)
+	}
+
+	var record IdempotentRecord
+	if err = json.Unmarshal(existing, &record); err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to decode idempotent record", err)
+	}
+
+	return &record, nil
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, record *IdempotentRecord, ttl time.Duration) error {
+	record.Completed = true
+
+	value, err := json.Marshal(record)
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to encode idempotent record", err)
+	}
+
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.Do("SET", Key(idempotencyKeyPrefix+key), value, "PX", ttl.Milliseconds()); err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to store idempotent record", err)
+	}
+
+	return nil
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.Do("DEL", Key(idempotencyKeyPrefix+key)); err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to release idempotency key", err)
+	}
+
+	return nil
+}
+
+// newIdempotencyStore Provider/Factory function to return a redis idempotency store
+func newIdempotencyStore(ctx context.Context, pool *Pool) (IIdempotencyStore, error) {
+	if pool == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindRedis, "Redis pool is nil")
+	}
+
+	logger.Debug(ctx, "Redis idempotency store initialised successfully")
+
+	return &IdempotencyStore{pool: pool}, nil
+}
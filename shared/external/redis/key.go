@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	uConfig "coupon_be/util/config"
+	"coupon_be/util/logger"
+	"strings"
+	"sync"
+)
+
+const namespaceSeparator = ":"
+
+var (
+	keys     *KeyBuilder
+	keysOnce sync.Once
+)
+
+// KeyBuilder prefixes the redis keys and channels with a namespace, so the apps, environments and tenants sharing a
+// redis do not share their keys.
+type KeyBuilder struct {
+	namespace string
+}
+
+// NewKeyBuilder returns a key builder for the namespace of the given parts, e.g. the app name, env and tenant. The
+// parts are lower-cased with their spaces replaced by dashes, and the empty ones are skipped.
+func NewKeyBuilder(parts ...string) *KeyBuilder {
+	namespace := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.Join(strings.Fields(strings.ToLower(part)), "-"); part != "" {
+			namespace = append(namespace, part)
+		}
+	}
+
+	return &KeyBuilder{namespace: strings.Join(namespace, namespaceSeparator)}
+}
+
+// Namespace returns the namespace of the keys, empty when they are not namespaced.
+func (b *KeyBuilder) Namespace() string {
+	return b.namespace
+}
+
+// Key returns the key in the namespace.
+func (b *KeyBuilder) Key(key string) string {
+	if b.namespace == "" {
+		return key
+	}
+
+	return b.namespace + namespaceSeparator + key
+}
+
+// Keys - Returns the key builder of the configured namespace, the keys are not namespaced unless it is configured
+func Keys() *KeyBuilder {
+	keysOnce.Do(func() {
+		keys = NewKeyBuilder(namespaceOf(uConfig.Env())...)
+	})
+
+	return keys
+}
+
+// Key - Returns the key in the configured namespace. Every redis key and channel of the app is built by it.
+func Key(key string) string {
+	return Keys().Key(key)
+}
+
+// CheckNamespace logs the namespace of the redis keys at startup.
+func CheckNamespace(ctx context.Context) {
+	namespace := Keys().Namespace()
+	if namespace == "" {
+		logger.Warn(ctx, "Redis keys are not namespaced, the apps and environments sharing the redis share their keys")
+		return
+	}
+
+	logger.Info(ctx, "Redis keys are namespaced with %s%s", namespace, namespaceSeparator)
+}
+
+// namespaceOf returns the configured namespace. It is opt-in, since namespacing the keys of a running deployment
+// moves every key, which orphans the stock not flushed to postgres yet and the claims still queued.
+func namespaceOf(c *uConfig.Config) []string {
+	if c == nil {
+		return nil
+	}
+
+	namespace := c.Redis.Namespace
+
+	return []string{namespace.App, namespace.Env, namespace.Tenant}
+}
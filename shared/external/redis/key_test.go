@@ -0,0 +1,57 @@
+package redis
+
+import (
+	uConfig "coupon_be/util/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyBuilder_Key(t *testing.T) {
+	tests := []struct {
+		name              string
+		parts             []string
+		expectedNamespace string
+		expectedKey       string
+	}{
+		{
+			name:              "app and env",
+			parts:             []string{"COUPON API", "dev", ""},
+			expectedNamespace: "coupon-api:dev",
+			expectedKey:       "coupon-api:dev:lock:claim:coupon:A",
+		},
+		{
+			name:              "tenant",
+			parts:             []string{"coupon", "staging", "Tenant 1"},
+			expectedNamespace: "coupon:staging:tenant-1",
+			expectedKey:       "coupon:staging:tenant-1:lock:claim:coupon:A",
+		},
+		{
+			name:              "no namespace",
+			parts:             []string{"", " "},
+			expectedNamespace: "",
+			expectedKey:       "lock:claim:coupon:A",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			keys := NewKeyBuilder(tc.parts...)
+
+			assert.Equal(t, tc.expectedNamespace, keys.Namespace())
+			assert.Equal(t, tc.expectedKey, keys.Key("lock:claim:coupon:A"))
+		})
+	}
+}
+
+func TestNamespaceOf(t *testing.T) {
+	c := &uConfig.Config{App: uConfig.AppConfig{Name: "COUPON API", Env: "dev"}}
+
+	// opt-in, the app name and env are not used unless configured
+	assert.Empty(t, NewKeyBuilder(namespaceOf(c)...).Namespace())
+
+	c.Redis.Namespace = uConfig.RedisNamespaceConfig{App: "coupon", Env: "staging", Tenant: "acme"}
+	assert.Equal(t, []string{"coupon", "staging", "acme"}, namespaceOf(c))
+
+	assert.Nil(t, namespaceOf(nil))
+}
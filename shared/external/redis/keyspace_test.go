@@ -0,0 +1,441 @@
+package redis
+
+import (
+	"cmp"
+	"coupon_be/shared/external/redis/redistest"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// keyspace is the state behind a RESP stand-in of redis, with the strings, sets and sorted sets the app keeps, their
+// expiries and the lua scripts run on them. Its clock only moves when advanced, and the scripts are run by gopher-lua,
+// so the lua scripts of the app are tested as redis runs them.
+type keyspace struct {
+	mu       sync.Mutex
+	now      time.Time
+	strings  map[string]string
+	sets     map[string]map[string]bool
+	zsets    map[string]map[string]float64
+	expiries map[string]time.Time
+	scripts  map[string]string
+}
+
+func newKeyspace() *keyspace {
+	return &keyspace{
+		now:      time.UnixMilli(1_700_000_000_000),
+		strings:  make(map[string]string),
+		sets:     make(map[string]map[string]bool),
+		zsets:    make(map[string]map[string]float64),
+		expiries: make(map[string]time.Time),
+		scripts:  make(map[string]string),
+	}
+}
+
+// newKeyspaceServer returns a RESP stand-in serving a new keyspace.
+func newKeyspaceServer(t *testing.T) (*redistest.Server, *keyspace) {
+	k := newKeyspace()
+
+	return redistest.NewServer(t, k.handle), k
+}
+
+// newKeyspacePool returns a pool of connections to the stand-in.
+func newKeyspacePool(s *redistest.Server) *Pool {
+	host, port := s.HostPort()
+	useTLS := false
+
+	return newPool(&config{Host: host, Port: port, MaxIdleConnections: 2, UseTLS: &useTLS})
+}
+
+// advance moves the clock of the keyspace, expiring the keys whose ttl passes.
+func (k *keyspace) advance(d time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.now = k.now.Add(d)
+}
+
+// get returns the string value of the key, empty when it does not exist.
+func (k *keyspace) get(key string) string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.expire(key)
+
+	return k.strings[key]
+}
+
+func (k *keyspace) handle(args []string) any {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.do(args)
+}
+
+func (k *keyspace) expire(key string) {
+	if expiry, ok := k.expiries[key]; ok && !k.now.Before(expiry) {
+		k.del(key)
+	}
+}
+
+func (k *keyspace) exists(key string) bool {
+	k.expire(key)
+
+	_, isString := k.strings[key]
+	_, isSet := k.sets[key]
+	_, isZSet := k.zsets[key]
+
+	return isString || isSet || isZSet
+}
+
+func (k *keyspace) del(key string) bool {
+	_, existed := k.strings[key]
+	existed = existed || k.sets[key] != nil || k.zsets[key] != nil
+	delete(k.strings, key)
+	delete(k.sets, key)
+	delete(k.zsets, key)
+	delete(k.expiries, key)
+
+	return existed
+}
+
+// do runs a command, with the keyspace locked.
+func (k *keyspace) do(args []string) any {
+	name := strings.ToUpper(args[0])
+	if name != "TIME" && name != "PING" && name != "EVAL" && name != "EVALSHA" && name != "SCRIPT" && len(args) > 1 {
+		k.expire(args[1])
+	}
+
+	switch name {
+	case "PING":
+		return redistest.Status("PONG")
+	case "TIME":
+		micros := k.now.UnixMicro()
+		return []any{strconv.FormatInt(micros/1_000_000, 10), strconv.FormatInt(micros%1_000_000, 10)}
+	case "GET":
+		value, ok := k.strings[args[1]]
+		if !ok {
+			return nil
+		}
+		return value
+	case "MGET":
+		values := make([]any, len(args)-1)
+		for i, key := range args[1:] {
+			k.expire(key)
+			if value, ok := k.strings[key]; ok {
+				values[i] = value
+			}
+		}
+		return values
+	case "SET":
+		return k.set(args)
+	case "DEL":
+		var deleted int64
+		for _, key := range args[1:] {
+			k.expire(key)
+			if k.del(key) {
+				deleted++
+			}
+		}
+		return deleted
+	case "EXISTS":
+		if k.exists(args[1]) {
+			return int64(1)
+		}
+		return int64(0)
+	case "INCR", "DECR":
+		value, err := strconv.ParseInt(cmp.Or(k.strings[args[1]], "0"), 10, 64)
+		if err != nil {
+			return redistest.Error("ERR value is not an integer or out of range")
+		}
+		if name == "INCR" {
+			value++
+		} else {
+			value--
+		}
+		k.strings[args[1]] = strconv.FormatInt(value, 10)
+		return value
+	case "PTTL":
+		if !k.exists(args[1]) {
+			return int64(-2)
+		}
+		expiry, ok := k.expiries[args[1]]
+		if !ok {
+			return int64(-1)
+		}
+		return expiry.Sub(k.now).Milliseconds()
+	case "PEXPIRE":
+		if !k.exists(args[1]) {
+			return int64(0)
+		}
+		ttl, _ := strconv.ParseInt(args[2], 10, 64)
+		k.expiries[args[1]] = k.now.Add(time.Duration(ttl) * time.Millisecond)
+		return int64(1)
+	case "SADD":
+		if k.sets[args[1]] == nil {
+			k.sets[args[1]] = make(map[string]bool)
+		}
+		var added int64
+		for _, member := range args[2:] {
+			if !k.sets[args[1]][member] {
+				k.sets[args[1]][member] = true
+				added++
+			}
+		}
+		return added
+	case "SISMEMBER":
+		if k.sets[args[1]][args[2]] {
+			return int64(1)
+		}
+		return int64(0)
+	case "ZADD":
+		if k.zsets[args[1]] == nil {
+			k.zsets[args[1]] = make(map[string]float64)
+		}
+		var added int64
+		for i := 2; i+1 < len(args); i += 2 {
+			score, _ := strconv.ParseFloat(args[i], 64)
+			if _, ok := k.zsets[args[1]][args[i+1]]; !ok {
+				added++
+			}
+			k.zsets[args[1]][args[i+1]] = score
+		}
+		return added
+	case "ZCARD":
+		return int64(len(k.zsets[args[1]]))
+	case "ZSCORE":
+		score, ok := k.zsets[args[1]][args[2]]
+		if !ok {
+			return nil
+		}
+		return strconv.FormatFloat(score, 'f', -1, 64)
+	case "ZREM":
+		var removed int64
+		for _, member := range args[2:] {
+			if _, ok := k.zsets[args[1]][member]; ok {
+				delete(k.zsets[args[1]], member)
+				removed++
+			}
+		}
+		k.dropEmptyZSet(args[1])
+		return removed
+	case "ZREMRANGEBYSCORE":
+		var removed int64
+		for member, score := range k.zsets[args[1]] {
+			if scoreAbove(score, args[2]) && scoreBelow(score, args[3]) {
+				delete(k.zsets[args[1]], member)
+				removed++
+			}
+		}
+		k.dropEmptyZSet(args[1])
+		return removed
+	case "SCRIPT":
+		if strings.ToUpper(args[1]) != "LOAD" {
+			return redistest.Error("ERR unknown SCRIPT subcommand")
+		}
+		return k.load(args[2])
+	case "EVAL":
+		return k.eval(k.load(args[1]), args[2:])
+	case "EVALSHA":
+		return k.eval(args[1], args[2:])
+	default:
+		return redistest.Error(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+}
+
+func (k *keyspace) set(args []string) any {
+	key, value := args[1], args[2]
+
+	var expiry time.Time
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			if k.exists(key) {
+				return nil
+			}
+		case "XX":
+			if !k.exists(key) {
+				return nil
+			}
+		case "PX", "EX":
+			ttl, _ := strconv.ParseInt(args[i+1], 10, 64)
+			unit := time.Millisecond
+			if strings.ToUpper(args[i]) == "EX" {
+				unit = time.Second
+			}
+			expiry = k.now.Add(time.Duration(ttl) * unit)
+			i++
+		}
+	}
+
+	k.del(key)
+	k.strings[key] = value
+	if !expiry.IsZero() {
+		k.expiries[key] = expiry
+	}
+
+	return redistest.Status("OK")
+}
+
+func (k *keyspace) dropEmptyZSet(key string) {
+	if len(k.zsets[key]) == 0 {
+		delete(k.zsets, key)
+		delete(k.expiries, key)
+	}
+}
+
+func (k *keyspace) load(script string) string {
+	sum := sha1.Sum([]byte(script))
+	sha := hex.EncodeToString(sum[:])
+	k.scripts[sha] = script
+
+	return sha
+}
+
+// eval runs the script of the sha with the given numkeys, keys and args, as redis does.
+func (k *keyspace) eval(sha string, args []string) any {
+	script, ok := k.scripts[sha]
+	if !ok {
+		return redistest.Error("NOSCRIPT No matching script. Please use EVAL.")
+	}
+
+	count, _ := strconv.Atoi(args[0])
+
+	state := lua.NewState()
+	defer state.Close()
+
+	state.SetGlobal("KEYS", luaArray(state, args[1:1+count]))
+	state.SetGlobal("ARGV", luaArray(state, args[1+count:]))
+
+	redisTable := state.NewTable()
+	state.SetField(redisTable, "call", state.NewFunction(func(L *lua.LState) int {
+		command := make([]string, L.GetTop())
+		for i := range command {
+			command[i] = lua.LVAsString(L.Get(i + 1))
+		}
+
+		reply := k.do(command)
+		if e, ok := reply.(redistest.Error); ok {
+			L.RaiseError("%s", string(e))
+		}
+
+		L.Push(toLua(L, reply))
+		return 1
+	}))
+	// the scripts are replicated by their effects anyway, as redis 7 does
+	state.SetField(redisTable, "replicate_commands", state.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LTrue)
+		return 1
+	}))
+	state.SetGlobal("redis", redisTable)
+
+	if err := state.DoString(script); err != nil {
+		return redistest.Error("ERR " + err.Error())
+	}
+
+	return fromLua(state.Get(-1))
+}
+
+func luaArray(L *lua.LState, values []string) *lua.LTable {
+	table := L.NewTable()
+	for _, value := range values {
+		table.Append(lua.LString(value))
+	}
+
+	return table
+}
+
+// toLua converts a redis reply as redis does for redis.call: nil is false, and a status is a table with an ok field.
+func toLua(L *lua.LState, reply any) lua.LValue {
+	switch v := reply.(type) {
+	case nil:
+		return lua.LFalse
+	case int64:
+		return lua.LNumber(v)
+	case string:
+		return lua.LString(v)
+	case redistest.Status:
+		table := L.NewTable()
+		L.SetField(table, "ok", lua.LString(v))
+		return table
+	case []any:
+		table := L.NewTable()
+		for _, item := range v {
+			table.Append(toLua(L, item))
+		}
+		return table
+	default:
+		panic(fmt.Sprintf("unexpected reply %v", reply))
+	}
+}
+
+// fromLua converts the result of a script as redis does: numbers are truncated to integers, and false is nil.
+func fromLua(value lua.LValue) any {
+	switch v := value.(type) {
+	case lua.LNumber:
+		return int64(v)
+	case lua.LString:
+		return string(v)
+	case lua.LBool:
+		if v {
+			return int64(1)
+		}
+		return nil
+	case *lua.LTable:
+		if ok := v.RawGetString("ok"); ok != lua.LNil {
+			return redistest.Status(lua.LVAsString(ok))
+		}
+		if err := v.RawGetString("err"); err != lua.LNil {
+			return redistest.Error(lua.LVAsString(err))
+		}
+		var items []any
+		for i := 1; ; i++ {
+			item := v.RawGetInt(i)
+			if item == lua.LNil {
+				break
+			}
+			items = append(items, fromLua(item))
+		}
+		return items
+	default:
+		return nil
+	}
+}
+
+// scoreAbove reports whether the score is above the min of a ZRANGEBYSCORE range, e.g. -inf, (5 or 5.
+func scoreAbove(score float64, min string) bool {
+	if strings.HasPrefix(min, "(") {
+		bound, _ := strconv.ParseFloat(min[1:], 64)
+		return score > bound
+	}
+
+	if min == "-inf" {
+		return true
+	}
+
+	bound, _ := strconv.ParseFloat(min, 64)
+
+	return score >= bound
+}
+
+// scoreBelow reports whether the score is below the max of a ZRANGEBYSCORE range, e.g. +inf, (5 or 5.
+func scoreBelow(score float64, max string) bool {
+	if max == "+inf" || max == "inf" {
+		return true
+	}
+	if strings.HasPrefix(max, "(") {
+		bound, _ := strconv.ParseFloat(max[1:], 64)
+		return score < bound
+	}
+
+	bound, _ := strconv.ParseFloat(max, 64)
+
+	return score <= bound
+}
@@ -4,50 +4,49 @@ import (
 	"context"
 	sharedErrs "coupon_be/shared/errors"
 	"coupon_be/util/logger"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-redsync/redsync"
 )
 
+//go:generate mockgen -package mock -source=lock.go -destination=../../../mock/redis_lock.go *
+
 const (
 	redisLockExpiry       = 20  // in seconds
 	redisLockRetryDelay   = 500 // in miliseconds
 	redisLockMaxNoOfRetry = 3
+	redisLockRetryJitter  = 100 // in miliseconds
+	// lockDriftFactor is the share of the expiry redsync allows for the clock drift between the nodes.
+	lockDriftFactor = 0.01
+
+	fenceKeyPrefix = "fence:"
 )
 
+// ErrLockLeaseLost is returned by WithLock when the lease of the lock expired before fn finished, so fn might have run
+// concurrently with another holder of the lock. A lease expiring after fn finished is not reported, since fn has
+// committed while holding the lock.
+var ErrLockLeaseLost = sharedErrs.New(sharedErrs.ErrKindRedis, "Redis lock lease is lost before the critical section finished")
+
 // Lock RedisLock - redis implementation for distributed locking
 type Lock struct {
+	fencer  *fencer
 	rSync   *redsync.Redsync
 	options *lockOptions
 }
 
 // ILock - interface for distributed locking
 type ILock interface {
-	WithLock(ctx context.Context, key string, fn func() error, options ...LockOption) error
-}
-
-func getLock(key string, rSync *redsync.Redsync, option *lockOptions) (*redsync.Mutex, error) {
-	if rSync == nil {
-		return nil, sharedErrs.New(sharedErrs.ErrKindRedis, "Redis pool is nil")
-	}
-
-	var lockOptions []redsync.Option
-
-	expiryOpt := redsync.SetExpiry(option.expiry)
-	triesOpt := redsync.SetTries(option.retriesCount)
-	retryDelayOpt := redsync.SetRetryDelay(option.retryDelay)
-	lockOptions = append(lockOptions, expiryOpt, triesOpt, retryDelayOpt)
-
-	mutex := rSync.NewMutex(key, lockOptions...)
-
-	if err := mutex.Lock(); err != nil {
-		return nil, sharedErrs.New(sharedErrs.ErrKindAcquireRedisLock, "Error acquiring redis lock: %v", err)
-	}
-
-	return mutex, nil
+	// WithLock runs fn while holding the lock of key. The context passed to fn is cancelled when the lease can not be
+	// renewed, and ErrLockLeaseLost is returned whenever the lease is lost before fn finished.
+	//
+	// fn receives the fencing token of the acquisition, which increases on every acquisition of key. Writes guarded by
+	// the lock should carry the token, so the store can reject writes of a holder whose lease has expired.
+	WithLock(ctx context.Context, key string, fn func(ctx context.Context, fence int64) error, options ...LockOption) error
 }
 
 // WithLock - Wrapper locking function for redis
-func (l *Lock) WithLock(ctx context.Context, key string, fn func() error, options ...LockOption) error {
+func (l *Lock) WithLock(ctx context.Context, key string, fn func(ctx context.Context, fence int64) error, options ...LockOption) error {
 	if l == nil {
 		return sharedErrs.New(sharedErrs.ErrKindRedis, "Lock object is nil")
 	}
@@ -57,32 +56,193 @@ func (l *Lock) WithLock(ctx context.Context, key string, fn func() error, option
 		return sharedErrs.Wrap(err, "WithLock applying option")
 	}
 
-	mutex, err := getLock(key, l.rSync, opts)
+	var lease lease
+
+	mutex, err := l.acquire(ctx, key, opts, &lease)
 	if err != nil {
-		return sharedErrs.New(sharedErrs.ErrKindAcquireRedisLock, "Error acquiring redis lock: %v", err)
+		return err
+	}
+
+	fence, err := l.fencer.next(ctx, key)
+	if err != nil {
+		if _, unlockErr := mutex.Unlock(); unlockErr != nil {
+			logger.Error(ctx, "Error while unlock %v", unlockErr)
+		}
+
+		logger.Error(ctx, "Failed to issue fencing token of lock %v: %v", key, err)
+
+		return ErrLockUnavailable
 	}
 
-	defer func() {
-		_, err = mutex.Unlock()
-		if err != nil {
-			logger.Error(ctx, "Error while unlock %v", err)
+	// the token is issued after the acquisition, so a holder pausing in between might get a token above the one of the
+	// next holder; it is only handed to fn while the lease is still held, i.e. before any next holder read its token
+	if !lease.heldAt(time.Now()) {
+		if _, unlockErr := mutex.Unlock(); unlockErr != nil {
+			logger.Error(ctx, "Error while unlock %v", unlockErr)
 		}
 
+		logger.Error(ctx, "Lease of lock %v is lost while issuing its fencing token %d", key, fence)
+
+		return ErrLockLeaseLost
+	}
+
+	logger.Debug(ctx, "Acquired lock for key %v with fencing token %d", key, fence)
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stopWatchdog := func() {}
+	if opts.autoExtend {
+		stopWatchdog = l.watch(lockCtx, key, mutex, opts.expiry, &lease, cancel)
+	}
+
+	err = fn(lockCtx, fence)
+	// fn has committed or given up by now, so the lease only matters up to here
+	finishedAt := time.Now()
+	stopWatchdog()
+
+	leaseLost := !lease.heldAt(finishedAt)
+
+	released, unlockErr := mutex.Unlock()
+	if unlockErr != nil {
+		logger.Error(ctx, "Error while unlock %v", unlockErr)
+	} else if !released && !leaseLost {
+		// the key expired, or was taken by another owner, after fn finished
+		logger.Warn(ctx, "Lock %v is no longer held at release, after the critical section finished", key)
+	} else if released {
 		logger.Debug(ctx, "Lock released successfully for key %v", key)
+	}
+
+	if leaseLost {
+		logger.Error(ctx, "Lease of lock %v is lost before the critical section finished, error: %v", key, err)
+		return ErrLockLeaseLost
+	}
+
+	return err
+}
+
+// lease tracks until when the lock is held for sure, i.e. the expiry counted from the start of the last acquisition or
+// renewal reaching a quorum, less the clock drift redsync allows for.
+type lease struct {
+	until atomic.Int64
+}
+
+// renew records the lease of an acquisition or renewal sent at start.
+func (l *lease) renew(start time.Time, expiry time.Duration) {
+	l.until.Store(start.Add(expiry - time.Duration(float64(expiry)*lockDriftFactor)).UnixNano())
+}
+
+// heldAt reports whether the lease has not expired at t.
+func (l *lease) heldAt(t time.Time) bool {
+	return t.UnixNano() < l.until.Load()
+}
+
+// acquire takes the mutex of key, retrying with the delays of opts until the tries are exhausted or ctx is done, and
+// records its lease.
+func (l *Lock) acquire(ctx context.Context, key string, opts *lockOptions, lease *lease) (*redsync.Mutex, error) {
+	if l.rSync == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindRedis, "Redis pool is nil")
+	}
+
+	// every try is driven here rather than by redsync, whose retries can not be interrupted
+	// the value of the key records the holder, so the held locks can be inspected
+	mutex := l.rSync.NewMutex(Key(lockKeyPrefix+key), redsync.SetExpiry(opts.expiry), redsync.SetTries(1),
+		redsync.SetGenValueFunc(func() (string, error) { return lockHolderValue(ctx), nil }))
+	tries := max(opts.retriesCount, 1)
+
+	var err error
+	for try := 1; ; try++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, lockCancelledErr(ctx, key, ctxErr)
+		}
+
+		start := time.Now()
+		if err = mutex.Lock(); err == nil {
+			lease.renew(start, opts.expiry)
+			return mutex, nil
+		}
+
+		if try >= tries {
+			break
+		}
+
+		if ctxErr := waitRetry(ctx, opts.delayBefore(try)); ctxErr != nil {
+			return nil, lockCancelledErr(ctx, key, ctxErr)
+		}
+	}
+
+	// tell a lock held by others apart from lock nodes which can not be reached
+	if !l.reachable(ctx) {
+		logger.Error(ctx, "Failed to acquire lock %v, a quorum of lock nodes is unreachable: %v", key, err)
+		return nil, ErrLockUnavailable
+	}
+
+	return nil, sharedErrs.New(sharedErrs.ErrKindAcquireRedisLock, "Error acquiring redis lock: %v", err)
+}
+
+// lockCancelledErr is returned when ctx is done while waiting for a lock, e.g. the client is gone or the request
+// deadline has passed.
+func lockCancelledErr(ctx context.Context, key string, err error) error {
+	logger.Warn(ctx, "Stopped waiting for lock %v: %v", key, err)
+
+	return sharedErrs.NewWithCause(sharedErrs.ErrKindLockCancelled, "Request is cancelled while waiting for the lock", err)
+}
+
+// watch renews the lease of the mutex every third of the expiry until the returned stop function is called, and calls
+// onLost once a renewal fails.
+func (l *Lock) watch(ctx context.Context, key string, mutex *redsync.Mutex, expiry time.Duration, lease *lease,
+	onLost func()) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(expiry / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				start := time.Now()
+				extended, err := mutex.Extend()
+				if err != nil || !extended {
+					logger.Error(ctx, "Failed to extend lease of lock %v: %v", key, err)
+					onLost()
+					return
+				}
+
+				lease.renew(start, expiry)
+				logger.Debug(ctx, "Extended lease of lock %v", key)
+			}
+		}
 	}()
 
-	logger.Debug(ctx, "Acquired lock for key %v", key)
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
 
-	if err = fn(); err != nil {
-		return err
+// reachable reports whether a quorum of the lock nodes answers a ping.
+func (l *Lock) reachable(ctx context.Context) bool {
+	healthy := 0
+	for _, health := range lockNodesHealth(ctx, l.fencer.nodes) {
+		if health.Healthy {
+			healthy++
+		}
 	}
 
-	return nil
+	return healthy >= quorum(len(l.fencer.nodes))
 }
 
-// newLock Provider/Factory function to return a redis lock struct
-func newLock(ctx context.Context, pool *Pool, options ...LockOption) (ILock, error) {
-	if pool == nil {
+// newLock Provider/Factory function to return a redis lock struct, which reaches a quorum across the given nodes
+func newLock(ctx context.Context, nodes []*lockNode, options ...LockOption) (ILock, error) {
+	if len(nodes) == 0 {
 		return nil, sharedErrs.New(sharedErrs.ErrKindRedis, "Redis pool is nil")
 	}
 
@@ -91,10 +251,20 @@ func newLock(ctx context.Context, pool *Pool, options ...LockOption) (ILock, err
 		return nil, sharedErrs.Wrap(err, "initialising lock")
 	}
 
-	logger.Debug(ctx, "Redis lock initialised successfully")
+	pools := make([]redsync.Pool, len(nodes))
+	for i, node := range nodes {
+		if node.pool == nil {
+			return nil, sharedErrs.New(sharedErrs.ErrKindRedis, "Redis pool is nil")
+		}
+
+		pools[i] = node.pool.Pool
+	}
+
+	logger.Debug(ctx, "Redis lock initialised successfully with %d nodes", len(nodes))
 
 	return &Lock{
-		rSync:   redsync.New([]redsync.Pool{pool.Pool}),
+		fencer:  &fencer{nodes: nodes, keys: Keys()},
+		rSync:   redsync.New(pools),
 		options: opts,
 	}, nil
 }
@@ -0,0 +1,305 @@
+package redis
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	uConfig "coupon_be/util/config"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -package mock -source=lock_admin.go -destination=../../../mock/redis_lock_admin.go *
+
+const (
+	lockKeyPrefix = "lock:"
+
+	// lockHolderSeparator separates the instance, correlation id and random part of the value of a lock key.
+	lockHolderSeparator = "|"
+)
+
+// lockInstance identifies this instance in the holders of the locks.
+var lockInstance = instanceName()
+
+// lockInfoScript returns the value and remaining ttl in milliseconds of a lock key.
+//
+// KEYS[1] lock key
+var lockInfoScript = redis.NewScript(1, `
+return {redis.call('GET', KEYS[1]), redis.call('PTTL', KEYS[1])}
+`)
+
+// forceReleaseScript deletes a lock key whatever its holder, and returns the value and remaining ttl it had.
+//
+// KEYS[1] lock key
+var forceReleaseScript = redis.NewScript(1, `
+local value = redis.call('GET', KEYS[1])
+local ttl = redis.call('PTTL', KEYS[1])
+if value then
+	redis.call('DEL', KEYS[1])
+end
+return {value, ttl}
+`)
+
+// LockHolder identifies the holder of a lock, the instance and the request it acquired the lock for.
+type LockHolder struct {
+	Instance      string `json:"instance"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// HeldLock is a lock key which is currently held.
+type HeldLock struct {
+	Key    string     `json:"key"`
+	TTL    string     `json:"ttl"`
+	Holder LockHolder `json:"holder"`
+}
+
+// ILockInspector - interface for inspecting the held locks, and releasing the locks of crashed holders
+type ILockInspector interface {
+	// HeldLocks returns the held lock keys, sorted by key.
+	HeldLocks(ctx context.Context) ([]*HeldLock, error)
+	// ForceRelease releases the lock of key whoever holds it, and returns the released lock. The holder loses its lease
+	// on its next renewal, and its writes are rejected by the fencing token of the next holder.
+	ForceRelease(ctx context.Context, key string) (*HeldLock, error)
+}
+
+// lockInspector inspects the locks held across the lock nodes, a key is held when a quorum of nodes holds it.
+type lockInspector struct {
+	nodes []*lockNode
+}
+
+type nodeLock struct {
+	value string
+	ttl   time.Duration
+}
+
+func (i *lockInspector) HeldLocks(ctx context.Context) ([]*HeldLock, error) {
+	type heldValue struct {
+		nodes int
+		ttl   time.Duration
+	}
+
+	held := make(map[string]map[string]*heldValue)
+	answered := 0
+
+	for _, node := range i.nodes {
+		locks, err := node.locks(ctx)
+		if err != nil {
+			logger.Error(ctx, "Failed to list the locks of redis lock node %s: %v", node.address, err)
+			continue
+		}
+
+		answered++
+		for key, lock := range locks {
+			if held[key] == nil {
+				held[key] = make(map[string]*heldValue)
+			}
+
+			if v, ok := held[key][lock.value]; ok {
+				v.nodes++
+				v.ttl = min(v.ttl, lock.ttl)
+			} else {
+				held[key][lock.value] = &heldValue{nodes: 1, ttl: lock.ttl}
+			}
+		}
+	}
+
+	if answered < quorum(len(i.nodes)) {
+		return nil, ErrLockUnavailable
+	}
+
+	result := make([]*HeldLock, 0, len(held))
+	for key, values := range held {
+		for value, v := range values {
+			if v.nodes >= quorum(len(i.nodes)) {
+				result = append(result, &HeldLock{Key: key, TTL: v.ttl.String(), Holder: parseLockHolder(value)})
+			}
+		}
+	}
+
+	sort.Slice(result, func(a, b int) bool { return result[a].Key < result[b].Key })
+
+	return result, nil
+}
+
+func (i *lockInspector) ForceRelease(ctx context.Context, key string) (*HeldLock, error) {
+	var (
+		released *HeldLock
+		answered int
+		errs     []error
+	)
+
+	for _, node := range i.nodes {
+		lock, err := node.forceRelease(ctx, key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", node.address, err))
+			continue
+		}
+
+		answered++
+		if lock != nil && released == nil {
+			released = &HeldLock{Key: key, TTL: lock.ttl.String(), Holder: parseLockHolder(lock.value)}
+		}
+	}
+
+	if answered < quorum(len(i.nodes)) {
+		logger.Error(ctx, "Failed to force-release lock %s on a quorum of lock nodes: %v", key, errors.Join(errs...))
+		return nil, ErrLockUnavailable
+	}
+
+	if released == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindDataNotFound, "Lock %s is not held", key)
+	}
+
+	auditForceRelease(ctx, released)
+
+	return released, nil
+}
+
+// locks returns the lock keys of the node, by key without the lock prefix.
+func (n *lockNode) locks(ctx context.Context) (map[string]*nodeLock, error) {
+	conn, err := n.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	result := make(map[string]*nodeLock)
+
+	cursor := 0
+	for {
+		values, err := redis.Values(conn.Do("SCAN", cursor, "MATCH", Key(lockKeyPrefix)+"*", "COUNT", 100))
+		if err != nil {
+			return nil, err
+		}
+
+		if cursor, err = redis.Int(values[0], nil); err != nil {
+			return nil, err
+		}
+
+		keys, err := redis.Strings(values[1], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			lock, err := runLockScript(conn, lockInfoScript, key)
+			if err != nil {
+				return nil, err
+			}
+
+			// expired since it was scanned
+			if lock == nil {
+				continue
+			}
+
+			result[strings.TrimPrefix(key, Key(lockKeyPrefix))] = lock
+		}
+
+		if cursor == 0 {
+			return result, nil
+		}
+	}
+}
+
+// forceRelease deletes the lock key on the node, and returns the lock it held, nil when it is not held.
+func (n *lockNode) forceRelease(ctx context.Context, key string) (*nodeLock, error) {
+	conn, err := n.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return runLockScript(conn, forceReleaseScript, Key(lockKeyPrefix+key))
+}
+
+// runLockScript runs a script returning the value and remaining ttl of a lock key, and returns nil when the key is not
+// held.
+func runLockScript(conn redis.Conn, script *redis.Script, key string) (*nodeLock, error) {
+	values, err := redis.Values(script.Do(conn, key))
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := redis.String(values[0], nil)
+	if errors.Is(err, redis.ErrNil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := redis.Int64(values[1], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodeLock{value: value, ttl: time.Duration(ttl) * time.Millisecond}, nil
+}
+
+// auditForceRelease logs every force-release, with the holder it was taken from and the requester.
+func auditForceRelease(ctx context.Context, lock *HeldLock) {
+	logger.Warn(ctx, "AUDIT lock %s is force-released by user %d, it was held by instance %s for request %s with %s left",
+		lock.Key, constant.UserIDFromCtx(ctx), lock.Holder.Instance, lock.Holder.CorrelationID, lock.TTL)
+}
+
+// lockHolderValue returns a unique value of a lock key, which records this instance and the request of ctx as its
+// holder.
+func lockHolderValue(ctx context.Context) string {
+	return strings.Join([]string{lockInstance, constant.CorrelationIDFromCtx(ctx), uuid.NewString()}, lockHolderSeparator)
+}
+
+// parseLockHolder returns the holder recorded in the value of a lock key by lockHolderValue.
+func parseLockHolder(value string) LockHolder {
+	instance, rest, ok := strings.Cut(value, lockHolderSeparator)
+	if !ok {
+		return LockHolder{}
+	}
+
+	correlationID := rest
+	if i := strings.LastIndex(rest, lockHolderSeparator); i >= 0 {
+		correlationID = rest[:i]
+	}
+
+	return LockHolder{Instance: instance, CorrelationID: correlationID}
+}
+
+func instanceName() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// GetLockInspector - Returns the inspector of the locks of the configured backend
+func GetLockInspector(ctx context.Context) (ILockInspector, error) {
+	backend := uConfig.Env().Lock.Backend
+
+	switch backend {
+	case LockBackendRedis, "":
+		nodes, err := getLockNodes(ctx)
+		if err != nil {
+			return nil, sharedErrs.Wrap(err, "get redis lock nodes")
+		}
+
+		return &lockInspector{nodes: nodes}, nil
+	case LockBackendMemory:
+		lock, err := getMemoryLock()
+		if err != nil {
+			return nil, err
+		}
+
+		return lock, nil
+	default:
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "Unknown lock backend %s", backend)
+	}
+}
@@ -0,0 +1,54 @@
+package redis
+
+import (
+	"context"
+	"coupon_be/util/constant"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLockHolder(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected LockHolder
+	}{
+		{
+			name:     "holder",
+			value:    "host-1|request-1|random",
+			expected: LockHolder{Instance: "host-1", CorrelationID: "request-1"},
+		},
+		{
+			name:     "no correlation id",
+			value:    "host-1||random",
+			expected: LockHolder{Instance: "host-1"},
+		},
+		{
+			name:     "correlation id with separator",
+			value:    "host-1|request|1|random",
+			expected: LockHolder{Instance: "host-1", CorrelationID: "request|1"},
+		},
+		{
+			name:     "value without holder",
+			value:    "cmFuZG9t",
+			expected: LockHolder{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, parseLockHolder(tc.value))
+		})
+	}
+}
+
+func TestLockHolderValue(t *testing.T) {
+	ctx := context.WithValue(context.Background(), constant.XCorrelationIDKey, "request-1")
+
+	value := lockHolderValue(ctx)
+
+	assert.Equal(t, LockHolder{Instance: lockInstance, CorrelationID: "request-1"}, parseLockHolder(value))
+	// every acquisition has a value of its own
+	assert.NotEqual(t, value, lockHolderValue(ctx))
+}
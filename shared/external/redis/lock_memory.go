@@ -0,0 +1,242 @@
+package redis
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryLease is the lease of the current holder of a key.
+type memoryLease struct {
+	token     uint64
+	expiresAt time.Time
+	holder    LockHolder
+}
+
+// MemoryLock - in-process implementation of ILock, with the semantics of the redis lock within a single instance. It
+// is meant for running locally without redis and for tests, as it does not exclude the holders of other instances.
+type MemoryLock struct {
+	mu      sync.Mutex
+	leases  map[string]*memoryLease
+	fences  map[string]int64
+	tokens  uint64
+	options *lockOptions
+	now     func() time.Time
+}
+
+// NewMemoryLock returns an in-process lock with the given default options.
+func NewMemoryLock(options ...LockOption) (*MemoryLock, error) {
+	opts, err := getLockOptions(options...)
+	if err != nil {
+		return nil, sharedErrs.Wrap(err, "initialising lock")
+	}
+
+	return &MemoryLock{
+		leases:  make(map[string]*memoryLease),
+		fences:  make(map[string]int64),
+		options: opts,
+		now:     time.Now,
+	}, nil
+}
+
+func (l *MemoryLock) WithLock(ctx context.Context, key string, fn func(ctx context.Context, fence int64) error, options ...LockOption) error {
+	opts, err := overwriteLockOptions(l.options, options...)
+	if err != nil {
+		return sharedErrs.Wrap(err, "WithLock applying option")
+	}
+
+	token, fence, err := l.acquire(ctx, key, opts)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug(ctx, "Acquired in-memory lock for key %v with fencing token %d", key, fence)
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stopWatchdog := func() {}
+	if opts.autoExtend {
+		stopWatchdog = l.watch(lockCtx, key, token, opts.expiry, cancel)
+	}
+
+	err = fn(lockCtx, fence)
+	stopWatchdog()
+
+	// fn has committed or given up by now, so the lease only matters up to here
+	leaseLost := !l.held(key, token)
+
+	if !l.release(key, token) {
+		// the lease expired after fn finished, and is possibly taken by another holder already
+		logger.Warn(ctx, "In-memory lock %v is no longer held at release, after the critical section finished", key)
+	} else {
+		logger.Debug(ctx, "In-memory lock released successfully for key %v", key)
+	}
+
+	if leaseLost {
+		logger.Error(ctx, "Lease of lock %v is lost before the critical section finished, error: %v", key, err)
+		return ErrLockLeaseLost
+	}
+
+	return err
+}
+
+// acquire tries to take the lease of key up to the retries count of opts, waiting the retry delays in between, until
+// ctx is done.
+func (l *MemoryLock) acquire(ctx context.Context, key string, opts *lockOptions) (uint64, int64, error) {
+	tries := max(opts.retriesCount, 1)
+
+	for try := 1; ; try++ {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, lockCancelledErr(ctx, key, err)
+		}
+
+		if token, fence, ok := l.tryAcquire(ctx, key, opts.expiry); ok {
+			return token, fence, nil
+		}
+
+		if try >= tries {
+			return 0, 0, sharedErrs.New(sharedErrs.ErrKindAcquireRedisLock, "Error acquiring lock: %s is held after %d tries", key, tries)
+		}
+
+		if err := waitRetry(ctx, opts.delayBefore(try)); err != nil {
+			return 0, 0, lockCancelledErr(ctx, key, err)
+		}
+	}
+}
+
+func (l *MemoryLock) tryAcquire(ctx context.Context, key string, expiry time.Duration) (uint64, int64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if lease, ok := l.leases[key]; ok && now.Before(lease.expiresAt) {
+		return 0, 0, false
+	}
+
+	l.tokens++
+	l.fences[key]++
+	l.leases[key] = &memoryLease{
+		token:     l.tokens,
+		expiresAt: now.Add(expiry),
+		holder:    LockHolder{Instance: lockInstance, CorrelationID: constant.CorrelationIDFromCtx(ctx)},
+	}
+
+	return l.tokens, l.fences[key], true
+}
+
+// extend renews the lease of key for expiry, as long as it is still held with token.
+func (l *MemoryLock) extend(key string, token uint64, expiry time.Duration) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	lease, ok := l.leases[key]
+	if !ok || lease.token != token || !now.Before(lease.expiresAt) {
+		return false
+	}
+
+	lease.expiresAt = now.Add(expiry)
+
+	return true
+}
+
+// held reports whether the lease of key is still held with token.
+func (l *MemoryLock) held(key string, token uint64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lease, ok := l.leases[key]
+
+	return ok && lease.token == token && l.now().Before(lease.expiresAt)
+}
+
+// release removes the lease of key, and reports whether it was still held with token.
+func (l *MemoryLock) release(key string, token uint64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lease, ok := l.leases[key]
+	if !ok || lease.token != token {
+		return false
+	}
+
+	delete(l.leases, key)
+
+	return l.now().Before(lease.expiresAt)
+}
+
+func (l *MemoryLock) HeldLocks(_ context.Context) ([]*HeldLock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	result := make([]*HeldLock, 0, len(l.leases))
+	for key, lease := range l.leases {
+		if now.Before(lease.expiresAt) {
+			result = append(result, &HeldLock{Key: key, TTL: lease.expiresAt.Sub(now).String(), Holder: lease.holder})
+		}
+	}
+
+	sort.Slice(result, func(a, b int) bool { return result[a].Key < result[b].Key })
+
+	return result, nil
+}
+
+func (l *MemoryLock) ForceRelease(ctx context.Context, key string) (*HeldLock, error) {
+	l.mu.Lock()
+
+	now := l.now()
+	lease, ok := l.leases[key]
+	if !ok || !now.Before(lease.expiresAt) {
+		l.mu.Unlock()
+		return nil, sharedErrs.New(sharedErrs.ErrKindDataNotFound, "Lock %s is not held", key)
+	}
+
+	delete(l.leases, key)
+	l.mu.Unlock()
+
+	released := &HeldLock{Key: key, TTL: lease.expiresAt.Sub(now).String(), Holder: lease.holder}
+	auditForceRelease(ctx, released)
+
+	return released, nil
+}
+
+// watch renews the lease every third of the expiry until the returned stop function is called, and calls onLost once
+// a renewal fails.
+func (l *MemoryLock) watch(ctx context.Context, key string, token uint64, expiry time.Duration, onLost func()) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(expiry / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !l.extend(key, token, expiry) {
+					logger.Error(ctx, "Failed to extend lease of lock %v", key)
+					onLost()
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
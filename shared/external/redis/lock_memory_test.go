@@ -0,0 +1,205 @@
+package redis
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryLock_MutualExclusion(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+
+	lock, err := NewMemoryLock(SetLockRetriesCount(1000), SetLockRetryDelay(time.Millisecond), SetLockExpiry(time.Minute))
+	assert.NoError(t, err)
+
+	var (
+		wg      sync.WaitGroup
+		holders int
+		counter int
+		fences  = make(map[int64]bool)
+		mu      sync.Mutex
+	)
+
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := lock.WithLock(ctx, "claim:coupon:A", func(ctx context.Context, fence int64) error {
+				holders++
+				assert.Equal(t, 1, holders)
+				time.Sleep(time.Millisecond)
+				counter++
+				holders--
+
+				mu.Lock()
+				fences[fence] = true
+				mu.Unlock()
+
+				return nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 20, counter)
+	// every acquisition is issued a distinct fencing token
+	assert.Len(t, fences, 20)
+	assert.True(t, fences[1] && fences[20])
+}
+
+func TestMemoryLock_Contended(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+
+	lock, err := NewMemoryLock(SetLockRetriesCount(3), SetLockRetryDelay(time.Millisecond), SetLockExpiry(time.Minute))
+	assert.NoError(t, err)
+
+	err = lock.WithLock(ctx, "claim:coupon:A", func(ctx context.Context, fence int64) error {
+		// another key is not excluded
+		err := lock.WithLock(ctx, "claim:coupon:B", func(ctx context.Context, fence int64) error {
+			assert.Equal(t, int64(1), fence)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		return lock.WithLock(ctx, "claim:coupon:A", func(ctx context.Context, fence int64) error {
+			t.Fatal("the held lock is acquired twice")
+			return nil
+		})
+	})
+
+	if assert.Error(t, err) {
+		assert.Equal(t, sharedErrs.ErrKindAcquireRedisLock, err.(sharedErrs.BaseError).Kind())
+	}
+}
+
+func TestMemoryLock_Expiry(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	lock, err := NewMemoryLock(SetLockRetriesCount(1), SetLockRetryDelay(time.Millisecond), SetLockExpiry(time.Second))
+	assert.NoError(t, err)
+	lock.now = func() time.Time { return now }
+
+	err = lock.WithLock(ctx, "claim:coupon:A", func(ctx context.Context, fence int64) error {
+		// the lease of the first holder expires, and is taken over by the second
+		now = now.Add(time.Second)
+
+		return lock.WithLock(ctx, "claim:coupon:A", func(ctx context.Context, fence int64) error {
+			assert.Equal(t, int64(2), fence)
+			return nil
+		})
+	})
+
+	assert.Equal(t, ErrLockLeaseLost, err)
+}
+
+func TestMemoryLock_AutoExtend(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+
+	lock, err := NewMemoryLock(SetLockRetriesCount(1), SetLockRetryDelay(time.Millisecond),
+		SetLockExpiry(30*time.Millisecond), SetLockAutoExtend())
+	assert.NoError(t, err)
+
+	err = lock.WithLock(ctx, "claim:coupon:A", func(ctx context.Context, fence int64) error {
+		time.Sleep(100 * time.Millisecond)
+
+		return ctx.Err()
+	})
+
+	assert.NoError(t, err)
+}
+
+func TestMemoryLock_Cancelled(t *testing.T) {
+	logger.Initialise()
+
+	lock, err := NewMemoryLock(SetLockRetriesCount(100), SetLockRetryDelay(time.Second), SetLockExpiry(time.Minute))
+	assert.NoError(t, err)
+
+	err = lock.WithLock(context.Background(), "claim:coupon:A", func(ctx context.Context, fence int64) error {
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := lock.WithLock(waitCtx, "claim:coupon:A", func(ctx context.Context, fence int64) error {
+			t.Fatal("the held lock is acquired twice")
+			return nil
+		})
+
+		// the waiter stops at the deadline rather than after its retries
+		assert.Less(t, time.Since(start), time.Second)
+		if assert.Error(t, err) {
+			assert.Equal(t, sharedErrs.ErrKindLockCancelled, err.(sharedErrs.BaseError).Kind())
+		}
+
+		return nil
+	})
+	assert.NoError(t, err)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = lock.WithLock(cancelled, "claim:coupon:B", func(ctx context.Context, fence int64) error {
+		t.Fatal("the lock is acquired for a cancelled context")
+		return nil
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, sharedErrs.ErrKindLockCancelled, err.(sharedErrs.BaseError).Kind())
+	}
+}
+
+func TestMemoryLock_ForceRelease(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.WithValue(context.Background(), constant.XCorrelationIDKey, "request-1")
+
+	lock, err := NewMemoryLock(SetLockRetriesCount(1), SetLockRetryDelay(time.Millisecond), SetLockExpiry(time.Minute))
+	assert.NoError(t, err)
+
+	err = lock.WithLock(ctx, "claim:coupon:A", func(ctx context.Context, fence int64) error {
+		locks, err := lock.HeldLocks(ctx)
+		assert.NoError(t, err)
+		if assert.Len(t, locks, 1) {
+			assert.Equal(t, "claim:coupon:A", locks[0].Key)
+			assert.Equal(t, LockHolder{Instance: lockInstance, CorrelationID: "request-1"}, locks[0].Holder)
+		}
+
+		released, err := lock.ForceRelease(ctx, "claim:coupon:A")
+		assert.NoError(t, err)
+		assert.Equal(t, "request-1", released.Holder.CorrelationID)
+
+		// the key can be taken by the next holder
+		return lock.WithLock(ctx, "claim:coupon:A", func(ctx context.Context, fence int64) error {
+			assert.Equal(t, int64(2), fence)
+			return nil
+		})
+	})
+
+	// the holder whose lock is force-released loses its lease
+	assert.Equal(t, ErrLockLeaseLost, err)
+
+	locks, err := lock.HeldLocks(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, locks)
+
+	_, err = lock.ForceRelease(ctx, "claim:coupon:A")
+	if assert.Error(t, err) {
+		assert.Equal(t, sharedErrs.ErrKindDataNotFound, err.(sharedErrs.BaseError).Kind())
+	}
+}
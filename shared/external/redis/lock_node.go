@@ -0,0 +1,289 @@
+package redis
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	uConfig "coupon_be/util/config"
+	"coupon_be/util/logger"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+const minStrictLockNodes = 3
+
+// setMaxScript raises the value of the key to ARGV[1], and returns the resulting value.
+//
+// KEYS[1] fence key
+// ARGV[1] fencing token
+var setMaxScript = redis.NewScript(1, `
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local fence = tonumber(ARGV[1])
+if current < fence then
+	redis.call('SET', KEYS[1], fence)
+	return fence
+end
+return current
+`)
+
+var lockNodes []*lockNode
+
+// lockNode is one of the independent redis nodes of the distributed lock.
+type lockNode struct {
+	address string
+	pool    *Pool
+}
+
+// NodeHealth is the health of a redis lock node.
+type NodeHealth struct {
+	Address string `json:"address"`
+	Healthy bool   `json:"healthy"`
+	Latency string `json:"latency,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// getLockNodes returns the configured lock nodes, or the main redis connection when none is configured.
+func getLockNodes(ctx context.Context) ([]*lockNode, error) {
+	if lockNodes != nil {
+		return lockNodes, nil
+	}
+
+	nodes, err := newLockNodes(ctx, uConfig.Env().Redis)
+	if err != nil {
+		return nil, err
+	}
+
+	lockNodes = nodes
+
+	return lockNodes, nil
+}
+
+// newLockNodes connects to the lock nodes of the config. The strict mode rejects the setups which can not keep the
+// lock safe through the loss of a node, i.e. fewer than three or an even number of nodes, the main redis alone
+// included, and a quorum of nodes being unreachable at startup.
+func newLockNodes(ctx context.Context, redisConfig uConfig.RedisConfig) ([]*lockNode, error) {
+	count := len(redisConfig.LockNodes)
+	if count < minStrictLockNodes || count%2 == 0 {
+		if redisConfig.LockStrictMode {
+			return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent,
+				"Redis lock strict mode requires an odd number of at least %d lock nodes, got %d", minStrictLockNodes, count)
+		}
+
+		if count > 0 {
+			logger.Warn(ctx, "Redis lock is configured with %d nodes, an odd number of at least %d nodes is recommended",
+				count, minStrictLockNodes)
+		}
+	}
+
+	if count == 0 {
+		pool, err := GetConnection(ctx)
+		if err != nil {
+			// connect lazily, locks can not be acquired until redis is reachable
+			logger.Error(ctx, "Redis is unreachable, locks can not be acquired until it is: %v", err)
+			useTLS := redisConfig.UseTLS
+			pool = newPool(&config{
+				Host:                 redisConfig.Host,
+				Port:                 redisConfig.Port,
+				Password:             redisConfig.Password,
+				MaxIdleConnections:   redisConfig.MaxIdleConnections,
+				MaxActiveConnections: redisConfig.MaxActiveConnections,
+				IdleTimeout:          redisConfig.IdleTimeout,
+				UseTLS:               &useTLS,
+				Sentinel:             sentinelConfigOf(redisConfig.Sentinel),
+			})
+		}
+
+		return []*lockNode{{address: redisAddress(redisConfig.Host, redisConfig.Port, redisConfig.Sentinel), pool: pool}}, nil
+	}
+
+	nodes := make([]*lockNode, count)
+	for i, node := range redisConfig.LockNodes {
+		useTLS := node.UseTLS
+		nodes[i] = &lockNode{
+			address: redisAddress(node.Host, node.Port, node.Sentinel),
+			pool: newPool(&config{
+				Host:                 node.Host,
+				Port:                 node.Port,
+				Password:             node.Password,
+				MaxIdleConnections:   redisConfig.MaxIdleConnections,
+				MaxActiveConnections: redisConfig.MaxActiveConnections,
+				IdleTimeout:          redisConfig.IdleTimeout,
+				UseTLS:               &useTLS,
+				Sentinel:             sentinelConfigOf(node.Sentinel),
+			}),
+		}
+	}
+
+	// an unreachable node does not block the startup, as long as a quorum of nodes is reachable
+	healthy := 0
+	for _, health := range lockNodesHealth(ctx, nodes) {
+		if health.Healthy {
+			healthy++
+			logger.Info(ctx, "Redis lock node %s is healthy", health.Address)
+		} else {
+			logger.Error(ctx, "Redis lock node %s is unhealthy: %s", health.Address, health.Error)
+		}
+	}
+
+	if healthy < quorum(count) {
+		if redisConfig.LockStrictMode {
+			return nil, sharedErrs.New(sharedErrs.ErrKindRedis,
+				"Only %d of %d redis lock nodes are reachable, a quorum of %d is required", healthy, count, quorum(count))
+		}
+
+		logger.Error(ctx, "Only %d of %d redis lock nodes are reachable, locks can not be acquired until %d are",
+			healthy, count, quorum(count))
+	}
+
+	return nodes, nil
+}
+
+// GetLockNodesHealth - Returns the health of every redis lock node
+func GetLockNodesHealth(ctx context.Context) ([]*NodeHealth, error) {
+	nodes, err := getLockNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return lockNodesHealth(ctx, nodes), nil
+}
+
+func lockNodesHealth(ctx context.Context, nodes []*lockNode) []*NodeHealth {
+	result := make([]*NodeHealth, len(nodes))
+	for i, node := range nodes {
+		result[i] = node.health(ctx)
+	}
+
+	return result
+}
+
+func (n *lockNode) health(ctx context.Context) *NodeHealth {
+	result := &NodeHealth{Address: n.address}
+
+	conn, err := n.pool.GetContext(ctx)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	if _, err = conn.Do("PING"); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Healthy = true
+	result.Latency = time.Since(start).String()
+
+	return result
+}
+
+// redisAddress names a lock node by its sentinel master name when it is discovered by sentinels.
+func redisAddress(host, port string, sentinel uConfig.RedisSentinelConfig) string {
+	if sentinel.MasterName != "" {
+		return "sentinel:" + sentinel.MasterName
+	}
+
+	return fmt.Sprintf("%v:%v", host, port)
+}
+
+func quorum(count int) int {
+	return count/2 + 1
+}
+
+// fencer issues fencing tokens that increase across a quorum of lock nodes. Any two quorums share a node, so reading
+// the highest token from a quorum always sees the token last written to a quorum.
+type fencer struct {
+	nodes []*lockNode
+	keys  *KeyBuilder
+}
+
+// next issues the token of a new acquisition of key, one above the highest token a quorum of nodes holds, and raises
+// a quorum of nodes to it before it is returned. An issued token is held by a quorum, so the next read quorum sees it
+// and the next token is higher, whichever nodes are down in between. The token is issued after the lock is acquired,
+// so WithLock drops a token issued once the lease has expired: the next holder may have read its own token meanwhile,
+// and a token kept while the lease is held is always issued before the next holder reads its own.
+//
+// An INCR on every node would not do: the nodes drift apart while one of them is down, and the INCR of a quorum
+// missing the node ahead of the others issues a token lower than one issued before, e.g. 11 is issued on nodes at
+// 10, 1 and 1 while the last one is down, and then 2 while the first one is.
+func (f *fencer) next(ctx context.Context, key string) (int64, error) {
+	fenceKey := f.keys.Key(fenceKeyPrefix + key)
+
+	// the tokens of a namespaced key carry on from those issued before the keys were namespaced, which the stock
+	// decrements of the coupons are fenced with already
+	keys := []any{fenceKey}
+	if f.keys.Namespace() != "" {
+		keys = append(keys, fenceKeyPrefix+key)
+	}
+
+	current, err := f.onQuorum(ctx, func(conn redis.Conn) (int64, error) {
+		values, err := redis.Int64s(conn.Do("MGET", keys...))
+		if err != nil {
+			return 0, err
+		}
+
+		return slices.Max(values), nil
+	})
+	if err != nil {
+		return 0, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to read fencing token", err)
+	}
+
+	fence := current + 1
+	if _, err = f.onQuorum(ctx, func(conn redis.Conn) (int64, error) {
+		return redis.Int64(setMaxScript.Do(conn, fenceKey, fence))
+	}); err != nil {
+		return 0, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to issue fencing token", err)
+	}
+
+	return fence, nil
+}
+
+// onQuorum runs fn on every node, and returns the highest result once a quorum of nodes succeeded.
+func (f *fencer) onQuorum(ctx context.Context, fn func(conn redis.Conn) (int64, error)) (int64, error) {
+	type result struct {
+		value int64
+		err   error
+	}
+
+	results := make(chan result, len(f.nodes))
+	for _, node := range f.nodes {
+		go func() {
+			conn, err := node.pool.GetContext(ctx)
+			if err != nil {
+				results <- result{err: err}
+				return
+			}
+			defer conn.Close()
+
+			value, err := fn(conn)
+			results <- result{value: value, err: err}
+		}()
+	}
+
+	var (
+		highest   int64
+		succeeded int
+		errs      []error
+	)
+	for range f.nodes {
+		r := <-results
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+
+		succeeded++
+		highest = max(highest, r.value)
+	}
+
+	if succeeded < quorum(len(f.nodes)) {
+		return 0, errors.Join(errs...)
+	}
+
+	return highest, nil
+}
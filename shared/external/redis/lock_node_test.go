@@ -0,0 +1,185 @@
+package redis
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis/redistest"
+	uConfig "coupon_be/util/config"
+	"coupon_be/util/logger"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFencer_NamespaceChange(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+	server, keyspace := newKeyspaceServer(t)
+	nodes := []*lockNode{{address: server.Address(), pool: newKeyspacePool(server)}}
+
+	// the keys are not namespaced by default, so the tokens issued before namespacing are carried on
+	unnamespaced := &fencer{nodes: nodes, keys: NewKeyBuilder()}
+	keyspace.handle([]string{"SET", "fence:claim:coupon:A", "41"})
+
+	fence, err := unnamespaced.next(ctx, "claim:coupon:A")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), fence)
+
+	// namespacing the keys carries on from the tokens issued before
+	namespaced := &fencer{nodes: nodes, keys: NewKeyBuilder("coupon", "dev")}
+	for _, expected := range []int64{43, 44} {
+		fence, err = namespaced.next(ctx, "claim:coupon:A")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, fence)
+	}
+	assert.Equal(t, "44", keyspace.get("coupon:dev:fence:claim:coupon:A"))
+
+	// a key never fenced before starts at 1
+	fence, err = namespaced.next(ctx, "claim:coupon:B")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), fence)
+}
+
+func TestFencer_MonotonicAcrossNodes(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+
+	const key = "claim:coupon:A"
+	down := make([]atomic.Bool, 3)
+	keyspaces := make([]*keyspace, len(down))
+	nodes := make([]*lockNode, len(down))
+	for i := range nodes {
+		keyspaces[i] = newKeyspace()
+		server := redistest.NewServer(t, func(args []string) any {
+			if down[i].Load() {
+				return redistest.Error(fmt.Sprintf("LOADING node %d is down", i))
+			}
+
+			return keyspaces[i].handle(args)
+		})
+		nodes[i] = &lockNode{address: server.Address(), pool: newKeyspacePool(server)}
+	}
+
+	// the last node was down while the tokens up to 10 were issued
+	keyspaces[0].handle([]string{"SET", fenceKeyPrefix + key, "10"})
+	keyspaces[1].handle([]string{"SET", fenceKeyPrefix + key, "10"})
+	keyspaces[2].handle([]string{"SET", fenceKeyPrefix + key, "1"})
+
+	f := &fencer{nodes: nodes, keys: NewKeyBuilder()}
+
+	last := int64(10)
+	for round := range 12 {
+		// a different node is down on every round, and none on every fourth
+		for i := range down {
+			down[i].Store(round%4 == i)
+		}
+
+		fence, err := f.next(ctx, key)
+		assert.NoError(t, err, "round %d", round)
+		assert.Greater(t, fence, last, "round %d", round)
+		last = fence
+	}
+
+	// without a quorum no token is issued
+	down[0].Store(true)
+	down[1].Store(true)
+	down[2].Store(false)
+
+	_, err := f.next(ctx, key)
+	assert.Error(t, err)
+}
+
+func TestNewLockNodes(t *testing.T) {
+	logger.Initialise()
+
+	reachable := func() uConfig.RedisNodeConfig {
+		server, _ := newKeyspaceServer(t)
+		host, port := server.HostPort()
+
+		return uConfig.RedisNodeConfig{Host: host, Port: port}
+	}
+	unreachable := func() uConfig.RedisNodeConfig {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		host, port, _ := net.SplitHostPort(listener.Addr().String())
+		listener.Close()
+
+		return uConfig.RedisNodeConfig{Host: host, Port: port}
+	}
+	tooFew := func(count int) error {
+		return sharedErrs.New(sharedErrs.ErrKindApplicationPermanent,
+			"Redis lock strict mode requires an odd number of at least %d lock nodes, got %d", minStrictLockNodes, count)
+	}
+
+	testCases := []struct {
+		name          string
+		strict        bool
+		nodes         []uConfig.RedisNodeConfig
+		expectedNodes int
+		expectedError error
+	}{
+		{
+			name:          "strict mode with the main redis alone",
+			strict:        true,
+			expectedError: tooFew(0),
+		},
+		{
+			name:          "strict mode with one node",
+			strict:        true,
+			nodes:         []uConfig.RedisNodeConfig{reachable()},
+			expectedError: tooFew(1),
+		},
+		{
+			name:          "strict mode with too few nodes",
+			strict:        true,
+			nodes:         []uConfig.RedisNodeConfig{reachable(), reachable()},
+			expectedError: tooFew(2),
+		},
+		{
+			name:          "strict mode with an even number of nodes",
+			strict:        true,
+			nodes:         []uConfig.RedisNodeConfig{reachable(), reachable(), reachable(), reachable()},
+			expectedError: tooFew(4),
+		},
+		{
+			name:   "strict mode without a reachable quorum",
+			strict: true,
+			nodes:  []uConfig.RedisNodeConfig{reachable(), unreachable(), unreachable()},
+			expectedError: sharedErrs.New(sharedErrs.ErrKindRedis,
+				"Only %d of %d redis lock nodes are reachable, a quorum of %d is required", 1, 3, 2),
+		},
+		{
+			name:          "strict mode with a reachable quorum",
+			strict:        true,
+			nodes:         []uConfig.RedisNodeConfig{reachable(), reachable(), unreachable()},
+			expectedNodes: 3,
+		},
+		{
+			name:          "too few nodes are only warned about",
+			nodes:         []uConfig.RedisNodeConfig{reachable(), reachable()},
+			expectedNodes: 2,
+		},
+		{
+			name:          "unreachable quorum is only logged",
+			nodes:         []uConfig.RedisNodeConfig{unreachable(), unreachable(), unreachable()},
+			expectedNodes: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes, err := newLockNodes(context.Background(), uConfig.RedisConfig{
+				LockNodes:      tc.nodes,
+				LockStrictMode: tc.strict,
+			})
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Len(t, nodes, tc.expectedNodes)
+		})
+	}
+}
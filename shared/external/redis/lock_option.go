@@ -1,7 +1,9 @@
 package redis
 
 import (
+	"context"
 	sharedErrs "coupon_be/shared/errors"
+	"math/rand/v2"
 	"time"
 )
 
@@ -15,6 +17,11 @@ type lockOptions struct {
 	expiry       time.Duration
 	retriesCount int
 	retryDelay   time.Duration
+	autoExtend   bool
+	// maxRetryDelay doubles the retry delay on every retry up to itself when it is set.
+	maxRetryDelay time.Duration
+	// retryJitter adds a random delay below itself to every retry when it is set.
+	retryJitter time.Duration
 }
 
 type LockOption func(options *lockOptions) error
@@ -58,6 +65,71 @@ func SetLockRetryDelay(retryDelay time.Duration) LockOption {
 	}
 }
 
+// SetLockAutoExtend renews the lease every third of the expiry while the critical section runs.
+func SetLockAutoExtend() LockOption {
+	return func(options *lockOptions) error {
+		options.autoExtend = true
+
+		return nil
+	}
+}
+
+// SetLockExponentialBackoff doubles the retry delay on every retry, up to maxDelay.
+func SetLockExponentialBackoff(maxDelay time.Duration) LockOption {
+	return func(options *lockOptions) error {
+		if maxDelay <= 0 {
+			return sharedErrs.New(sharedErrs.ErrKindRedis, "max retry delay is less than 1")
+		}
+
+		options.maxRetryDelay = maxDelay
+
+		return nil
+	}
+}
+
+// SetLockRetryJitter adds a random delay of up to jitter to every retry, so the waiters of a key do not retry in step.
+func SetLockRetryJitter(jitter time.Duration) LockOption {
+	return func(options *lockOptions) error {
+		if jitter < 0 {
+			return sharedErrs.New(sharedErrs.ErrKindRedis, "retry jitter is less than 0")
+		}
+
+		options.retryJitter = jitter
+
+		return nil
+	}
+}
+
+// delayBefore returns how long to wait before the given retry, the first retry being 1.
+func (o *lockOptions) delayBefore(retry int) time.Duration {
+	delay := o.retryDelay
+	if o.maxRetryDelay > 0 {
+		for i := 1; i < retry && delay < o.maxRetryDelay; i++ {
+			delay *= 2
+		}
+		delay = min(delay, o.maxRetryDelay)
+	}
+
+	if o.retryJitter > 0 {
+		delay += rand.N(o.retryJitter)
+	}
+
+	return delay
+}
+
+// waitRetry waits for delay, and returns the error of ctx when it is done first.
+func waitRetry(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 func getLockOptions(opts ...LockOption) (*lockOptions, error) {
 	options := &lockOptions{
 		expiry:       expiry,
@@ -81,11 +153,8 @@ func overwriteLockOptions(globalOptions *lockOptions, opts ...LockOption) (*lock
 		return globalOptions, nil
 	}
 
-	options := &lockOptions{
-		expiry:       globalOptions.expiry,
-		retriesCount: globalOptions.retriesCount,
-		retryDelay:   globalOptions.retryDelay,
-	}
+	copied := *globalOptions
+	options := &copied
 
 	for _, o := range opts {
 		if o != nil {
@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockOptions_DelayBefore(t *testing.T) {
+	testCases := []struct {
+		name     string
+		options  []LockOption
+		expected []time.Duration
+	}{
+		{
+			name:     "fixed delay",
+			options:  []LockOption{SetLockRetryDelay(100 * time.Millisecond)},
+			expected: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond},
+		},
+		{
+			name: "exponential backoff",
+			options: []LockOption{
+				SetLockRetryDelay(100 * time.Millisecond),
+				SetLockExponentialBackoff(time.Second),
+			},
+			expected: []time.Duration{
+				100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond,
+				time.Second, time.Second,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts, err := getLockOptions(tc.options...)
+			assert.NoError(t, err)
+
+			for i, expected := range tc.expected {
+				assert.Equal(t, expected, opts.delayBefore(i+1), "retry %d", i+1)
+			}
+		})
+	}
+}
+
+func TestLockOptions_Jitter(t *testing.T) {
+	opts, err := getLockOptions(SetLockRetryDelay(100*time.Millisecond), SetLockRetryJitter(50*time.Millisecond))
+	assert.NoError(t, err)
+
+	for retry := 1; retry <= 100; retry++ {
+		delay := opts.delayBefore(retry)
+		assert.GreaterOrEqual(t, delay, 100*time.Millisecond)
+		assert.Less(t, delay, 150*time.Millisecond)
+	}
+}
+
+func TestLockOptions_Overwrite(t *testing.T) {
+	global, err := getLockOptions(SetLockRetryJitter(50*time.Millisecond), SetLockAutoExtend())
+	assert.NoError(t, err)
+
+	opts, err := overwriteLockOptions(global, SetLockRetriesCount(1))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, opts.retriesCount)
+	assert.Equal(t, 50*time.Millisecond, opts.retryJitter)
+	assert.True(t, opts.autoExtend)
+	// the global options are left untouched
+	assert.Equal(t, retriesCount, global.retriesCount)
+}
@@ -0,0 +1,135 @@
+package redis
+
+import (
+	"context"
+	"coupon_be/shared/external/redis/redistest"
+	"coupon_be/util/logger"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock_Lease(t *testing.T) {
+	logger.Initialise()
+
+	const key = "claim:coupon:A"
+	lockKey := Key(lockKeyPrefix + key)
+
+	testCases := []struct {
+		name    string
+		options []LockOption
+		// fn is the critical section, finishing with committed set.
+		fn func(ctx context.Context, k *keyspace, committed *atomic.Bool) error
+		// onCommand runs before every command the stand-in receives.
+		onCommand   func(args []string, k *keyspace, committed *atomic.Bool)
+		expectedErr error
+		// expectedHolder is the value of the lock key left on the node, empty when it is released.
+		expectedHolder string
+	}{
+		{
+			name:    "watchdog renews the lease past the expiry",
+			options: []LockOption{SetLockExpiry(150 * time.Millisecond), SetLockAutoExtend()},
+			fn: func(ctx context.Context, k *keyspace, committed *atomic.Bool) error {
+				for range 4 {
+					time.Sleep(100 * time.Millisecond)
+					// the node counts the ttl renewed by the watchdog
+					k.advance(100 * time.Millisecond)
+				}
+				assert.NotEmpty(t, k.get(lockKey))
+
+				committed.Store(true)
+				return ctx.Err()
+			},
+		},
+		{
+			name:    "lease expires before the critical section finishes",
+			options: []LockOption{SetLockExpiry(100 * time.Millisecond)},
+			fn: func(ctx context.Context, k *keyspace, committed *atomic.Bool) error {
+				time.Sleep(150 * time.Millisecond)
+
+				committed.Store(true)
+				return nil
+			},
+			expectedErr: ErrLockLeaseLost,
+		},
+		{
+			name:    "renewal fails once the lease is taken by another holder",
+			options: []LockOption{SetLockExpiry(150 * time.Millisecond), SetLockAutoExtend()},
+			fn: func(ctx context.Context, k *keyspace, committed *atomic.Bool) error {
+				k.handle([]string{"SET", lockKey, "other"})
+
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Second):
+					assert.Fail(t, "context of the critical section is not cancelled")
+				}
+				// the writes of the section are rejected from here, e.g. by the fencing token
+				time.Sleep(150 * time.Millisecond)
+
+				committed.Store(true)
+				return ctx.Err()
+			},
+			expectedErr:    ErrLockLeaseLost,
+			expectedHolder: "other",
+		},
+		{
+			name:    "lease expires while the fencing token is issued",
+			options: []LockOption{SetLockExpiry(100 * time.Millisecond)},
+			fn: func(ctx context.Context, k *keyspace, committed *atomic.Bool) error {
+				assert.Fail(t, "critical section runs without the lease")
+				return nil
+			},
+			onCommand: func(args []string, k *keyspace, committed *atomic.Bool) {
+				// the holder pauses between the acquisition and the issue of its token
+				if args[0] == "MGET" {
+					time.Sleep(150 * time.Millisecond)
+				}
+			},
+			expectedErr: ErrLockLeaseLost,
+		},
+		{
+			name:    "lease expiring after the critical section committed is not lost",
+			options: []LockOption{SetLockExpiry(time.Second)},
+			fn: func(ctx context.Context, k *keyspace, committed *atomic.Bool) error {
+				committed.Store(true)
+				return nil
+			},
+			onCommand: func(args []string, k *keyspace, committed *atomic.Bool) {
+				// the key expires on the node between the commit and the release
+				if committed.CompareAndSwap(true, false) {
+					k.advance(time.Second)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var committed atomic.Bool
+
+			k := newKeyspace()
+			server := redistest.NewServer(t, func(args []string) any {
+				if tc.onCommand != nil {
+					tc.onCommand(args, k, &committed)
+				}
+
+				return k.handle(args)
+			})
+
+			options := append([]LockOption{SetLockRetriesCount(1), SetLockRetryDelay(time.Millisecond)}, tc.options...)
+			lock, err := newLock(context.Background(),
+				[]*lockNode{{address: server.Address(), pool: newKeyspacePool(server)}}, options...)
+			assert.NoError(t, err)
+
+			err = lock.WithLock(context.Background(), key, func(ctx context.Context, fence int64) error {
+				assert.Equal(t, int64(1), fence)
+				return tc.fn(ctx, k, &committed)
+			})
+
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedHolder, k.get(lockKey))
+		})
+	}
+}
@@ -4,10 +4,22 @@ import (
 	"context"
 	sharedErrs "coupon_be/shared/errors"
 	uConfig "coupon_be/util/config"
+	"coupon_be/util/logger"
 	"time"
 )
 
-var redisClient *Pool
+const (
+	// LockBackendRedis is the distributed lock across the redis lock nodes.
+	LockBackendRedis = "redis"
+	// LockBackendMemory is the in-process lock, which excludes the holders of a single instance only.
+	LockBackendMemory = "memory"
+)
+
+var (
+	redisClient *Pool
+	lockBreaker *circuitBreaker
+	memoryLock  *MemoryLock
+)
 
 // GetConnection - Returns the redis connection
 func GetConnection(ctx context.Context) (*Pool, error) {
@@ -22,6 +34,7 @@ func GetConnection(ctx context.Context) (*Pool, error) {
 			MaxActiveConnections: redisConfig.MaxActiveConnections,
 			IdleTimeout:          redisConfig.IdleTimeout,
 			UseTLS:               &redisConfig.UseTLS,
+			Sentinel:             sentinelConfigOf(redisConfig.Sentinel),
 		})
 		if err != nil {
 			return nil, err
@@ -33,15 +46,134 @@ func GetConnection(ctx context.Context) (*Pool, error) {
 	return redisClient, nil
 }
 
+// GetLock - Returns the lock of the configured backend
+func GetLock(ctx context.Context) (ILock, error) {
+	backend := uConfig.Env().Lock.Backend
+
+	switch backend {
+	case LockBackendRedis, "":
+		return GetRedisLock(ctx)
+	case LockBackendMemory:
+		logger.Warn(ctx, "Lock is in-memory, the claims of other instances are not excluded")
+
+		lock, err := getMemoryLock()
+		if err != nil {
+			return nil, err
+		}
+
+		return lock, nil
+	default:
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "Unknown lock backend %s", backend)
+	}
+}
+
+// getMemoryLock returns the in-memory lock shared by the claims and the lock inspection of this instance.
+func getMemoryLock() (*MemoryLock, error) {
+	if memoryLock == nil {
+		lock, err := NewMemoryLock(defaultLockOptions()...)
+		if err != nil {
+			return nil, err
+		}
+
+		memoryLock = lock
+	}
+
+	return memoryLock, nil
+}
+
+// GetRedisLock returns the distributed lock guarded by a circuit breaker. Unreachable lock nodes do not fail the
+// startup unless the lock is in strict mode, the breaker is opened once acquisitions fail instead.
 func GetRedisLock(ctx context.Context) (ILock, error) {
+	nodes, err := getLockNodes(ctx)
+	if err != nil {
+		return nil, sharedErrs.Wrap(err, "get redis lock nodes")
+	}
+
+	lock, err := newLock(ctx, nodes, defaultLockOptions()...)
+	if err != nil {
+		return nil, err
+	}
+
+	if lockBreaker == nil {
+		redisConfig := uConfig.Env().Redis
+
+		var cooldown time.Duration
+		if redisConfig.LockBreakerCooldown != "" {
+			if cooldown, err = time.ParseDuration(redisConfig.LockBreakerCooldown); err != nil {
+				return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindApplicationPermanent, "Invalid redis lock breaker cooldown", err)
+			}
+		}
+
+		lockBreaker = newCircuitBreaker(redisConfig.LockBreakerThreshold, cooldown)
+	}
+
+	return &breakerLock{lock: lock, breaker: lockBreaker}, nil
+}
+
+// GetLockBreakerStatus - Returns the state of the circuit breaker of the redis lock, nil before the lock is created
+func GetLockBreakerStatus() *BreakerStatus {
+	if lockBreaker == nil {
+		return nil
+	}
+
+	return lockBreaker.status()
+}
+
+func GetStockCounter(ctx context.Context) (IStockCounter, error) {
+	redis, err := GetConnection(ctx)
+	if err != nil {
+		return nil, sharedErrs.Wrap(err, "get redis conn")
+	}
+
+	return newStockCounter(ctx, redis)
+}
+
+func GetIdempotencyStore(ctx context.Context) (IIdempotencyStore, error) {
 	redis, err := GetConnection(ctx)
 	if err != nil {
 		return nil, sharedErrs.Wrap(err, "get redis conn")
 	}
 
-	return newLock(ctx, redis,
+	return newIdempotencyStore(ctx, redis)
+}
+
+func GetSoldOutMarker(ctx context.Context) (ISoldOutMarker, error) {
+	redis, err := GetConnection(ctx)
+	if err != nil {
+		return nil, sharedErrs.Wrap(err, "get redis conn")
+	}
+
+	return newSoldOutMarker(ctx, redis)
+}
+
+// GetSemaphore - Returns the semaphore of the configured lock backend
+func GetSemaphore(ctx context.Context) (ISemaphore, error) {
+	backend := uConfig.Env().Lock.Backend
+
+	switch backend {
+	case LockBackendRedis, "":
+		redis, err := GetConnection(ctx)
+		if err != nil {
+			return nil, sharedErrs.Wrap(err, "get redis conn")
+		}
+
+		return newRedisSemaphore(ctx, redis, defaultLockOptions()...)
+	case LockBackendMemory:
+		logger.Warn(ctx, "Semaphore is in-memory, the permits of other instances are not counted")
+
+		return NewMemorySemaphore(defaultLockOptions()...)
+	default:
+		return nil, sharedErrs.New(sharedErrs.ErrKindApplicationPermanent, "Unknown lock backend %s", backend)
+	}
+}
+
+// defaultLockOptions are the options of the lock of every backend.
+func defaultLockOptions() []LockOption {
+	return []LockOption{
 		SetLockRetriesCount(redisLockMaxNoOfRetry),
-		SetLockRetryDelay(redisLockRetryDelay*time.Millisecond),
-		SetLockExpiry(redisLockExpiry*time.Second),
-	)
+		SetLockRetryDelay(redisLockRetryDelay * time.Millisecond),
+		SetLockRetryJitter(redisLockRetryJitter * time.Millisecond),
+		SetLockExpiry(redisLockExpiry * time.Second),
+		SetLockAutoExtend(),
+	}
 }
@@ -0,0 +1,204 @@
+// Package redistest provides a small RESP stand-in of redis for the tests of the packages talking to redis, which
+// answers the commands with the handler of the test.
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type (
+	// Status is answered as a RESP simple string, e.g. OK.
+	Status string
+	// Error is answered as a RESP error.
+	Error string
+)
+
+// Server is a small RESP stand-in of redis, which answers every command with handle. The replies are nil, Status,
+// Error, int64, string or []any of them. SUBSCRIBE is answered by the server itself, and the subscribed connections
+// receive what is published.
+type Server struct {
+	listener net.Listener
+	handle   func(args []string) any
+
+	mu          sync.Mutex
+	conns       []net.Conn
+	subscribers []net.Conn
+	commands    []string
+}
+
+// NewServer starts a server answering with handle, which is closed on the cleanup of the test.
+func NewServer(t testing.TB, handle func(args []string) any) *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{listener: listener, handle: handle}
+	t.Cleanup(s.Close)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+// Address returns the host:port the server listens on.
+func (s *Server) Address() string {
+	return s.listener.Addr().String()
+}
+
+// HostPort returns the host and port the server listens on.
+func (s *Server) HostPort() (string, string) {
+	host, port, _ := net.SplitHostPort(s.Address())
+	return host, port
+}
+
+// Received returns the names of the commands received so far.
+func (s *Server) Received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.commands...)
+}
+
+// Subscribed returns the number of connections subscribed so far.
+func (s *Server) Subscribed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.subscribers)
+}
+
+// Publish sends the message of the channel to every subscribed connection.
+func (s *Server) Publish(channel, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.subscribers {
+		writeRESP(conn, []any{"message", channel, message})
+	}
+}
+
+// Close stops listening, and closes the connections accepted.
+func (s *Server) Close() {
+	s.listener.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *Server) serve(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+
+	for {
+		args, err := readRESP(reader)
+		if err != nil {
+			return
+		}
+
+		name := strings.ToUpper(args[0])
+
+		s.mu.Lock()
+		s.commands = append(s.commands, name)
+		if name == "SUBSCRIBE" {
+			s.subscribers = append(s.subscribers, conn)
+		}
+		s.mu.Unlock()
+
+		if name == "SUBSCRIBE" {
+			for i, channel := range args[1:] {
+				writeRESP(conn, []any{"subscribe", channel, int64(i + 1)})
+			}
+			continue
+		}
+
+		writeRESP(conn, s.handle(args))
+	}
+}
+
+func readRESP(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected command %q", line)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+
+		args[i] = string(data[:size])
+	}
+
+	return args, nil
+}
+
+func writeRESP(w io.Writer, value any) {
+	fmt.Fprint(w, encodeRESP(value))
+}
+
+func encodeRESP(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "$-1\r\n"
+	case Status:
+		return fmt.Sprintf("+%s\r\n", v)
+	case Error:
+		return fmt.Sprintf("-%s\r\n", v)
+	case int64:
+		return fmt.Sprintf(":%d\r\n", v)
+	case string:
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case []any:
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%d\r\n", len(v))
+		for _, item := range v {
+			b.WriteString(encodeRESP(item))
+		}
+		return b.String()
+	default:
+		panic(fmt.Sprintf("unexpected RESP value %v", value))
+	}
+}
@@ -0,0 +1,190 @@
+package redis
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/logger"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -package mock -source=semaphore.go -destination=../../../mock/redis_semaphore.go *
+
+const semaphoreKeyPrefix = "semaphore:"
+
+// Permit is one of the permits of a semaphore key, held until it is released or expires.
+type Permit struct {
+	Key string
+	ID  string
+}
+
+// ISemaphore - interface for distributed counting semaphores, which let at most limit holders of a key at once
+type ISemaphore interface {
+	// Acquire takes one of the limit permits of key, retrying with the delays of the options until ctx is done. The
+	// permit expires after the expiry of the options unless it is released first, so a crashed holder does not keep it.
+	Acquire(ctx context.Context, key string, limit int, options ...LockOption) (*Permit, error)
+	// Release returns the permit, or ErrLockLeaseLost when it has expired already.
+	Release(ctx context.Context, permit *Permit) error
+	// WithPermit runs fn while holding one of the limit permits of key, with the lease semantics of WithLock: the
+	// context passed to fn is cancelled when the permit can not be renewed, and ErrLockLeaseLost is returned whenever
+	// the permit is lost before fn finished.
+	WithPermit(ctx context.Context, key string, limit int, fn func(ctx context.Context) error, options ...LockOption) error
+}
+
+// semaphore holds the permits in a store, redis or in-memory.
+type semaphore struct {
+	store   permitStore
+	options *lockOptions
+	now     func() time.Time
+}
+
+func newSemaphore(store permitStore, options ...LockOption) (*semaphore, error) {
+	opts, err := getLockOptions(options...)
+	if err != nil {
+		return nil, sharedErrs.Wrap(err, "initialising semaphore")
+	}
+
+	return &semaphore{store: store, options: opts, now: time.Now}, nil
+}
+
+func (s *semaphore) Acquire(ctx context.Context, key string, limit int, options ...LockOption) (*Permit, error) {
+	opts, err := overwriteLockOptions(s.options, options...)
+	if err != nil {
+		return nil, sharedErrs.Wrap(err, "Acquire applying option")
+	}
+
+	return s.acquire(ctx, key, limit, opts, nil)
+}
+
+func (s *semaphore) Release(ctx context.Context, permit *Permit) error {
+	released, err := s.store.release(ctx, permit.Key, permit.ID)
+	if err != nil {
+		return err
+	}
+	if !released {
+		logger.Error(ctx, "Permit %s of semaphore %v is expired before it is released", permit.ID, permit.Key)
+		return ErrLockLeaseLost
+	}
+
+	logger.Debug(ctx, "Permit %s of semaphore %v released successfully", permit.ID, permit.Key)
+
+	return nil
+}
+
+func (s *semaphore) WithPermit(ctx context.Context, key string, limit int, fn func(ctx context.Context) error, options ...LockOption) error {
+	opts, err := overwriteLockOptions(s.options, options...)
+	if err != nil {
+		return sharedErrs.Wrap(err, "WithPermit applying option")
+	}
+
+	var lease lease
+
+	permit, err := s.acquire(ctx, key, limit, opts, &lease)
+	if err != nil {
+		return err
+	}
+
+	permitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stopWatchdog := func() {}
+	if opts.autoExtend {
+		stopWatchdog = s.watch(permitCtx, permit, opts.expiry, &lease, cancel)
+	}
+
+	err = fn(permitCtx)
+	// fn has committed or given up by now, so the permit only matters up to here
+	leaseLost := !lease.heldAt(s.now())
+	stopWatchdog()
+
+	if releaseErr := s.Release(ctx, permit); releaseErr != nil && !errors.Is(releaseErr, ErrLockLeaseLost) {
+		logger.Error(ctx, "Error while releasing permit %v", releaseErr)
+	}
+
+	if leaseLost {
+		logger.Error(ctx, "Permit of semaphore %v is lost before the critical section finished, error: %v", key, err)
+		return ErrLockLeaseLost
+	}
+
+	return err
+}
+
+// acquire takes a permit of key, retrying with the delays of opts until the tries are exhausted or ctx is done, and
+// records its lease when one is given.
+func (s *semaphore) acquire(ctx context.Context, key string, limit int, opts *lockOptions, lease *lease) (*Permit, error) {
+	if limit < 1 {
+		return nil, sharedErrs.New(sharedErrs.ErrKindRedis, "semaphore limit is less than 1")
+	}
+
+	permit := &Permit{Key: key, ID: uuid.NewString()}
+	tries := max(opts.retriesCount, 1)
+
+	for try := 1; ; try++ {
+		if err := ctx.Err(); err != nil {
+			return nil, lockCancelledErr(ctx, key, err)
+		}
+
+		start := s.now()
+		acquired, err := s.store.acquire(ctx, key, permit.ID, limit, opts.expiry)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			if lease != nil {
+				lease.renew(start, opts.expiry)
+			}
+			logger.Debug(ctx, "Acquired permit %s of semaphore %v", permit.ID, key)
+			return permit, nil
+		}
+
+		if try >= tries {
+			return nil, sharedErrs.New(sharedErrs.ErrKindAcquireRedisLock,
+				"Error acquiring semaphore permit: all %d permits of %s are held after %d tries", limit, key, tries)
+		}
+
+		if err = waitRetry(ctx, opts.delayBefore(try)); err != nil {
+			return nil, lockCancelledErr(ctx, key, err)
+		}
+	}
+}
+
+// watch renews the permit every third of the expiry until the returned stop function is called, and calls onLost
+// once a renewal fails.
+func (s *semaphore) watch(ctx context.Context, permit *Permit, expiry time.Duration, lease *lease,
+	onLost func()) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(expiry / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				start := s.now()
+				extended, err := s.store.extend(ctx, permit.Key, permit.ID, expiry)
+				if err != nil || !extended {
+					logger.Error(ctx, "Failed to extend permit of semaphore %v: %v", permit.Key, err)
+					onLost()
+					return
+				}
+
+				lease.renew(start, expiry)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryPermitStore keeps the permits in-process, so the semaphore counts the holders of a single instance only. It
+// is meant for running locally without redis and for tests.
+type memoryPermitStore struct {
+	mu      sync.Mutex
+	permits map[string]map[string]time.Time
+	now     func() time.Time
+}
+
+// NewMemorySemaphore returns an in-process semaphore with the given default options.
+func NewMemorySemaphore(options ...LockOption) (ISemaphore, error) {
+	return newSemaphore(&memoryPermitStore{permits: make(map[string]map[string]time.Time), now: time.Now}, options...)
+}
+
+func (s *memoryPermitStore) acquire(_ context.Context, key, id string, limit int, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	permits := s.permits[key]
+	for held, expiresAt := range permits {
+		if !expiresAt.After(now) {
+			delete(permits, held)
+		}
+	}
+
+	if len(permits) >= limit {
+		return false, nil
+	}
+
+	if permits == nil {
+		permits = make(map[string]time.Time)
+		s.permits[key] = permits
+	}
+	permits[id] = now.Add(ttl)
+
+	return true, nil
+}
+
+func (s *memoryPermitStore) extend(_ context.Context, key, id string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	expiresAt, ok := s.permits[key][id]
+	if !ok || !expiresAt.After(now) {
+		return false, nil
+	}
+
+	s.permits[key][id] = now.Add(ttl)
+
+	return true, nil
+}
+
+func (s *memoryPermitStore) release(_ context.Context, key, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	permits := s.permits[key]
+	expiresAt, ok := permits[id]
+	delete(permits, id)
+	if len(permits) == 0 {
+		delete(s.permits, key)
+	}
+
+	return ok && expiresAt.After(now), nil
+}
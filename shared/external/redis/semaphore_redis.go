@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/logger"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// permitStore keeps the permits of the semaphore keys with their expiry, counted by the clock of the store, so the
+// holders of every instance agree on when a permit expires whatever the clocks of their hosts.
+type permitStore interface {
+	// acquire adds the permit to key unless limit unexpired permits are held already.
+	acquire(ctx context.Context, key, id string, limit int, ttl time.Duration) (bool, error)
+	// extend renews the permit, unless it is expired already.
+	extend(ctx context.Context, key, id string, ttl time.Duration) (bool, error)
+	// release removes the permit, reporting whether it was still unexpired.
+	release(ctx context.Context, key, id string) (bool, error)
+}
+
+// semaphoreNow is the current time of redis in milliseconds, from TIME rather than the clock of the caller. Scripts
+// calling TIME before writing are replicated by their effects, which redis only does by default since 7.0.
+const semaphoreNow = `
+redis.replicate_commands()
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+`
+
+// semaphoreAcquireScript drops the expired permits of the semaphore, and adds the permit when less than limit are
+// left. The permits are the members of a sorted set scored by their expiry in milliseconds, and the set itself
+// expires with its last permit.
+//
+// KEYS[1] semaphore key
+// ARGV[1] ttl, ARGV[2] limit, ARGV[3] permit id
+var semaphoreAcquireScript = redis.NewScript(1, semaphoreNow+`
+local ttl = tonumber(ARGV[1])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], now + ttl, ARGV[3])
+if redis.call('PTTL', KEYS[1]) < ttl then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// semaphoreExtendScript renews the permit unless it is expired or released already.
+//
+// KEYS[1] semaphore key
+// ARGV[1] ttl, ARGV[2] permit id
+var semaphoreExtendScript = redis.NewScript(1, semaphoreNow+`
+local ttl = tonumber(ARGV[1])
+local expiresAt = redis.call('ZSCORE', KEYS[1], ARGV[2])
+if not expiresAt or tonumber(expiresAt) <= now then
+	return 0
+end
+redis.call('ZADD', KEYS[1], now + ttl, ARGV[2])
+if redis.call('PTTL', KEYS[1]) < ttl then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// semaphoreReleaseScript removes the permit, returning 1 when it was not expired yet.
+//
+// KEYS[1] semaphore key
+// ARGV[1] permit id
+var semaphoreReleaseScript = redis.NewScript(1, semaphoreNow+`
+local expiresAt = redis.call('ZSCORE', KEYS[1], ARGV[1])
+redis.call('ZREM', KEYS[1], ARGV[1])
+if not expiresAt or tonumber(expiresAt) <= now then
+	return 0
+end
+return 1
+`)
+
+// redisPermitStore keeps the permits on the main redis, so the semaphore counts the holders of every instance. Unlike
+// the lock, it is not spread across the lock nodes.
+type redisPermitStore struct {
+	pool *Pool
+}
+
+func (s *redisPermitStore) acquire(ctx context.Context, key, id string, limit int, ttl time.Duration) (bool, error) {
+	return s.do(ctx, "acquire", semaphoreAcquireScript, Key(semaphoreKeyPrefix+key), ttl.Milliseconds(), limit, id)
+}
+
+func (s *redisPermitStore) extend(ctx context.Context, key, id string, ttl time.Duration) (bool, error) {
+	return s.do(ctx, "extend", semaphoreExtendScript, Key(semaphoreKeyPrefix+key), ttl.Milliseconds(), id)
+}
+
+func (s *redisPermitStore) release(ctx context.Context, key, id string) (bool, error) {
+	return s.do(ctx, "release", semaphoreReleaseScript, Key(semaphoreKeyPrefix+key), id)
+}
+
+func (s *redisPermitStore) do(ctx context.Context, action string, script *redis.Script, args ...interface{}) (bool, error) {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return false, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	ok, err := redis.Bool(script.Do(conn, args...))
+	if err != nil {
+		return false, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to "+action+" semaphore permit", err)
+	}
+
+	return ok, nil
+}
+
+// newRedisSemaphore Provider/Factory function to return a redis semaphore
+func newRedisSemaphore(ctx context.Context, pool *Pool, options ...LockOption) (ISemaphore, error) {
+	if pool == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindRedis, "Redis pool is nil")
+	}
+
+	s, err := newSemaphore(&redisPermitStore{pool: pool}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug(ctx, "Redis semaphore initialised successfully")
+
+	return s, nil
+}
@@ -0,0 +1,209 @@
+package redis
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/logger"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemaphore_Limit(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+
+	s, err := NewMemorySemaphore(SetLockRetriesCount(1000), SetLockRetryDelay(time.Millisecond), SetLockExpiry(time.Minute))
+	assert.NoError(t, err)
+
+	var (
+		wg       sync.WaitGroup
+		holders  atomic.Int32
+		peak     atomic.Int32
+		counter  atomic.Int32
+		limit    = 3
+		routines = 20
+	)
+
+	for range routines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := s.WithPermit(ctx, "claim:coupon:A", limit, func(ctx context.Context) error {
+				current := holders.Add(1)
+				for {
+					if p := peak.Load(); current <= p || peak.CompareAndSwap(p, current) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				counter.Add(1)
+				holders.Add(-1)
+
+				return nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(routines), counter.Load())
+	assert.LessOrEqual(t, peak.Load(), int32(limit))
+}
+
+func TestSemaphore_Contended(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+
+	s, err := NewMemorySemaphore(SetLockRetriesCount(3), SetLockRetryDelay(time.Millisecond), SetLockExpiry(time.Minute))
+	assert.NoError(t, err)
+
+	first, err := s.Acquire(ctx, "claim:coupon:A", 2)
+	assert.NoError(t, err)
+	second, err := s.Acquire(ctx, "claim:coupon:A", 2)
+	assert.NoError(t, err)
+
+	_, err = s.Acquire(ctx, "claim:coupon:A", 2)
+	if assert.Error(t, err) {
+		assert.Equal(t, sharedErrs.ErrKindAcquireRedisLock, err.(sharedErrs.BaseError).Kind())
+	}
+
+	// another key is not counted
+	other, err := s.Acquire(ctx, "claim:coupon:B", 2)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Release(ctx, other))
+
+	// a released permit can be taken again
+	assert.NoError(t, s.Release(ctx, first))
+	third, err := s.Acquire(ctx, "claim:coupon:A", 2)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Release(ctx, second))
+	assert.NoError(t, s.Release(ctx, third))
+
+	_, err = s.Acquire(ctx, "claim:coupon:A", 0)
+	assert.Error(t, err)
+}
+
+func TestSemaphore_Expiry(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	s, err := NewMemorySemaphore(SetLockRetriesCount(1), SetLockRetryDelay(time.Millisecond), SetLockExpiry(time.Second))
+	assert.NoError(t, err)
+	clock := func() time.Time { return now }
+	s.(*semaphore).now = clock
+	s.(*semaphore).store.(*memoryPermitStore).now = clock
+
+	// the permit of a crashed holder is never released
+	_, err = s.Acquire(ctx, "claim:coupon:A", 1)
+	assert.NoError(t, err)
+
+	_, err = s.Acquire(ctx, "claim:coupon:A", 1)
+	assert.Error(t, err)
+
+	// until it expires, and is taken by the next holder
+	now = now.Add(time.Second)
+
+	err = s.WithPermit(ctx, "claim:coupon:A", 1, func(ctx context.Context) error {
+		now = now.Add(time.Second)
+		return nil
+	})
+
+	assert.Equal(t, ErrLockLeaseLost, err)
+}
+
+func TestSemaphore_AutoExtend(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+
+	s, err := NewMemorySemaphore(SetLockRetriesCount(1), SetLockRetryDelay(time.Millisecond),
+		SetLockExpiry(30*time.Millisecond), SetLockAutoExtend())
+	assert.NoError(t, err)
+
+	err = s.WithPermit(ctx, "claim:coupon:A", 1, func(ctx context.Context) error {
+		time.Sleep(100 * time.Millisecond)
+
+		return ctx.Err()
+	})
+
+	assert.NoError(t, err)
+}
+
+func TestSemaphore_Cancelled(t *testing.T) {
+	logger.Initialise()
+
+	s, err := NewMemorySemaphore(SetLockRetriesCount(100), SetLockRetryDelay(time.Second), SetLockExpiry(time.Minute))
+	assert.NoError(t, err)
+
+	err = s.WithPermit(context.Background(), "claim:coupon:A", 1, func(ctx context.Context) error {
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := s.Acquire(waitCtx, "claim:coupon:A", 1)
+
+		// the waiter stops at the deadline rather than after its retries
+		assert.Less(t, time.Since(start), time.Second)
+		if assert.Error(t, err) {
+			assert.Equal(t, sharedErrs.ErrKindLockCancelled, err.(sharedErrs.BaseError).Kind())
+		}
+
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestRedisSemaphore_Scripts(t *testing.T) {
+	logger.Initialise()
+
+	ctx := context.Background()
+	server, keyspace := newKeyspaceServer(t)
+
+	s, err := newRedisSemaphore(ctx, newKeyspacePool(server),
+		SetLockRetriesCount(1), SetLockRetryDelay(time.Millisecond), SetLockExpiry(time.Second))
+	assert.NoError(t, err)
+	// the permits expire by the clock of redis, whatever the clock of the holder says
+	s.(*semaphore).now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	first, err := s.Acquire(ctx, "claim:coupon:A", 2)
+	assert.NoError(t, err)
+	second, err := s.Acquire(ctx, "claim:coupon:A", 2)
+	assert.NoError(t, err)
+
+	_, err = s.Acquire(ctx, "claim:coupon:A", 2)
+	assert.Error(t, err)
+
+	// the second permit is renewed, while the first one expires
+	keyspace.advance(500 * time.Millisecond)
+	extended, err := s.(*semaphore).store.extend(ctx, second.Key, second.ID, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, extended)
+
+	keyspace.advance(500 * time.Millisecond)
+	third, err := s.Acquire(ctx, "claim:coupon:A", 2)
+	assert.NoError(t, err)
+
+	_, err = s.Acquire(ctx, "claim:coupon:A", 2)
+	assert.Error(t, err)
+
+	assert.Equal(t, ErrLockLeaseLost, s.Release(ctx, first))
+	assert.NoError(t, s.Release(ctx, second))
+	assert.NoError(t, s.Release(ctx, third))
+
+	// the set of permits expires with its last permit
+	_, err = s.Acquire(ctx, "claim:coupon:A", 2)
+	assert.NoError(t, err)
+	keyspace.advance(time.Second)
+	assert.Equal(t, int64(0), keyspace.handle([]string{"EXISTS", Key(semaphoreKeyPrefix + "claim:coupon:A")}))
+}
@@ -0,0 +1,297 @@
+package redis
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	uConfig "coupon_be/util/config"
+	"coupon_be/util/logger"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	switchMasterChannel = "+switch-master"
+
+	sentinelRetryDelay = time.Second
+)
+
+// errStaleMaster discards the pooled connections to a master which is replaced by a failover.
+var errStaleMaster = errors.New("connection is to a master replaced by a failover")
+
+// sentinelConfig - Config of the sentinels monitoring the redis master
+type sentinelConfig struct {
+	MasterName string
+	Addresses  []string
+	Password   string
+}
+
+// sentinel discovers the address of the master monitored by the sentinels, and follows its failovers. Every failover
+// starts a new generation, and the connections of the previous generations are discarded by the pool.
+type sentinel struct {
+	config      *sentinelConfig
+	dialOptions []redis.DialOption
+
+	mu         sync.Mutex
+	master     string
+	generation uint64
+
+	stop    context.CancelFunc
+	stopped chan struct{}
+}
+
+// sentinelConn is a connection to the master of a generation.
+type sentinelConn struct {
+	redis.Conn
+	generation uint64
+}
+
+// sentinelConfigOf returns the config of the sentinels, nil when the master is not discovered by sentinels.
+func sentinelConfigOf(c uConfig.RedisSentinelConfig) *sentinelConfig {
+	if c.MasterName == "" {
+		return nil
+	}
+
+	return &sentinelConfig{MasterName: c.MasterName, Addresses: c.Addresses, Password: c.Password}
+}
+
+func newSentinel(config *sentinelConfig, dialOptions ...redis.DialOption) *sentinel {
+	return &sentinel{config: config, dialOptions: dialOptions}
+}
+
+// dial connects to the current master with dialMaster, discovering it first when it is not known. A master which
+// can not be reached, or is no master anymore, is discovered again.
+func (s *sentinel) dial(dialMaster func(address string) (redis.Conn, error)) (redis.Conn, error) {
+	address, generation, err := s.currentMaster()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dialMaster(address)
+	if err == nil {
+		if err = checkMaster(conn); err == nil {
+			return &sentinelConn{Conn: conn, generation: generation}, nil
+		}
+
+		conn.Close()
+	}
+
+	// the master is possibly failed over, and the failover not noticed yet
+	logger.Warn(context.Background(), "Redis master %s of %s is unavailable, discovering it again: %v",
+		address, s.config.MasterName, err)
+	s.forget(address)
+
+	return nil, err
+}
+
+// current reports whether conn is connected to the current master.
+func (s *sentinel) current(conn redis.Conn) bool {
+	c, ok := conn.(*sentinelConn)
+	if !ok {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return c.generation == s.generation
+}
+
+func (s *sentinel) currentMaster() (string, uint64, error) {
+	s.mu.Lock()
+	address, generation := s.master, s.generation
+	s.mu.Unlock()
+
+	if address != "" {
+		return address, generation, nil
+	}
+
+	address, err := s.discover()
+	if err != nil {
+		return "", 0, err
+	}
+
+	return s.setMaster(address)
+}
+
+// discover asks the sentinels for the address of the master, in turn until one answers.
+func (s *sentinel) discover() (string, error) {
+	var errs []error
+	for _, sentinelAddress := range s.config.Addresses {
+		address, err := s.askMaster(sentinelAddress)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sentinelAddress, err))
+			continue
+		}
+
+		return address, nil
+	}
+
+	return "", sharedErrs.NewWithCause(sharedErrs.ErrKindRedis,
+		fmt.Sprintf("No sentinel knows the redis master %s", s.config.MasterName), errors.Join(errs...))
+}
+
+func (s *sentinel) askMaster(sentinelAddress string) (string, error) {
+	conn, err := s.dialSentinel(sentinelAddress)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	master, err := redis.Strings(conn.Do("SENTINEL", "get-master-addr-by-name", s.config.MasterName))
+	if err != nil {
+		return "", err
+	}
+	if len(master) != 2 {
+		return "", fmt.Errorf("unexpected master address %v", master)
+	}
+
+	return net.JoinHostPort(master[0], master[1]), nil
+}
+
+// setMaster records the address of the master, starting a new generation when it has changed.
+func (s *sentinel) setMaster(address string) (string, uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.master != address {
+		if s.master != "" {
+			logger.Warn(context.Background(), "Redis master %s failed over from %s to %s", s.config.MasterName, s.master, address)
+		} else {
+			logger.Info(context.Background(), "Redis master %s discovered at %s", s.config.MasterName, address)
+		}
+
+		s.master = address
+		s.generation++
+	}
+
+	return s.master, s.generation, nil
+}
+
+// forget drops the address of the master, unless it is replaced already, so the next dial discovers it again.
+func (s *sentinel) forget(address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.master == address {
+		s.master = ""
+	}
+}
+
+// watch follows the failovers announced by the sentinels until close is called, discovering the master again
+// whenever it subscribes, so a failover announced while no sentinel was listened to is not missed.
+func (s *sentinel) watch() {
+	if len(s.config.Addresses) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+	s.stopped = make(chan struct{})
+
+	go func() {
+		defer close(s.stopped)
+
+		for i := 0; ctx.Err() == nil; i++ {
+			sentinelAddress := s.config.Addresses[i%len(s.config.Addresses)]
+			if err := s.listen(ctx, sentinelAddress); err != nil && ctx.Err() == nil {
+				logger.Warn(ctx, "Lost sentinel %s, failovers of %s are not followed until another answers: %v",
+					sentinelAddress, s.config.MasterName, err)
+
+				select {
+				case <-ctx.Done():
+				case <-time.After(sentinelRetryDelay):
+				}
+			}
+		}
+	}()
+}
+
+// listen follows the failovers announced by a sentinel, until the connection is lost or ctx is done.
+func (s *sentinel) listen(ctx context.Context, sentinelAddress string) error {
+	conn, err := s.dialSentinel(sentinelAddress)
+	if err != nil {
+		return err
+	}
+
+	psc := redis.PubSubConn{Conn: conn}
+	defer psc.Close()
+
+	if err = psc.Subscribe(switchMasterChannel); err != nil {
+		return err
+	}
+
+	// unblock the receive below once ctx is done
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		switch msg := psc.ReceiveWithTimeout(0).(type) {
+		case redis.Message:
+			// <master name> <old ip> <old port> <new ip> <new port>
+			fields := strings.Fields(string(msg.Data))
+			if len(fields) == 5 && fields[0] == s.config.MasterName {
+				s.setMaster(net.JoinHostPort(fields[3], fields[4]))
+			}
+		case redis.Subscription:
+			if msg.Count == 0 {
+				return nil
+			}
+
+			if address, err := s.askMaster(sentinelAddress); err == nil {
+				s.setMaster(address)
+			}
+		case error:
+			return msg
+		}
+	}
+}
+
+func (s *sentinel) dialSentinel(address string) (redis.Conn, error) {
+	options := s.dialOptions
+	if s.config.Password != "" {
+		options = append(options[:len(options):len(options)], redis.DialPassword(s.config.Password))
+	}
+
+	return redis.Dial(connectionType, address, options...)
+}
+
+func (s *sentinel) close() {
+	if s.stop != nil {
+		s.stop()
+		<-s.stopped
+	}
+}
+
+// checkMaster fails when conn is not connected to a master, e.g. one demoted by a failover.
+func checkMaster(conn redis.Conn) error {
+	role, err := redis.Values(conn.Do("ROLE"))
+	if err != nil {
+		return err
+	}
+	if len(role) == 0 {
+		return errors.New("empty role")
+	}
+
+	name, err := redis.String(role[0], nil)
+	if err != nil {
+		return err
+	}
+	if name != "master" {
+		return fmt.Errorf("redis is a %s, not a master", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package redis
+
+import (
+	"coupon_be/shared/external/redis/redistest"
+	"coupon_be/util/logger"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testMasterName = "mymaster"
+
+// fakeRedis is a redis stand-in answering ROLE with its role and PING with PONG.
+type fakeRedis struct {
+	*redistest.Server
+
+	mu   sync.Mutex
+	role string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	r := &fakeRedis{role: "master"}
+	r.Server = redistest.NewServer(t, func(args []string) any {
+		switch strings.ToUpper(args[0]) {
+		case "ROLE":
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			return []any{r.role, int64(0), []any{}}
+		case "PING":
+			return redistest.Status("PONG")
+		default:
+			return redistest.Status("OK")
+		}
+	})
+
+	return r
+}
+
+func (r *fakeRedis) demote() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.role = "slave"
+}
+
+// fakeSentinel is a sentinel stand-in answering the address of its current master.
+type fakeSentinel struct {
+	*redistest.Server
+
+	mu     sync.Mutex
+	master *fakeRedis
+}
+
+func newFakeSentinel(t *testing.T, master *fakeRedis) *fakeSentinel {
+	s := &fakeSentinel{master: master}
+	s.Server = redistest.NewServer(t, func(args []string) any {
+		if len(args) != 3 || strings.ToUpper(args[0]) != "SENTINEL" || args[2] != testMasterName {
+			return redistest.Error("ERR unexpected command")
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		host, port := s.master.HostPort()
+		return []any{host, port}
+	})
+
+	return s
+}
+
+// failover switches the master, announcing it on the +switch-master channel when announce is set.
+func (s *fakeSentinel) failover(master *fakeRedis, announce bool) {
+	s.mu.Lock()
+	previous := s.master
+	s.master = master
+	s.mu.Unlock()
+
+	previous.demote()
+
+	if announce {
+		oldHost, oldPort := previous.HostPort()
+		newHost, newPort := master.HostPort()
+		s.Publish(switchMasterChannel, strings.Join([]string{testMasterName, oldHost, oldPort, newHost, newPort}, " "))
+	}
+}
+
+func newSentinelPool(addresses ...string) *Pool {
+	useTLS := false
+
+	return newPool(&config{
+		MaxIdleConnections: 2,
+		UseTLS:             &useTLS,
+		Sentinel:           &sentinelConfig{MasterName: testMasterName, Addresses: addresses},
+	})
+}
+
+// unreachableAddress returns an address nothing listens on.
+func unreachableAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
+
+func countOf(commands []string, name string) int {
+	count := 0
+	for _, command := range commands {
+		if command == name {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestSentinel_DiscoversMaster(t *testing.T) {
+	logger.Initialise()
+
+	master := newFakeRedis(t)
+	sentinel := newFakeSentinel(t, master)
+
+	// an unreachable sentinel is skipped
+	pool := newSentinelPool(unreachableAddress(t), sentinel.Address())
+	defer pool.Close()
+
+	result, err := pool.ping()
+	assert.NoError(t, err)
+	assert.Equal(t, "PONG", result)
+
+	conn := pool.Get()
+	_, err = conn.Do("SET", "key", "value")
+	assert.NoError(t, err)
+	conn.Close()
+
+	assert.Equal(t, 1, countOf(master.Received(), "SET"))
+}
+
+func TestSentinel_Failover(t *testing.T) {
+	logger.Initialise()
+
+	first := newFakeRedis(t)
+	second := newFakeRedis(t)
+	sentinel := newFakeSentinel(t, first)
+
+	pool := newSentinelPool(sentinel.Address())
+	defer pool.Close()
+
+	assert.Eventually(t, func() bool { return sentinel.Subscribed() == 1 }, time.Second, 5*time.Millisecond)
+
+	// leaves an idle connection to the first master in the pool
+	conn := pool.Get()
+	_, err := conn.Do("SET", "key", "value")
+	assert.NoError(t, err)
+	conn.Close()
+
+	sentinel.failover(second, true)
+
+	secondAddress := second.Address()
+	assert.Eventually(t, func() bool {
+		address, _, _ := pool.sentinel.currentMaster()
+		return address == secondAddress
+	}, time.Second, 5*time.Millisecond)
+
+	// the idle connection is discarded, and the next one is to the second master
+	conn = pool.Get()
+	_, err = conn.Do("SET", "key", "value")
+	assert.NoError(t, err)
+	conn.Close()
+
+	assert.Equal(t, 1, countOf(first.Received(), "SET"))
+	assert.Equal(t, 1, countOf(second.Received(), "SET"))
+}
+
+func TestSentinel_UnannouncedFailover(t *testing.T) {
+	logger.Initialise()
+
+	first := newFakeRedis(t)
+	second := newFakeRedis(t)
+	sentinel := newFakeSentinel(t, first)
+
+	pool := newSentinelPool(sentinel.Address())
+	defer pool.Close()
+
+	assert.Eventually(t, func() bool { return sentinel.Subscribed() == 1 }, time.Second, 5*time.Millisecond)
+
+	sentinel.failover(second, false)
+
+	// the demoted master is found out when it is dialled, and the master is discovered again
+	conn := pool.Get()
+	_, err := conn.Do("SET", "key", "value")
+	assert.Error(t, err)
+	conn.Close()
+
+	conn = pool.Get()
+	_, err = conn.Do("SET", "key", "value")
+	assert.NoError(t, err)
+	conn.Close()
+
+	assert.Equal(t, 0, countOf(first.Received(), "SET"))
+	assert.Equal(t, 1, countOf(second.Received(), "SET"))
+}
@@ -0,0 +1,87 @@
+package redis
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/logger"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+//go:generate mockgen -package mock -source=sold_out.go -destination=../../../mock/redis_sold_out.go *
+
+const soldOutKeyPrefix = "sold_out:coupon:"
+
+// ISoldOutMarker - interface for marking the sold-out coupons, so their claims are rejected without reaching postgres
+type ISoldOutMarker interface {
+	// Mark marks the coupon as sold out for ttl.
+	Mark(ctx context.Context, couponName string, ttl time.Duration) error
+	// Clear removes the mark of the coupon, once its stock is restored.
+	Clear(ctx context.Context, couponName string) error
+	// IsMarked reports whether the coupon is marked as sold out.
+	IsMarked(ctx context.Context, couponName string) (bool, error)
+}
+
+// SoldOutMarker - redis implementation of ISoldOutMarker
+type SoldOutMarker struct {
+	pool *Pool
+}
+
+func (s *SoldOutMarker) Mark(ctx context.Context, couponName string, ttl time.Duration) error {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.Do("SET", Key(soldOutKeyPrefix+couponName), 1, "PX", ttl.Milliseconds()); err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to mark coupon as sold out", err)
+	}
+
+	logger.Debug(ctx, "coupon %s is marked as sold out for %v", couponName, ttl)
+
+	return nil
+}
+
+func (s *SoldOutMarker) Clear(ctx context.Context, couponName string) error {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.Do("DEL", Key(soldOutKeyPrefix+couponName)); err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to clear sold out mark", err)
+	}
+
+	logger.Debug(ctx, "sold out mark of coupon %s is cleared", couponName)
+
+	return nil
+}
+
+func (s *SoldOutMarker) IsMarked(ctx context.Context, couponName string) (bool, error) {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return false, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	marked, err := redis.Bool(conn.Do("EXISTS", Key(soldOutKeyPrefix+couponName)))
+	if err != nil {
+		return false, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to read sold out mark", err)
+	}
+
+	return marked, nil
+}
+
+// newSoldOutMarker Provider/Factory function to return a redis sold out marker
+func newSoldOutMarker(ctx context.Context, pool *Pool) (ISoldOutMarker, error) {
+	if pool == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindRedis, "Redis pool is nil")
+	}
+
+	logger.Debug(ctx, "Redis sold out marker initialised successfully")
+
+	return &SoldOutMarker{pool: pool}, nil
+}
@@ -0,0 +1,198 @@
+package redis
+
+import (
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/util/logger"
+	"encoding/json"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+//go:generate mockgen -package mock -source=stock.go -destination=../../../mock/redis_stock.go *
+
+const (
+	stockKeySuffix     = ":stock"
+	claimantsKeySuffix = ":claimants"
+	stockPendingKey    = "stock:pending"
+)
+
+// TakeResult is the outcome of taking one stock from a counter.
+type TakeResult int
+
+const (
+	// TakeNotLoaded means the counter does not exist in redis yet and has to be rebuilt first.
+	TakeNotLoaded TakeResult = -2
+	// TakeDuplicate means the member has already taken a stock from the counter.
+	TakeDuplicate TakeResult = -1
+	// TakeSoldOut means there is no stock left.
+	TakeSoldOut TakeResult = 0
+	// TakeSuccess means one stock is taken, and the take is recorded in the pending list.
+	TakeSuccess TakeResult = 1
+)
+
+// takeScript checks the member is not a claimant yet, decrements the stock, records the member as a claimant and
+// appends the take to the pending list, all in one atomic step.
+//
+// KEYS[1] stock key, KEYS[2] claimants key, KEYS[3] pending list key
+// ARGV[1] member, ARGV[2] pending entry
+var takeScript = redis.NewScript(3, `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -2
+end
+if redis.call('SISMEMBER', KEYS[2], ARGV[1]) == 1 then
+	return -1
+end
+if tonumber(redis.call('GET', KEYS[1])) <= 0 then
+	return 0
+end
+redis.call('DECR', KEYS[1])
+redis.call('SADD', KEYS[2], ARGV[1])
+redis.call('RPUSH', KEYS[3], ARGV[2])
+return 1
+`)
+
+// rebuildScript replaces the stock and claimants of a counter with the given amount and members. Takes of the counter
+// that are still in the pending list are counted as well, as they are not persisted by the caller yet.
+//
+// KEYS[1] stock key, KEYS[2] claimants key, KEYS[3] pending list key
+// ARGV[1] counter key, ARGV[2] amount, ARGV[3...] members
+var rebuildScript = redis.NewScript(3, `
+redis.call('DEL', KEYS[1], KEYS[2])
+local claimed = 0
+for i = 3, #ARGV do
+	claimed = claimed + redis.call('SADD', KEYS[2], ARGV[i])
+end
+for _, raw in ipairs(redis.call('LRANGE', KEYS[3], 0, -1)) do
+	local entry = cjson.decode(raw)
+	if entry.key == ARGV[1] then
+		claimed = claimed + redis.call('SADD', KEYS[2], entry.member)
+	end
+end
+local remaining = tonumber(ARGV[2]) - claimed
+if remaining < 0 then
+	remaining = 0
+end
+redis.call('SET', KEYS[1], remaining)
+return remaining
+`)
+
+// PendingTake is a successful take which is not acknowledged by its consumer yet.
+type PendingTake struct {
+	Key     string `json:"key"`
+	Member  string `json:"member"`
+	TakenAt int64  `json:"taken_at"`
+}
+
+// IStockCounter - interface for atomic stock counters with a set of claimants per counter
+type IStockCounter interface {
+	// Take atomically takes one stock of the counter for the member.
+	Take(ctx context.Context, key, member string) (TakeResult, error)
+	// Rebuild replaces the state of the counter from the source of truth, and returns the remaining stock.
+	Rebuild(ctx context.Context, key string, amount int64, members []string) (int64, error)
+	// Pending returns up to count oldest takes which are not acknowledged yet.
+	Pending(ctx context.Context, count int) ([]*PendingTake, error)
+	// Ack removes the count oldest takes from the pending list.
+	Ack(ctx context.Context, count int) error
+}
+
+// StockCounter - redis implementation of IStockCounter using lua scripts
+type StockCounter struct {
+	pool *Pool
+}
+
+func (s *StockCounter) Take(ctx context.Context, key, member string) (TakeResult, error) {
+	entry, err := json.Marshal(&PendingTake{Key: key, Member: member, TakenAt: time.Now().UnixMilli()})
+	if err != nil {
+		return TakeNotLoaded, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to encode pending take", err)
+	}
+
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return TakeNotLoaded, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	result, err := redis.Int(takeScript.Do(conn, Key(key+stockKeySuffix), Key(key+claimantsKeySuffix), Key(stockPendingKey), member, entry))
+	if err != nil {
+		return TakeNotLoaded, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to take stock", err)
+	}
+
+	logger.Debug(ctx, "take stock %s for member %s result: %d", key, member, result)
+
+	return TakeResult(result), nil
+}
+
+func (s *StockCounter) Rebuild(ctx context.Context, key string, amount int64, members []string) (int64, error) {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return 0, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	args := []any{Key(key + stockKeySuffix), Key(key + claimantsKeySuffix), Key(stockPendingKey), key, amount}
+	for _, member := range members {
+		args = append(args, member)
+	}
+
+	remaining, err := redis.Int64(rebuildScript.Do(conn, args...))
+	if err != nil {
+		return 0, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to rebuild stock", err)
+	}
+
+	logger.Info(ctx, "stock %s is rebuilt with %d remaining", key, remaining)
+
+	return remaining, nil
+}
+
+func (s *StockCounter) Pending(ctx context.Context, count int) ([]*PendingTake, error) {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	entries, err := redis.ByteSlices(conn.Do("LRANGE", Key(stockPendingKey), 0, count-1))
+	if err != nil {
+		return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to read pending takes", err)
+	}
+
+	result := make([]*PendingTake, len(entries))
+	for i, entry := range entries {
+		if err = json.Unmarshal(entry, &result[i]); err != nil {
+			return nil, sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to decode pending take", err)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *StockCounter) Ack(ctx context.Context, count int) error {
+	if count <= 0 {
+		return nil
+	}
+
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to get redis connection", err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.Do("LTRIM", Key(stockPendingKey), count, -1); err != nil {
+		return sharedErrs.NewWithCause(sharedErrs.ErrKindRedis, "failed to acknowledge pending takes", err)
+	}
+
+	return nil
+}
+
+// newStockCounter Provider/Factory function to return a redis stock counter
+func newStockCounter(ctx context.Context, pool *Pool) (IStockCounter, error) {
+	if pool == nil {
+		return nil, sharedErrs.New(sharedErrs.ErrKindRedis, "Redis pool is nil")
+	}
+
+	logger.Debug(ctx, "Redis stock counter initialised successfully")
+
+	return &StockCounter{pool: pool}, nil
+}
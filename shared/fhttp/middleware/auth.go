@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"coupon_be/shared/auth"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/fhttp"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"net/http"
+	"strings"
+)
+
+const (
+	authorizationKey = "Authorization"
+	bearerPrefix     = "Bearer "
+)
+
+// Authenticate - Middleware to verify the bearer access token of the "Authorization" header, and put the id, username
+// and role of its user into the request context. The requests without the header go through unauthenticated, and are
+// left to RequirePermission to reject, while a malformed, invalid or expired token is rejected with 401 Unauthorized.
+func Authenticate(tokens auth.ITokenManager) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			header := r.Header.Get(authorizationKey)
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := strings.CutPrefix(header, bearerPrefix)
+			if !ok || strings.TrimSpace(token) == "" {
+				fhttp.WriteErrorResponse(ctx, sharedErrs.UnauthorizedErr, w)
+				return
+			}
+
+			claims, err := tokens.Parse(ctx, strings.TrimSpace(token), auth.TokenTypeAccess)
+			if err != nil {
+				fhttp.WriteErrorResponse(ctx, err, w)
+				return
+			}
+
+			userID, err := claims.UserID()
+			if err != nil || userID == 0 {
+				logger.Warn(ctx, "access token of %s has an invalid subject %q", claims.Username, claims.Subject)
+				fhttp.WriteErrorResponse(ctx, sharedErrs.InvalidTokenErr, w)
+				return
+			}
+
+			ctx = context.WithValue(ctx, constant.XUserIDKey, userID)
+			ctx = context.WithValue(ctx, constant.XUsernameKey, claims.Username)
+			ctx = context.WithValue(ctx, constant.XRoleKey, claims.Role.String())
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequirePermission - Middleware to let through the requests of the authenticated users whose role is granted the
+// permission. The unauthenticated requests are rejected with 401 Unauthorized, and the others with 403 Forbidden.
+func RequirePermission(permission auth.Permission) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			if constant.UserIDFromCtx(ctx) == 0 {
+				fhttp.WriteErrorResponse(ctx, sharedErrs.UnauthorizedErr, w)
+				return
+			}
+
+			if !auth.Can(ctx, permission) {
+				logger.Warn(ctx, "user %s of role %q is denied %s %s, which requires %s",
+					constant.UsernameFromCtx(ctx), constant.RoleFromCtx(ctx), r.Method, r.URL.Path, permission)
+				fhttp.WriteErrorResponse(ctx, sharedErrs.ForbiddenErr, w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Authorized returns the handler of a route requiring the permission, e.g.
+//
+//	r.Handle("", middleware.Authorized(auth.PermissionManageCoupons, c.Store)).Methods(http.MethodPost)
+func Authorized(permission auth.Permission, handler fhttp.AppHandler) http.Handler {
+	return RequirePermission(permission)(handler)
+}
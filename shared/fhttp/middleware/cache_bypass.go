@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"coupon_be/shared/auth"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"net/http"
+	"strconv"
+)
+
+// CacheBypass - Middleware to read around the cache for the requests with a truthy "Cache-Bypass" header, for debugging
+// the cached responses against the database. It runs after Authenticate, and ignores the header of the users not
+// granted auth.PermissionBypassCache, since every bypassing read goes to the database.
+func CacheBypass(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		bypass, err := strconv.ParseBool(r.Header.Get(constant.XCacheBypassKey))
+		if err != nil || !bypass {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !auth.Can(ctx, auth.PermissionBypassCache) {
+			logger.Warn(ctx, "ignoring the cache bypass of user %q of role %q",
+				constant.UsernameFromCtx(ctx), constant.RoleFromCtx(ctx))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, constant.XCacheBypassKey, true)))
+	})
+}
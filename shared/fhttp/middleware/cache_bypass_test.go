@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheBypass(t *testing.T) {
+	logger.Initialise()
+
+	tokens, alice, bob := newTestTokens(t)
+
+	testCases := []struct {
+		name           string
+		authorization  string
+		bypass         string
+		expectedBypass bool
+	}{
+		{
+			name:           "admin bypassing",
+			authorization:  "Bearer " + alice.AccessToken,
+			bypass:         "true",
+			expectedBypass: true,
+		},
+		{
+			name:          "admin without the header",
+			authorization: "Bearer " + alice.AccessToken,
+		},
+		{
+			name:          "admin with a falsy header",
+			authorization: "Bearer " + alice.AccessToken,
+			bypass:        "false",
+		},
+		{
+			name:          "customer bypassing",
+			authorization: "Bearer " + bob.AccessToken,
+			bypass:        "true",
+		},
+		{
+			name:   "anonymous bypassing",
+			bypass: "true",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var bypass bool
+			handler := Authenticate(tokens)(CacheBypass(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				bypass = constant.CacheBypassFromCtx(r.Context())
+			})))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/coupons/1", nil)
+			if tc.authorization != "" {
+				req.Header.Set(authorizationKey, tc.authorization)
+			}
+			if tc.bypass != "" {
+				req.Header.Set(constant.XCacheBypassKey, tc.bypass)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tc.expectedBypass, bypass)
+		})
+	}
+}
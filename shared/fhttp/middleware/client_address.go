@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"coupon_be/util/constant"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const forwardedForKey = "X-Forwarded-For"
+
+// ClientAddress - Middleware to put the address of the client into the request context. The X-Forwarded-For header is
+// only read from the trusted proxies, since any client can send it: the client is the last address of the header
+// which is not a trusted proxy itself. The peer address of the connection is the client otherwise.
+func ClientAddress(trustedProxies []*net.IPNet) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			address := clientAddressOf(r, trustedProxies)
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), constant.XIPAddressKey, address)))
+		})
+	}
+}
+
+func clientAddressOf(r *http.Request, trustedProxies []*net.IPNet) string {
+	address := peerAddressOf(r)
+	if !isTrustedProxy(address, trustedProxies) {
+		return address
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values(forwardedForKey), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if hop == "" {
+			continue
+		}
+
+		address = hop
+		if !isTrustedProxy(hop, trustedProxies) {
+			break
+		}
+	}
+
+	return address
+}
+
+// peerAddressOf returns the host of the address the request is received from.
+func peerAddressOf(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func isTrustedProxy(address string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseTrustedProxies parses the trusted proxies given as IP addresses or CIDR ranges.
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	result := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, network)
+	}
+
+	return result, nil
+}
+
+// callerOf identifies the client of the request by its address.
+func callerOf(r *http.Request) string {
+	if address, ok := r.Context().Value(constant.XIPAddressKey).(string); ok && address != "" {
+		return address
+	}
+
+	return peerAddressOf(r)
+}
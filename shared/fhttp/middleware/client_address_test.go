@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientAddress(t *testing.T) {
+	trustedProxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name            string
+		remoteAddr      string
+		forwardedFor    []string
+		expectedAddress string
+	}{
+		{
+			name:            "direct client",
+			remoteAddr:      "203.0.113.7:1234",
+			expectedAddress: "203.0.113.7",
+		},
+		{
+			name:            "forwarded header of an untrusted client is ignored",
+			remoteAddr:      "203.0.113.7:1234",
+			forwardedFor:    []string{"198.51.100.1"},
+			expectedAddress: "203.0.113.7",
+		},
+		{
+			name:            "forwarded by a trusted proxy",
+			remoteAddr:      "10.0.0.5:1234",
+			forwardedFor:    []string{"198.51.100.1"},
+			expectedAddress: "198.51.100.1",
+		},
+		{
+			name:            "spoofed addresses before the trusted proxies are ignored",
+			remoteAddr:      "10.0.0.5:1234",
+			forwardedFor:    []string{"1.1.1.1, 198.51.100.1", "192.168.1.1"},
+			expectedAddress: "198.51.100.1",
+		},
+		{
+			name:            "trusted proxy without a forwarded header",
+			remoteAddr:      "192.168.1.1:1234",
+			expectedAddress: "192.168.1.1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var address string
+			handler := ClientAddress(trustedProxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				address = callerOf(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/coupons", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for _, value := range tc.forwardedFor {
+				req.Header.Add(forwardedForKey, value)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tc.expectedAddress, address)
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	_, err := ParseTrustedProxies([]string{"not-an-address"})
+	assert.Error(t, err)
+
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.1", "::1", "172.16.0.0/12"})
+	assert.NoError(t, err)
+	assert.Len(t, proxies, 3)
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/redis"
+	"coupon_be/shared/fhttp"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	idempotentReplayedKey   = "Idempotent-Replayed"
+)
+
+// Idempotency - Middleware to replay the first response of a mutating request for the repeats with the same
+// Idempotency-Key header. Keys are scoped by route and caller, and the responses expire after ttl. A key in flight
+// expires after inFlightTTL, so a request which never completes, e.g. on a crash, does not hold its key for the ttl.
+func Idempotency(store redis.IIdempotencyStore, ttl, inFlightTTL time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(constant.XIdempotencyKey)
+			if key == "" || !isMutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), constant.XIdempotencyKey, key)
+
+			if len(key) > maxIdempotencyKeyLength {
+				fhttp.WriteErrorResponse(ctx, fhttp.NewErrorResponse(
+					http.StatusBadRequest,
+					sharedErrs.ErrKindValidation.String(),
+					"Idempotency-Key must not be longer than 255 characters"), w)
+				return
+			}
+
+			var body []byte
+			if r.Body != nil {
+				var err error
+				if body, err = io.ReadAll(r.Body); err != nil {
+					fhttp.WriteErrorResponse(ctx, sharedErrs.NewWithCause(sharedErrs.ErrKindInvalidRequest, "Failed to read request body", err), w)
+					return
+				}
+				r.Body.Close()
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			storeKey := hashOf(idempotencyScopeOf(r), r.Method, r.URL.Path, key)
+			fingerprint := hashOf(string(body))
+
+			record, err := store.Reserve(ctx, storeKey, fingerprint, inFlightTTL)
+			if err != nil {
+				logger.Error(ctx, "failed to reserve idempotency key %s: %v", key, err)
+				fhttp.WriteErrorResponse(ctx, err, w)
+				return
+			}
+
+			if record != nil {
+				replay(ctx, w, key, fingerprint, record)
+				return
+			}
+
+			rec := &loggingResponseWriter{ResponseWriter: w}
+			serveReleasingOnPanic(ctx, store, storeKey, next, rec, r.WithContext(ctx))
+
+			status := rec.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			// server errors are not stored, so the request can be retried with the same key
+			if status >= http.StatusInternalServerError {
+				if err = store.Release(ctx, storeKey); err != nil {
+					logger.Error(ctx, "failed to release idempotency key %s: %v", key, err)
+				}
+				return
+			}
+
+			err = store.Complete(ctx, storeKey, &redis.IdempotentRecord{
+				Fingerprint: fingerprint,
+				Status:      status,
+				Headers:     map[string]string{fhttp.ContentTypeKey: rec.Header().Get(fhttp.ContentTypeKey)},
+				Body:        rec.Body,
+			}, ttl)
+			if err != nil {
+				logger.Error(ctx, "failed to store response of idempotency key %s: %v", key, err)
+			}
+		})
+	}
+}
+
+// serveReleasingOnPanic serves the request, and releases its idempotency key before passing a panic on to
+// PanicRecovery, so the request can be retried with the key.
+func serveReleasingOnPanic(ctx context.Context, store redis.IIdempotencyStore, storeKey string, next http.Handler,
+	w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if p := recover(); p != nil {
+			if err := store.Release(ctx, storeKey); err != nil {
+				logger.Error(ctx, "failed to release idempotency key %s: %v", ctx.Value(constant.XIdempotencyKey), err)
+			}
+			panic(p)
+		}
+	}()
+
+	next.ServeHTTP(w, r)
+}
+
+func replay(ctx context.Context, w http.ResponseWriter, key, fingerprint string, record *redis.IdempotentRecord) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		fhttp.WriteErrorResponse(ctx, fhttp.NewErrorResponse(
+			http.StatusUnprocessableEntity,
+			sharedErrs.ErrKindValidation.String(),
+			"Idempotency-Key is already used with a different request body"), w)
+	case !record.Completed:
+		fhttp.WriteErrorResponse(ctx, fhttp.NewErrorResponse(
+			http.StatusConflict,
+			sharedErrs.ErrKindConflict.String(),
+			"A request with the same Idempotency-Key is still in progress"), w)
+	default:
+		logger.Info(ctx, "replaying the response of idempotency key %s", key)
+
+		for header, value := range record.Headers {
+			w.Header().Set(header, value)
+		}
+		w.Header().Set(idempotentReplayedKey, "true")
+		w.WriteHeader(record.Status)
+		if _, err := w.Write(record.Body); err != nil {
+			logger.Error(ctx, "Error writing http response: %v", err)
+		}
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// idempotencyScopeOf scopes the idempotency keys by the authenticated user, or by the client address of the
+// anonymous requests.
+func idempotencyScopeOf(r *http.Request) string {
+	if userID := constant.UserIDFromCtx(r.Context()); userID != 0 {
+		return fmt.Sprintf("user:%d", userID)
+	}
+
+	return "ip:" + callerOf(r)
+}
+
+func hashOf(parts ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(hash[:])
+}
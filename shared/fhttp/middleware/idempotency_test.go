@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"context"
+	m "coupon_be/mock"
+	"coupon_be/shared/external/redis"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIdempotency(t *testing.T) {
+	logger.Initialise()
+
+	body := `{"coupon_name":"COUPON_TEST","user_id":"user_123"}`
+	fingerprint := hashOf(body)
+
+	testCases := []struct {
+		name           string
+		method         string
+		key            string
+		body           string
+		prepareMock    func(store *m.MockIIdempotencyStore)
+		expectedStatus int
+		expectedBody   string
+		expectedCalls  int
+		replayed       bool
+	}{
+		{
+			name:   "first request",
+			method: http.MethodPost,
+			key:    "key-1",
+			body:   body,
+			prepareMock: func(store *m.MockIIdempotencyStore) {
+				store.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Eq(fingerprint), gomock.Eq(time.Minute)).
+					Return(nil, nil).
+					Times(1)
+				store.EXPECT().Complete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq(time.Hour)).
+					DoAndReturn(func(_ any, _ string, record *redis.IdempotentRecord, _ time.Duration) error {
+						assert.Equal(t, http.StatusCreated, record.Status)
+						assert.Equal(t, "created", string(record.Body))
+						return nil
+					}).
+					Times(1)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "created",
+			expectedCalls:  1,
+		},
+		{
+			name:   "repeated request is replayed",
+			method: http.MethodPost,
+			key:    "key-1",
+			body:   body,
+			prepareMock: func(store *m.MockIIdempotencyStore) {
+				store.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Eq(fingerprint), gomock.Any()).
+					Return(&redis.IdempotentRecord{
+						Fingerprint: fingerprint,
+						Completed:   true,
+						Status:      http.StatusCreated,
+						Body:        []byte("created"),
+					}, nil).
+					Times(1)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "created",
+			replayed:       true,
+		},
+		{
+			name:   "first request still in flight",
+			method: http.MethodPost,
+			key:    "key-1",
+			body:   body,
+			prepareMock: func(store *m.MockIIdempotencyStore) {
+				store.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Eq(fingerprint), gomock.Any()).
+					Return(&redis.IdempotentRecord{Fingerprint: fingerprint}, nil).
+					Times(1)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "different body under the same key",
+			method: http.MethodPost,
+			key:    "key-1",
+			body:   `{"coupon_name":"COUPON_OTHER","user_id":"user_123"}`,
+			prepareMock: func(store *m.MockIIdempotencyStore) {
+				store.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&redis.IdempotentRecord{Fingerprint: fingerprint, Completed: true}, nil).
+					Times(1)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "server error releases the key",
+			method: http.MethodPut,
+			key:    "key-2",
+			body:   "fail",
+			prepareMock: func(store *m.MockIIdempotencyStore) {
+				store.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, nil).
+					Times(1)
+				store.EXPECT().Release(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				store.EXPECT().Complete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "failed",
+			expectedCalls:  1,
+		},
+		{
+			name:   "panic releases the key",
+			method: http.MethodPost,
+			key:    "key-4",
+			body:   "panic",
+			prepareMock: func(store *m.MockIIdempotencyStore) {
+				store.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, nil).
+					Times(1)
+				store.EXPECT().Release(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				store.EXPECT().Complete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCalls:  1,
+		},
+		{
+			name:           "without key",
+			method:         http.MethodPost,
+			body:           body,
+			prepareMock:    func(store *m.MockIIdempotencyStore) {},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "created",
+			expectedCalls:  1,
+		},
+		{
+			name:           "not mutating",
+			method:         http.MethodGet,
+			key:            "key-3",
+			prepareMock:    func(store *m.MockIIdempotencyStore) {},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "created",
+			expectedCalls:  1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := m.NewMockIIdempotencyStore(ctrl)
+			tc.prepareMock(store)
+
+			calls := 0
+			// PanicRecovery is the outer middleware, as in the router
+			idempotency := Idempotency(store, time.Hour, time.Minute)
+			handler := PanicRecovery()(idempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+
+				data, _ := io.ReadAll(r.Body)
+				if string(data) == "panic" {
+					panic("claim failed")
+				}
+				if string(data) == "fail" {
+					w.WriteHeader(http.StatusInternalServerError)
+					_, _ = w.Write([]byte("failed"))
+					return
+				}
+
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("created"))
+			})))
+
+			req := httptest.NewRequest(tc.method, "/api/coupons/claim", strings.NewReader(tc.body))
+			if tc.key != "" {
+				req.Header.Set("Idempotency-Key", tc.key)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedCalls, calls)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, rec.Body.String())
+			}
+			if tc.replayed {
+				assert.Equal(t, "true", rec.Header().Get(idempotentReplayedKey))
+			}
+		})
+	}
+}
+
+func TestIdempotency_ScopedByUser(t *testing.T) {
+	logger.Initialise()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var storeKeys []string
+	store := m.NewMockIIdempotencyStore(ctrl)
+	store.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ any, key, _ string, _ time.Duration) (*redis.IdempotentRecord, error) {
+			storeKeys = append(storeKeys, key)
+			return nil, nil
+		}).
+		AnyTimes()
+	store.EXPECT().Complete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	handler := Idempotency(store, time.Hour, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	send := func(userID uint64, forwardedFor string) {
+		req := httptest.NewRequest(http.MethodPost, "/api/coupons/claim", strings.NewReader("{}"))
+		req.Header.Set("Idempotency-Key", "key-1")
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		if userID != 0 {
+			req = req.WithContext(context.WithValue(req.Context(), constant.XUserIDKey, userID))
+		}
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	send(1, "10.0.0.1")
+	send(1, "10.0.0.2")
+	send(2, "10.0.0.1")
+
+	assert.Len(t, storeKeys, 3)
+	assert.Equal(t, storeKeys[0], storeKeys[1], "the key of a user does not depend on the client address")
+	assert.NotEqual(t, storeKeys[0], storeKeys[2], "the same key of another user is another key")
+}
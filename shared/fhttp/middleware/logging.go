@@ -7,8 +7,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
 )
 
+// secretFields matches the JSON string fields whose values are never logged.
+var secretFields = regexp.MustCompile(`"(password|access_token|refresh_token)"\s*:\s*"(?:[^"\\]|\\.)*"`)
+
+// redactSecrets masks the values of the secret fields of a JSON body.
+func redactSecrets(body []byte) string {
+	return secretFields.ReplaceAllString(string(body), `"$1":"[REDACTED]"`)
+}
+
 func LogRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/healthcheck" {
@@ -28,7 +37,7 @@ func LogRequest(next http.Handler) http.Handler {
 		if err != nil && err != io.EOF {
 			logger.Error(ctx, "Error reading request body: %v", err)
 		} else {
-			logger.Info(ctx, "Received Request from URL: %v, with body: %v", r.URL.Path, string(body))
+			logger.Info(ctx, "Received Request from URL: %v, with body: %v", r.URL.Path, redactSecrets(body))
 		}
 		r.Body.Close()
 
@@ -52,7 +61,7 @@ func LogResponse(h http.Handler) http.Handler {
 		h.ServeHTTP(rec, r)
 
 		statusCode := rec.Status
-		logMsg := fmt.Sprintf("Received HTTP Status Code: %d, with Response: %s", statusCode, string(rec.Body))
+		logMsg := fmt.Sprintf("Received HTTP Status Code: %d, with Response: %s", statusCode, redactSecrets(rec.Body))
 
 		switch {
 		case statusCode >= 500:
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "password",
+			body:     `{"username": "user_1", "password": "secret \"quoted\" pass"}`,
+			expected: `{"username": "user_1", "password":"[REDACTED]"}`,
+		},
+		{
+			name:     "tokens",
+			body:     `{"data":{"access_token":"a.b.c","refresh_token":"d.e.f","token_type":"Bearer"}}`,
+			expected: `{"data":{"access_token":"[REDACTED]","refresh_token":"[REDACTED]","token_type":"Bearer"}}`,
+		},
+		{
+			name:     "no secret",
+			body:     `{"user_id": "user_1", "coupon_name": "COUPON_A"}`,
+			expected: `{"user_id": "user_1", "coupon_name": "COUPON_A"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, redactSecrets([]byte(tc.body)))
+		})
+	}
+}
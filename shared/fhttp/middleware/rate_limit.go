@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"bytes"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/ratelimit"
+	"coupon_be/shared/fhttp"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// RateLimitKey is what the requests of a rate limit rule are counted by.
+type RateLimitKey string
+
+const (
+	// RateLimitKeyIP counts the requests of every client address.
+	RateLimitKeyIP RateLimitKey = "ip"
+	// RateLimitKeyUser counts the requests of every authenticated user.
+	RateLimitKeyUser RateLimitKey = "user"
+	// RateLimitKeyClaimUser counts the claims of every user, by the user_id of the request body. It is chosen by the
+	// client, so it is meant to be combined with an ip rule.
+	RateLimitKeyClaimUser RateLimitKey = "claim_user"
+)
+
+const (
+	rateLimitLimitKey     = "RateLimit-Limit"
+	rateLimitRemainingKey = "RateLimit-Remaining"
+	rateLimitResetKey     = "RateLimit-Reset"
+	retryAfterKey         = "Retry-After"
+)
+
+// RateLimitRule limits the requests of a route to Limit per sliding Window, counted by Key. Path is the path template
+// of the route, e.g. /api/coupons/{coupon_name}, and an empty Method matches every method.
+type RateLimitRule struct {
+	Method string
+	Path   string
+	Key    RateLimitKey
+	Limit  int
+	Window time.Duration
+}
+
+func (r *RateLimitRule) matches(method, path string) bool {
+	return (r.Method == "" || r.Method == method) && r.Path == path
+}
+
+// RateLimit - Middleware to reject the requests beyond the limit of a matching rule with 429 Too Many Requests.
+// The requests a user or claim_user key can not be resolved for, i.e. the anonymous ones and the bodies without a
+// user_id, are counted by the client address instead.
+// The limiter failing lets the requests through.
+func RateLimit(limiter ratelimit.ILimiter, rules []*RateLimitRule) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			path := r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					path = template
+				}
+			}
+
+			// the result closest to its limit is the one reported
+			var reported *ratelimit.Result
+
+			for _, rule := range rules {
+				if !rule.matches(r.Method, path) {
+					continue
+				}
+
+				key, err := rateLimitKeyOf(r, rule.Key)
+				if err != nil {
+					fhttp.WriteErrorResponse(ctx, err, w)
+					return
+				}
+
+				result, err := limiter.Allow(ctx, fmt.Sprintf("%s %s:%s", rule.Method, rule.Path, key), rule.Limit, rule.Window)
+				if err != nil {
+					logger.Error(ctx, "failed to rate limit %s %s by %s, letting the request through: %v", r.Method, path, key, err)
+					continue
+				}
+
+				if !result.Allowed {
+					logger.Warn(ctx, "rate limit of %d per %v on %s %s is exceeded by %s", rule.Limit, rule.Window, r.Method, path, key)
+
+					setRateLimitHeaders(w, result)
+					w.Header().Set(retryAfterKey, strconv.Itoa(ceilSeconds(result.Reset)))
+					fhttp.WriteErrorResponse(ctx, fhttp.NewErrorResponse(
+						http.StatusTooManyRequests,
+						sharedErrs.ErrKindRateLimited.String(),
+						"Too many requests, please try again later"), w)
+					return
+				}
+
+				if reported == nil || result.Remaining < reported.Remaining {
+					reported = result
+				}
+			}
+
+			if reported != nil {
+				setRateLimitHeaders(w, reported)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitKeyOf returns the value the requests are counted by, prefixed with the key type.
+func rateLimitKeyOf(r *http.Request, key RateLimitKey) (string, error) {
+	switch key {
+	case RateLimitKeyUser:
+		if userID := constant.UserIDFromCtx(r.Context()); userID != 0 {
+			return fmt.Sprintf("%s:%d", key, userID), nil
+		}
+	case RateLimitKeyClaimUser:
+		userID, err := claimUserOf(r)
+		if err != nil {
+			return "", err
+		}
+		if userID != "" {
+			return fmt.Sprintf("%s:%s", key, userID), nil
+		}
+	}
+
+	return fmt.Sprintf("%s:%s", RateLimitKeyIP, callerOf(r)), nil
+}
+
+// claimUserOf reads the user_id of the JSON body, leaving the body to be read again by the handler.
+func claimUserOf(r *http.Request) (string, error) {
+	if r.Body == nil {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", sharedErrs.NewWithCause(sharedErrs.ErrKindInvalidRequest, "Failed to read request body", err)
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var input struct {
+		UserID string `json:"user_id"`
+	}
+	if err = json.Unmarshal(body, &input); err != nil {
+		// left to the handler to reject
+		return "", nil
+	}
+
+	return input.UserID, nil
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, result *ratelimit.Result) {
+	w.Header().Set(rateLimitLimitKey, strconv.Itoa(result.Limit))
+	w.Header().Set(rateLimitRemainingKey, strconv.Itoa(max(result.Remaining, 0)))
+	w.Header().Set(rateLimitResetKey, strconv.Itoa(ceilSeconds(result.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	m "coupon_be/mock"
+	sharedErrs "coupon_be/shared/errors"
+	"coupon_be/shared/external/ratelimit"
+	"coupon_be/util/constant"
+	"coupon_be/util/logger"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRateLimit(t *testing.T) {
+	logger.Initialise()
+
+	type request struct {
+		path           string
+		remoteAddr     string
+		username       string
+		body           string
+		expectedStatus int
+		unlimited      bool
+	}
+
+	testCases := []struct {
+		name     string
+		rules    []*RateLimitRule
+		requests []request
+	}{
+		{
+			name: "limited by client address",
+			rules: []*RateLimitRule{
+				{Method: http.MethodPost, Path: "/api/coupons/claim", Key: RateLimitKeyIP, Limit: 2, Window: time.Minute},
+			},
+			requests: []request{
+				{path: "/api/coupons/claim", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusOK},
+				{path: "/api/coupons/claim", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusOK},
+				{path: "/api/coupons/claim", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusTooManyRequests},
+				{path: "/api/coupons/claim", remoteAddr: "10.0.0.2:1234", expectedStatus: http.StatusOK},
+			},
+		},
+		{
+			name: "limited by the user claimed for",
+			rules: []*RateLimitRule{
+				{Method: http.MethodPost, Path: "/api/coupons/claim", Key: RateLimitKeyClaimUser, Limit: 1, Window: time.Minute},
+			},
+			requests: []request{
+				{path: "/api/coupons/claim", remoteAddr: "10.0.0.1:1234", username: "alice", body: `{"user_id":"alice"}`, expectedStatus: http.StatusOK},
+				{path: "/api/coupons/claim", remoteAddr: "10.0.0.2:1234", username: "bob", body: `{"user_id":"alice"}`, expectedStatus: http.StatusTooManyRequests},
+				{path: "/api/coupons/claim", remoteAddr: "10.0.0.1:1234", username: "alice", body: `{"user_id":"carol"}`, expectedStatus: http.StatusOK},
+				{path: "/api/coupons/claim", remoteAddr: "10.0.0.3:1234", body: `{"coupon_name":"COUPON_A"}`, expectedStatus: http.StatusOK},
+				{path: "/api/coupons/claim", remoteAddr: "10.0.0.3:1234", body: `not json`, expectedStatus: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name: "limited by path template",
+			rules: []*RateLimitRule{
+				{Path: "/api/coupons/{coupon_name}/grants", Key: RateLimitKeyIP, Limit: 1, Window: time.Minute},
+			},
+			requests: []request{
+				{path: "/api/coupons/COUPON_A/grants", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusOK},
+				{path: "/api/coupons/COUPON_B/grants", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusTooManyRequests},
+				{path: "/api/coupons/claim", remoteAddr: "10.0.0.1:1234", expectedStatus: http.StatusOK, unlimited: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newRateLimitedRouter(ratelimit.NewMemoryLimiter(), tc.rules)
+
+			for i, r := range tc.requests {
+				req := httptest.NewRequest(http.MethodPost, r.path, strings.NewReader(r.body))
+				req.RemoteAddr = r.remoteAddr
+				if r.username != "" {
+					req = req.WithContext(context.WithValue(req.Context(), constant.XUsernameKey, r.username))
+				}
+				rec := httptest.NewRecorder()
+
+				router.ServeHTTP(rec, req)
+
+				assert.Equal(t, r.expectedStatus, rec.Code, "request %d", i)
+				if r.unlimited {
+					assert.Empty(t, rec.Header().Get(rateLimitLimitKey), "request %d", i)
+				} else {
+					assert.NotEmpty(t, rec.Header().Get(rateLimitLimitKey), "request %d", i)
+				}
+				if r.expectedStatus == http.StatusTooManyRequests {
+					assert.Equal(t, "0", rec.Header().Get(rateLimitRemainingKey))
+					assert.Equal(t, "60", rec.Header().Get(retryAfterKey))
+				} else {
+					assert.Equal(t, r.body, rec.Body.String(), "request %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimit_LimiterFailure(t *testing.T) {
+	logger.Initialise()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	limiter := m.NewMockILimiter(ctrl)
+	limiter.EXPECT().Allow(gomock.Any(), gomock.Any(), gomock.Eq(1), gomock.Eq(time.Minute)).
+		Return(nil, sharedErrs.New(sharedErrs.ErrKindRedis, "redis is down")).
+		Times(1)
+
+	router := newRateLimitedRouter(limiter, []*RateLimitRule{
+		{Method: http.MethodPost, Path: "/api/coupons/claim", Key: RateLimitKeyIP, Limit: 1, Window: time.Minute},
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/coupons/claim", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+// newRateLimitedRouter routes the coupon claims and grants to a handler echoing the request body.
+func newRateLimitedRouter(limiter ratelimit.ILimiter, rules []*RateLimitRule) *mux.Router {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	})
+
+	router := mux.NewRouter()
+	router.Use(RateLimit(limiter, rules))
+
+	coupons := router.PathPrefix("/api").Subrouter().PathPrefix("/coupons").Subrouter()
+	coupons.Handle("/claim", echo).Methods(http.MethodPost)
+	coupons.Handle("/{coupon_name}/grants", echo).Methods(http.MethodPost)
+
+	return router
+}
@@ -3,34 +3,214 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
-// RequestBody Request payload structure
+const stressUserPassword = "user1234"
+
+// RequestBody Request payload structure, claimed as the user of the access token
 type RequestBody struct {
-	UserID     string `json:"user_id"`
 	CouponName string `json:"coupon_name"`
 }
 
+// RegisterBody Register user payload structure
+type RegisterBody struct {
+	Username string `json:"username"`
+	FullName string `json:"full_name"`
+	Password string `json:"password"`
+}
+
+// LoginBody Login payload structure
+type LoginBody struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// CouponBody Create coupon payload structure
+type CouponBody struct {
+	Name          string `json:"name"`
+	Amount        uint64 `json:"amount"`
+	ClaimStrategy string `json:"claim_strategy,omitempty"`
+}
+
+// Result holds the outcome of a single stress test run
+type Result struct {
+	Strategy        string
+	CouponName      string
+	SuccessCount    int64
+	FailureCount    int64
+	TotalDurationNs int64
+	Elapsed         time.Duration
+}
+
 func main() {
 	var (
-		successCount    int64
-		failureCount    int64
-		totalDurationNs int64
-		url             = "http://localhost:9000"
-		totalRequests   = 100
+		url           = flag.String("url", "http://localhost:9000", "base url of the coupon api")
+		totalRequests = flag.Int("requests", 100, "number of concurrent claim requests per run")
+		couponName    = flag.String("coupon", "COUPON_TEST", "coupon to claim, used when no strategies are given")
+		amount        = flag.Uint64("amount", 5, "stock of the coupons created for each strategy")
+		strategies    = flag.String("strategies", "", "comma separated claim strategies to compare, e.g. redis_lock,row_lock,optimistic")
+		verbose       = flag.Bool("verbose", true, "print the outcome of every request")
+		adminUsername = flag.String("admin-username", "admin", "username of the admin or operator creating the coupons")
+		adminPassword = flag.String("admin-password", "admin1234", "password of the admin or operator creating the coupons")
 	)
+	flag.Parse()
 
 	client := &http.Client{Timeout: 5 * time.Second}
 
+	tokens, err := loginUsers(client, *url, *totalRequests)
+	if err != nil {
+		fmt.Println("Failed to log in users:", err)
+		return
+	}
+
+	var results []*Result
+	if *strategies == "" {
+		results = append(results, run(client, *url, "", *couponName, tokens, *verbose))
+	} else {
+		adminToken, err := login(client, *url, *adminUsername, *adminPassword)
+		if err != nil {
+			fmt.Println("Failed to log in admin:", err)
+			return
+		}
+
+		suffix := time.Now().Unix()
+		for _, strategy := range strings.Split(*strategies, ",") {
+			strategy = strings.TrimSpace(strategy)
+			name := fmt.Sprintf("STRESS_%s_%d", strings.ToUpper(strategy), suffix)
+
+			if err := createCoupon(client, *url, adminToken, &CouponBody{Name: name, Amount: *amount, ClaimStrategy: strategy}); err != nil {
+				fmt.Printf("[STRATEGY=%s] Failed to create coupon %s: %v\n", strategy, name, err)
+				continue
+			}
+
+			results = append(results, run(client, *url, strategy, name, tokens, *verbose))
+		}
+	}
+
+	fmt.Println("====== Results ======")
+	for _, result := range results {
+		if result.Strategy != "" {
+			fmt.Printf("Strategy: %s (coupon %s)\n", result.Strategy, result.CouponName)
+		}
+		fmt.Printf("Total Requests: %d\n", *totalRequests)
+		fmt.Printf("Success: %d\n", result.SuccessCount)
+		fmt.Printf("Failed: %d\n", result.FailureCount)
+		fmt.Printf("Average Response Time: %s\n", time.Duration(result.TotalDurationNs/int64(*totalRequests)).String())
+		fmt.Printf("Total Elapsed Time: %s\n", result.Elapsed.String())
+		fmt.Println("---------------------")
+	}
+}
+
+// loginUsers registers user_0 .. user_{total-1}, keeping the users registered by a previous run as is, and returns
+// their access tokens
+func loginUsers(client *http.Client, url string, total int) ([]string, error) {
+	tokens := make([]string, total)
+	for i := 0; i < total; i++ {
+		username := fmt.Sprintf("user_%d", i)
+		bodyBytes, err := json.Marshal(RegisterBody{
+			Username: username,
+			FullName: fmt.Sprintf("User %d", i),
+			Password: stressUserPassword,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Post(fmt.Sprintf("%s/api/users/register", url), "application/json", bytes.NewBuffer(bodyBytes))
+		if err != nil {
+			return nil, err
+		}
+		respBodyBytes, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		// 400 means the username is already taken
+		if resp.StatusCode >= 400 && resp.StatusCode != http.StatusBadRequest {
+			return nil, fmt.Errorf("[USER=%s] status %d | response : %s", username, resp.StatusCode, string(respBodyBytes))
+		}
+
+		if tokens[i], err = login(client, url, username, stressUserPassword); err != nil {
+			return nil, fmt.Errorf("[USER=%s] %w", username, err)
+		}
+	}
+
+	return tokens, nil
+}
+
+func login(client *http.Client, url, username, password string) (string, error) {
+	bodyBytes, err := json.Marshal(LoginBody{Username: username, Password: password})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Post(fmt.Sprintf("%s/api/users/login", url), "application/json", bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("status %d | response : %s", resp.StatusCode, string(respBodyBytes))
+	}
+
+	var body struct {
+		Data struct {
+			AccessToken string `json:"access_token"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(respBodyBytes, &body); err != nil {
+		return "", err
+	}
+
+	return body.Data.AccessToken, nil
+}
+
+func createCoupon(client *http.Client, url, token string, coupon *CouponBody) error {
+	bodyBytes, err := json.Marshal(coupon)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/api/coupons", url), bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respBodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d | response : %s", resp.StatusCode, string(respBodyBytes))
+	}
+
+	return nil
+}
+
+func run(client *http.Client, url, strategy, couponName string, tokens []string, verbose bool) *Result {
+	totalRequests := len(tokens)
+	result := &Result{Strategy: strategy, CouponName: couponName}
+
 	var wg sync.WaitGroup
 	wg.Add(totalRequests)
 
+	start := time.Now()
 	for i := 0; i < totalRequests; i++ {
 		go func() {
 			defer wg.Done()
@@ -38,15 +218,11 @@ func main() {
 			startTime := time.Now()
 
 			userID := fmt.Sprintf("user_%d", i)
-			couponName := "COUPON_TEST"
 
-			bodyBytes, err := json.Marshal(RequestBody{
-				UserID:     userID,
-				CouponName: couponName,
-			})
+			bodyBytes, err := json.Marshal(RequestBody{CouponName: couponName})
 			if err != nil {
 				fmt.Println("JSON marshal error:", err)
-				atomic.AddInt64(&failureCount, 1)
+				atomic.AddInt64(&result.FailureCount, 1)
 				return
 			}
 
@@ -54,48 +230,51 @@ func main() {
 			req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(bodyBytes))
 			if err != nil {
 				fmt.Println("Request creation error:", err)
-				atomic.AddInt64(&failureCount, 1)
+				atomic.AddInt64(&result.FailureCount, 1)
 				return
 			}
 
 			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tokens[i])
 
 			resp, err := client.Do(req)
 			if err != nil {
 				fmt.Println("HTTP error:", err)
-				atomic.AddInt64(&failureCount, 1)
+				atomic.AddInt64(&result.FailureCount, 1)
 				return
 			}
+			defer resp.Body.Close()
 
 			respBodyBytes, err := io.ReadAll(resp.Body)
 			if err != nil {
 				fmt.Printf("[USER=%s] Failed to read response body: %v\n", userID, err)
-				atomic.AddInt64(&failureCount, 1)
+				atomic.AddInt64(&result.FailureCount, 1)
 				return
 			}
 
 			duration := time.Since(startTime)
 
-			defer resp.Body.Close()
-
 			if resp.StatusCode >= 400 {
-				atomic.AddInt64(&failureCount, 1)
-				fmt.Printf("[USER=%s] FAILED | Status %d | Response : %s \n", userID, resp.StatusCode, string(respBodyBytes))
+				atomic.AddInt64(&result.FailureCount, 1)
+				if verbose {
+					fmt.Printf("[USER=%s] FAILED | Status %d | Response : %s \n", userID, resp.StatusCode, string(respBodyBytes))
+				}
 			} else if resp.StatusCode >= 200 {
-				atomic.AddInt64(&successCount, 1)
-				fmt.Printf("[USER=%s] SUCCESS | Status  %d\n", userID, resp.StatusCode)
+				atomic.AddInt64(&result.SuccessCount, 1)
+				if verbose {
+					fmt.Printf("[USER=%s] SUCCESS | Status  %d\n", userID, resp.StatusCode)
+				}
 			}
 
-			fmt.Printf("[USER=%s] Request duration : %s\n", userID, duration.String())
-			atomic.AddInt64(&totalDurationNs, duration.Nanoseconds())
+			if verbose {
+				fmt.Printf("[USER=%s] Request duration : %s\n", userID, duration.String())
+			}
+			atomic.AddInt64(&result.TotalDurationNs, duration.Nanoseconds())
 		}()
 	}
 
 	wg.Wait()
+	result.Elapsed = time.Since(start)
 
-	fmt.Println("====== Results ======")
-	fmt.Printf("Total Requests: %d\n", totalRequests)
-	fmt.Printf("Success: %d\n", successCount)
-	fmt.Printf("Failed: %d\n", failureCount)
-	fmt.Printf("Average Response Time: %s\n", time.Duration(totalDurationNs/int64(totalRequests)).String())
+	return result
 }
@@ -12,10 +12,18 @@ var c *Config
 
 type (
 	Config struct {
-		App      AppConfig      `env:"app"`
-		Database DatabaseConfig `env:"database"`
-		Context  ContextConfig  `env:"context"`
-		Redis    RedisConfig    `env:"redis"`
+		App         AppConfig         `env:"app"`
+		Database    DatabaseConfig    `env:"database"`
+		Context     ContextConfig     `env:"context"`
+		Redis       RedisConfig       `env:"redis"`
+		Lock        LockConfig        `env:"lock"`
+		Coupon      CouponConfig      `env:"coupon"`
+		Queue       QueueConfig       `env:"queue"`
+		Idempotency IdempotencyConfig `env:"idempotency"`
+		RateLimit   RateLimitConfig   `env:"rate_limit"`
+		Cache       CacheConfig       `env:"cache"`
+		Auth        AuthConfig        `env:"auth"`
+		JWTSecret   string            `env:"jwt_secret"`
 	}
 
 	AppConfig struct {
@@ -24,6 +32,8 @@ type (
 		Version   string `env:"version"`
 		Port      int32  `env:"port"`
 		APIPrefix string `env:"api_prefix"`
+		// TrustedProxies are the addresses or CIDR ranges of the proxies whose X-Forwarded-For header is trusted.
+		TrustedProxies []string `env:"trusted_proxies"`
 	}
 
 	DatabaseConfig struct {
@@ -46,6 +56,116 @@ type (
 		MaxActiveConnections int    `envconfig:"max_active_connections"`
 		IdleTimeout          int    `envconfig:"idle_timeout"`
 		UseTLS               bool   `envconfig:"use_tls"`
+
+		// LockNodes are the independent redis nodes the distributed lock reaches a quorum on. The lock uses the
+		// redis above when it is empty.
+		LockNodes      []RedisNodeConfig `env:"lock_nodes"`
+		LockStrictMode bool              `env:"lock_strict_mode"`
+
+		// LockBreakerThreshold is the number of consecutive unavailable lock acquisitions which open the circuit
+		// breaker of the lock, LockBreakerCooldown is how long it stays open before a trial acquisition.
+		LockBreakerThreshold int    `env:"lock_breaker_threshold"`
+		LockBreakerCooldown  string `env:"lock_breaker_cooldown"`
+
+		// Sentinel discovers the master instead of Host and Port, and follows its failovers, when its MasterName is set.
+		Sentinel RedisSentinelConfig `env:"sentinel"`
+
+		// Namespace prefixes every redis key and channel when it is set, the keys are not namespaced by default.
+		Namespace RedisNamespaceConfig `env:"namespace"`
+	}
+
+	RedisNamespaceConfig struct {
+		App    string `env:"app"`
+		Env    string `env:"env"`
+		Tenant string `env:"tenant"`
+	}
+
+	// RedisSentinelConfig are the sentinels monitoring a redis master, by their host:port Addresses.
+	RedisSentinelConfig struct {
+		MasterName string   `env:"master_name"`
+		Addresses  []string `env:"addresses"`
+		Password   string   `env:"password"`
+	}
+
+	// LockConfig selects the backend of the claim locks, redis by default. The memory backend excludes the holders of
+	// a single instance only.
+	LockConfig struct {
+		Backend string `env:"backend"`
+	}
+
+	RedisNodeConfig struct {
+		Host     string              `env:"host"`
+		Port     string              `env:"port"`
+		Password string              `env:"password"`
+		UseTLS   bool                `env:"use_tls"`
+		Sentinel RedisSentinelConfig `env:"sentinel"`
+	}
+
+	CouponConfig struct {
+		ClaimStrategy        string `env:"claim_strategy"`
+		OptimisticMaxRetries int    `env:"optimistic_max_retries"`
+		ClaimWriterInterval  string `env:"claim_writer_interval"`
+		ClaimWriterBatchSize int    `env:"claim_writer_batch_size"`
+		ClaimMode            string `env:"claim_mode"`
+		ReconcileInterval    string `env:"reconcile_interval"`
+		ReconcileAutoCorrect bool   `env:"reconcile_auto_correct"`
+		MaxTransfers         int    `env:"max_transfers"`
+		LockUnavailableMode  string `env:"lock_unavailable_mode"`
+		SoldOutTTL           string `env:"sold_out_ttl"`
+	}
+
+	QueueConfig struct {
+		Backend     string `env:"backend"`
+		Partitions  int    `env:"partitions"`
+		Capacity    int    `env:"capacity"`
+		MaxAttempts int    `env:"max_attempts"`
+		RetryDelay  string `env:"retry_delay"`
+		ClaimIdle   string `env:"claim_idle"`
+	}
+
+	IdempotencyConfig struct {
+		TTL         string `env:"ttl"`
+		InFlightTTL string `env:"in_flight_ttl"`
+	}
+
+	// CacheConfig configures the read-through cache of the coupon detail and listing. LocalTTL keeps the values on
+	// every instance as well when it is set, invalidated through the pub/sub Channel.
+	CacheConfig struct {
+		Enabled   bool   `env:"enabled"`
+		Codec     string `env:"codec"`
+		DetailTTL string `env:"detail_ttl"`
+		ListTTL   string `env:"list_ttl"`
+		LocalTTL  string `env:"local_ttl"`
+		Channel   string `env:"channel"`
+	}
+
+	// AuthConfig are the lifetimes of the access and refresh tokens issued on login, and the first admin.
+	AuthConfig struct {
+		AccessTokenExpiration  string          `env:"access_token_expiration"`
+		RefreshTokenExpiration string          `env:"refresh_token_expiration"`
+		Admin                  AuthAdminConfig `env:"admin"`
+	}
+
+	// AuthAdminConfig is the user made the first admin at startup while there is no admin, created when it does not
+	// exist.
+	AuthAdminConfig struct {
+		Username string `env:"username"`
+		FullName string `env:"full_name"`
+		Password string `env:"password"`
+	}
+
+	RateLimitConfig struct {
+		Backend string                `env:"backend"`
+		Rules   []RateLimitRuleConfig `env:"rules"`
+	}
+
+	// RateLimitRuleConfig limits the requests of a route to Limit per Window, for every key of the given type.
+	RateLimitRuleConfig struct {
+		Method string `env:"method"`
+		Path   string `env:"path"`
+		Key    string `env:"key"`
+		Limit  int    `env:"limit"`
+		Window string `env:"window"`
 	}
 )
 
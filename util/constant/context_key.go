@@ -14,11 +14,20 @@ const (
 	correlationIDKey = contextKey("Correlation-ID")
 	idempotencyKey   = contextKey("Idempotency-Key")
 	ipAddressKey     = contextKey("ip-address")
+	userIDKey        = contextKey("user-id")
+	usernameKey      = contextKey("username")
+	roleKey          = contextKey("role")
+	cacheBypassKey   = contextKey("Cache-Bypass")
 )
 
 var (
 	XCorrelationIDKey = correlationIDKey.String()
+	XIdempotencyKey   = idempotencyKey.String()
 	XIPAddressKey     = ipAddressKey.String()
+	XUserIDKey        = userIDKey.String()
+	XUsernameKey      = usernameKey.String()
+	XRoleKey          = roleKey.String()
+	XCacheBypassKey   = cacheBypassKey.String()
 )
 
 func CorrelationIDFromCtx(ctx context.Context) string {
@@ -29,3 +38,49 @@ func CorrelationIDFromCtx(ctx context.Context) string {
 
 	return correlationID
 }
+
+func IdempotencyKeyFromCtx(ctx context.Context) string {
+	key, ok := ctx.Value(XIdempotencyKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return key
+}
+
+// UserIDFromCtx returns the id of the authenticated user, or zero when the request is not authenticated.
+func UserIDFromCtx(ctx context.Context) uint64 {
+	userID, ok := ctx.Value(XUserIDKey).(uint64)
+	if !ok {
+		return 0
+	}
+
+	return userID
+}
+
+// UsernameFromCtx returns the username of the authenticated user, or empty when the request is not authenticated.
+func UsernameFromCtx(ctx context.Context) string {
+	username, ok := ctx.Value(XUsernameKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return username
+}
+
+// RoleFromCtx returns the role of the authenticated user, or empty when the request is not authenticated.
+func RoleFromCtx(ctx context.Context) string {
+	role, ok := ctx.Value(XRoleKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return role
+}
+
+// CacheBypassFromCtx reports whether the request reads around the cache.
+func CacheBypassFromCtx(ctx context.Context) bool {
+	bypass, _ := ctx.Value(XCacheBypassKey).(bool)
+
+	return bypass
+}